@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
@@ -9,8 +10,10 @@ import (
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"payment-reconciliation-engine/backend/internal/auth"
 	"payment-reconciliation-engine/backend/internal/db"
 	"payment-reconciliation-engine/backend/internal/handlers"
+	"payment-reconciliation-engine/backend/internal/webhooks"
 )
 
 func main() {
@@ -21,6 +24,23 @@ func main() {
 	}
 	defer database.Close()
 
+	// Webhook delivery - draws from every handler below that emits through
+	// webhookEmitter (ConfirmMatch/ManualMatch/BulkConfirm/UndoMatch today).
+	webhookEmitter := webhooks.NewEmitter()
+	deliveryWorker := webhooks.NewDeliveryWorker(database, webhookEmitter)
+	deliveryCtx, cancelDelivery := context.WithCancel(context.Background())
+	defer cancelDelivery()
+	go deliveryWorker.Start(deliveryCtx)
+
+	// Replays the reconciliation_events rows ActionsHandler writes inside its
+	// own transactions - the durable path for confirm/reject/manual-match/
+	// mark-external events, independent of whether webhookEmitter's in-memory
+	// channel happened to be drained before a crash.
+	outboxDispatcher := webhooks.NewOutboxDispatcher(database, deliveryWorker)
+	outboxCtx, cancelOutbox := context.WithCancel(context.Background())
+	defer cancelOutbox()
+	go outboxDispatcher.Start(outboxCtx)
+
 	// Get upload directory
 	uploadDir := os.Getenv("UPLOAD_DIR")
 	if uploadDir == "" {
@@ -63,11 +83,25 @@ func main() {
 	transactionsHandler := handlers.NewTransactionsHandler(database)
 	invoicesHandler := handlers.NewInvoicesHandler(database)
 	transactionDetailHandler := handlers.NewTransactionDetailHandler(database)
-	actionsHandler := handlers.NewActionsHandler(database)
+	actionsHandler := handlers.NewActionsHandler(database, webhookEmitter)
+	feedbackHandler := handlers.NewFeedbackHandler(database)
+	explainHandler := handlers.NewExplainHandler(database)
+	reportsHandler := handlers.NewReportsHandler(database)
+	adminJobsHandler := handlers.NewAdminJobsHandler(database)
+	wsHandler := handlers.NewWSHandler()
+	webhooksHandler := handlers.NewWebhooksHandler(database)
+	tusUploadHandler := handlers.NewTusUploadHandler(database, uploadHandler, uploadDir)
+	ledgerHandler := handlers.NewLedgerHandler(database)
 
 	// Routes
 	e.POST("/api/reconciliation/upload", uploadHandler.Upload)
+	e.POST("/api/reconciliation/uploads", tusUploadHandler.Create)
+	e.HEAD("/api/reconciliation/uploads/:id", tusUploadHandler.Head)
+	e.PATCH("/api/reconciliation/uploads/:id", tusUploadHandler.Patch)
+	e.DELETE("/api/reconciliation/uploads/:id", tusUploadHandler.Delete)
 	e.GET("/api/reconciliation/:batchId", batchHandler.GetBatch)
+	e.GET("/api/reconciliation/:batchId/stream", batchHandler.StreamBatch)
+	e.GET("/ws/reconciliation/:batchId", wsHandler.StreamReconciliation)
 	e.GET("/api/reconciliation/:batchId/transactions", transactionsHandler.ListTransactions)
 	
 	// Debug: List all routes
@@ -81,12 +115,31 @@ func main() {
 	e.GET("/api/invoices/search", invoicesHandler.SearchInvoices)
 	e.GET("/api/transactions/:id", transactionDetailHandler.GetTransaction)
 	
-	// Action endpoints
-	e.POST("/api/transactions/:id/confirm", actionsHandler.ConfirmMatch)
-	e.POST("/api/transactions/:id/reject", actionsHandler.RejectMatch)
-	e.POST("/api/transactions/:id/match", actionsHandler.ManualMatch)
-	e.POST("/api/transactions/:id/external", actionsHandler.MarkExternal)
-	e.POST("/api/transactions/bulk-confirm", actionsHandler.BulkConfirm)
+	// Action endpoints - gated by role, on top of the JWT/session auth
+	// every one of them requires before ActionsHandler ever sees the
+	// request. Reviewers can confirm/reject/manually match; bulk-confirming
+	// an entire batch at once needs admin.
+	authMiddleware := auth.Middleware(os.Getenv("AUTH_JWT_SECRET"))
+	e.POST("/api/transactions/:id/confirm", actionsHandler.ConfirmMatch, authMiddleware, auth.RequireRole(auth.RoleReviewer))
+	e.POST("/api/transactions/:id/reject", actionsHandler.RejectMatch, authMiddleware, auth.RequireRole(auth.RoleReviewer))
+	e.POST("/api/transactions/:id/match", actionsHandler.ManualMatch, authMiddleware, auth.RequireRole(auth.RoleReviewer))
+	e.POST("/api/transactions/:id/external", actionsHandler.MarkExternal, authMiddleware, auth.RequireRole(auth.RoleReviewer))
+	e.POST("/api/transactions/bulk-confirm", actionsHandler.BulkConfirm, authMiddleware, auth.RequireRole(auth.RoleAdmin))
+	e.POST("/api/transactions/:id/undo", actionsHandler.UndoMatch, authMiddleware, auth.RequireRole(auth.RoleReviewer))
+	e.POST("/api/transactions/:id/feedback", feedbackHandler.PostFeedback)
+	e.GET("/api/transactions/:id/explain", explainHandler.GetExplanation)
+	e.GET("/api/reports/reconciliation-summary", reportsHandler.GetReconciliationSummary)
+	e.GET("/admin/jobs", adminJobsHandler.ListJobs)
+	e.POST("/admin/jobs/:id/requeue", adminJobsHandler.RequeueJob)
+	e.POST("/api/webhooks", webhooksHandler.CreateWebhook)
+	e.GET("/api/webhooks", webhooksHandler.ListWebhooks)
+	e.DELETE("/api/webhooks/:id", webhooksHandler.DeleteWebhook)
+	e.GET("/api/webhooks/:id/deliveries", webhooksHandler.ListDeliveries)
+	e.POST("/api/webhooks/deliveries/:id/replay", webhooksHandler.ReplayDelivery)
+	e.GET("/api/invoices/:id/ledger", ledgerHandler.GetInvoicePostings)
+	e.GET("/api/transactions/:id/ledger", ledgerHandler.GetTransactionPostings)
+	e.GET("/api/reconciliation/:batchId/trial-balance", ledgerHandler.GetBatchTrialBalance)
+	e.POST("/api/reconciliation/:batchId/recount", batchHandler.RecountBatch, authMiddleware, auth.RequireRole(auth.RoleAdmin))
 
 	// Start server
 	port := os.Getenv("PORT")
@@ -0,0 +1,32 @@
+// Command recalibrate runs processor/learning's offline weight-fitting job:
+// it reads every labeled match_feedback row and fits a new ScoringWeights,
+// which ProcessJob picks up as the active scoring config on its next run
+// (see learning.LoadLatestWeights). Meant to be run periodically (a cron
+// job) or manually by an operator after a batch of reviews has accumulated
+// - not on every request, since a few hundred gradient descent iterations
+// over the whole feedback table isn't cheap.
+package main
+
+import (
+	"context"
+	"log"
+
+	recondb "payment-reconciliation-engine/backend/internal/db"
+	"payment-reconciliation-engine/backend/internal/processor/learning"
+)
+
+func main() {
+	database, err := recondb.Connect()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer database.Close()
+
+	weights, err := learning.RecalibrateWeights(context.Background(), database)
+	if err != nil {
+		log.Fatalf("Recalibration failed: %v", err)
+	}
+
+	log.Printf("Fitted scoring weights version %d from %d labeled examples: name=%.4f date=%.4f ambiguity=%.4f",
+		weights.Version, weights.TrainingExamples, weights.NameWeight, weights.DateWeight, weights.AmbiguityWeight)
+}
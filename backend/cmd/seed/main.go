@@ -5,13 +5,18 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"math"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync/atomic"
+	"syscall"
 	"time"
 
-	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
+	"github.com/cheggaaa/pb/v3"
+	recondb "payment-reconciliation-engine/backend/internal/db"
 )
 
 type Invoice struct {
@@ -19,17 +24,56 @@ type Invoice struct {
 	CustomerName  string     `db:"customer_name"`
 	CustomerEmail *string    `db:"customer_email"`
 	Amount        string     `db:"amount"`
+	UnitPrice     string     `db:"unit_price"`
+	Quantity      int        `db:"quantity"`
+	VATRateBps    int        `db:"vat_rate_bps"`
+	TotalNet      string     `db:"total_net"`
+	TotalGross    string     `db:"total_gross"`
+	Currency      string     `db:"currency"`
 	Status        string     `db:"status"`
 	DueDate       time.Time  `db:"due_date"`
 	PaidAt        *time.Time `db:"paid_at"`
 	CreatedAt     time.Time  `db:"created_at"`
 }
 
+// calculateInvoiceData computes an invoice's net and gross totals from its
+// unit price, quantity, and VAT rate (basis points, e.g. 2000 = 20%), so
+// invoices seeded as net-of-VAT line items and invoices seeded as flat
+// totals (quantity=1, vatRateBps=0) both end up with consistent net/gross
+// figures. Both totals are rounded to 2dp since they're stored as
+// fixed-point currency values.
+func calculateInvoiceData(unitPrice float64, quantity int, vatRateBps int) (totalNet, totalGross float64) {
+	totalNet = round2(unitPrice * float64(quantity))
+	totalGross = round2(totalNet * (1 + float64(vatRateBps)/10000.0))
+	return totalNet, totalGross
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
 func main() {
 	var csvFile string
+	var silent, noProgress bool
 	flag.StringVar(&csvFile, "file", "", "Path to invoices CSV file (default: ../../seed/data/invoices.csv)")
+	flag.BoolVar(&silent, "silent", false, "Suppress all output except the final summary")
+	flag.BoolVar(&noProgress, "no-progress", false, "Disable the progress bar (useful for CI logs)")
 	flag.Parse()
 
+	if silent {
+		noProgress = true
+		if devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0); err == nil {
+			log.SetOutput(devNull)
+		}
+	}
+
+	// printf is like fmt.Printf but respects --silent.
+	printf := func(format string, args ...interface{}) {
+		if !silent {
+			fmt.Printf(format, args...)
+		}
+	}
+
 	if csvFile == "" {
 		// Default to seed/data/invoices.csv relative to repo root
 		// When running from backend/cmd/seed, go up 3 levels to repo root
@@ -56,7 +100,8 @@ func main() {
 	}
 
 	// Connect to database
-	db, err := sqlx.Connect("postgres", dbURL)
+	dialect := recondb.DetectDialect(dbURL)
+	db, err := recondb.Connect()
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
@@ -116,11 +161,45 @@ func main() {
 			customerEmail = &record[3]
 		}
 
+		unitPrice, err := strconv.ParseFloat(record[4], 64)
+		if err != nil {
+			log.Printf("Skipping row %d: invalid amount: %v", i+1, err)
+			continue
+		}
+
+		// vat_rate_bps, quantity, and currency are optional trailing columns
+		// so existing flat-total CSVs (no VAT breakdown) keep working: they
+		// parse as a single net=gross line item at quantity 1.
+		quantity := 1
+		vatRateBps := 0
+		currency := "GBP"
+		if len(record) > 9 && record[9] != "" {
+			if parsed, err := strconv.Atoi(record[9]); err == nil {
+				vatRateBps = parsed
+			}
+		}
+		if len(record) > 10 && record[10] != "" {
+			if parsed, err := strconv.Atoi(record[10]); err == nil && parsed > 0 {
+				quantity = parsed
+			}
+		}
+		if len(record) > 11 && record[11] != "" {
+			currency = record[11]
+		}
+
+		totalNet, totalGross := calculateInvoiceData(unitPrice, quantity, vatRateBps)
+
 		invoice := Invoice{
 			InvoiceNumber: record[1],
 			CustomerName:  record[2],
 			CustomerEmail: customerEmail,
-			Amount:        record[4],
+			Amount:        fmt.Sprintf("%.2f", totalGross),
+			UnitPrice:     fmt.Sprintf("%.2f", unitPrice),
+			Quantity:      quantity,
+			VATRateBps:    vatRateBps,
+			TotalNet:      fmt.Sprintf("%.2f", totalNet),
+			TotalGross:    fmt.Sprintf("%.2f", totalGross),
+			Currency:      currency,
 			Status:        record[5],
 			DueDate:       dueDate,
 			PaidAt:        paidAt,
@@ -130,15 +209,37 @@ func main() {
 		invoices = append(invoices, invoice)
 	}
 
-	fmt.Printf("Parsed %d invoices from CSV\n", len(invoices))
+	printf("Parsed %d invoices from CSV\n", len(invoices))
+
+	// Abort flag, set from the SIGINT/SIGTERM handler and checked before each
+	// batch so a Ctrl-C mid-run leaves a clean, reported partial state
+	// instead of a half-written batch.
+	var aborted atomic.Bool
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		log.Println("Received interrupt, rolling back current batch then stopping...")
+		aborted.Store(true)
+	}()
 
 	// Batch insert with ON CONFLICT - optimized for Neon
 	startTime := time.Now()
 	batchSize := 100 // Smaller batches for Neon pooler
 	inserted := 0
 	skipped := 0
+	remaining := len(invoices)
+
+	var bar *pb.ProgressBar
+	if !noProgress {
+		bar = pb.StartNew(len(invoices))
+	}
 
 	for i := 0; i < len(invoices); i += batchSize {
+		if aborted.Load() {
+			break
+		}
+
 		end := i + batchSize
 		if end > len(invoices) {
 			end = len(invoices)
@@ -151,23 +252,33 @@ func main() {
 			log.Fatalf("Failed to begin transaction: %v", err)
 		}
 
-		// Build multi-row insert query with ON CONFLICT
+		// Build multi-row insert query, using whichever "skip duplicates" syntax
+		// this dialect supports (ON CONFLICT for Postgres, ON DUPLICATE KEY
+		// UPDATE for MySQL, INSERT OR IGNORE for SQLite)
 		// Using VALUES clause with multiple rows for better performance
-		query := `
-			INSERT INTO invoices (invoice_number, customer_name, customer_email, amount, status, due_date, paid_at, created_at)
+		query := dialect.InsertIgnorePrefix() + ` invoices (invoice_number, customer_name, customer_email, amount, unit_price, quantity, vat_rate_bps, total_net, total_gross, currency, status, due_date, paid_at, created_at)
 			VALUES `
-		
-		args := make([]interface{}, 0, len(batch)*8)
+
+		const cols = 14
+		args := make([]interface{}, 0, len(batch)*cols)
 		placeholders := make([]string, 0, len(batch))
-		
+
 		for idx, inv := range batch {
-			placeholders = append(placeholders, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
-				idx*8+1, idx*8+2, idx*8+3, idx*8+4, idx*8+5, idx*8+6, idx*8+7, idx*8+8))
+			placeholders = append(placeholders, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+				idx*cols+1, idx*cols+2, idx*cols+3, idx*cols+4, idx*cols+5, idx*cols+6,
+				idx*cols+7, idx*cols+8, idx*cols+9, idx*cols+10, idx*cols+11, idx*cols+12,
+				idx*cols+13, idx*cols+14))
 			args = append(args,
 				inv.InvoiceNumber,
 				inv.CustomerName,
 				inv.CustomerEmail,
 				inv.Amount,
+				inv.UnitPrice,
+				inv.Quantity,
+				inv.VATRateBps,
+				inv.TotalNet,
+				inv.TotalGross,
+				inv.Currency,
 				inv.Status,
 				inv.DueDate,
 				inv.PaidAt,
@@ -175,8 +286,10 @@ func main() {
 			)
 		}
 		
-		fullQuery := query + strings.Join(placeholders, ", ") + `
-			ON CONFLICT (invoice_number) DO NOTHING`
+		fullQuery := query + strings.Join(placeholders, ", ")
+		if clause := dialect.UpsertIgnore("invoice_number"); clause != "" {
+			fullQuery += "\n\t\t\t" + clause
+		}
 
 		// Execute batch insert
 		result, err := tx.Exec(fullQuery, args...)
@@ -185,20 +298,35 @@ func main() {
 			log.Fatalf("Failed to insert batch: %v", err)
 		}
 
-		rowsAffected, _ := result.RowsAffected()
-		inserted += int(rowsAffected)
-		skipped += len(batch) - int(rowsAffected)
+		// Check for an interrupt that arrived while this batch was running
+		// before committing it, so a Ctrl-C never leaves a half-applied batch.
+		if aborted.Load() {
+			tx.Rollback()
+			break
+		}
 
 		// Commit transaction
 		if err := tx.Commit(); err != nil {
 			log.Fatalf("Failed to commit transaction: %v", err)
 		}
-		
+
+		rowsAffected, _ := result.RowsAffected()
+		inserted += int(rowsAffected)
+		skipped += len(batch) - int(rowsAffected)
+		remaining = len(invoices) - end
+
+		if bar != nil {
+			bar.Add(len(batch))
+		}
 		if (i/batchSize+1)%5 == 0 {
-			fmt.Printf("Processed %d/%d invoices...\n", end, len(invoices))
+			printf("Processed %d/%d invoices...\n", end, len(invoices))
 		}
 	}
 
+	if bar != nil {
+		bar.Finish()
+	}
+
 	duration := time.Since(startTime)
 
 	// Get final count
@@ -215,5 +343,12 @@ func main() {
 	fmt.Printf("Skipped (duplicates): %d\n", skipped)
 	fmt.Printf("Total invoices in DB: %d\n", totalCount)
 	fmt.Printf("Time taken: %v\n", duration)
+
+	if aborted.Load() {
+		fmt.Printf("Remaining (not processed): %d\n", remaining)
+		fmt.Printf("\nSeed aborted by interrupt - partial insert committed up to the last full batch.\n")
+		os.Exit(1)
+	}
+
 	fmt.Printf("\nSeed completed successfully!\n")
 }
@@ -1,16 +1,35 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"github.com/jmoiron/sqlx"
+	"payment-reconciliation-engine/backend/internal/batchcounters"
 	"payment-reconciliation-engine/backend/internal/db"
 	"payment-reconciliation-engine/backend/internal/processor"
+	"payment-reconciliation-engine/backend/internal/webhooks"
 	"payment-reconciliation-engine/backend/internal/worker"
 )
 
+// defaultShutdownGrace is how long the worker waits for an in-flight job to
+// finish on its own before marking it failed and exiting. Override with
+// WORKER_SHUTDOWN_GRACE (e.g. "30s") to match the kill timeout of whatever
+// platform is running this (Fly, Render, k8s all send SIGTERM well before
+// the hard kill).
+const defaultShutdownGrace = 30 * time.Second
+
+// batchCounterRecomputeInterval is how often every batch's counters get
+// recomputed from bank_transactions from scratch - a backstop against drift
+// in the incremental updates ActionsHandler applies on every confirm/
+// reject/match, independent of BatchHandler.RecountBatch's on-demand path.
+const batchCounterRecomputeInterval = 24 * time.Hour
+
 func main() {
 	log.Println("Worker starting...")
 
@@ -19,16 +38,44 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
-	defer database.Close()
 
 	// Create worker
 	w := worker.NewWorker(database)
 
-	// Set CSV processing function
+	// Deliver the batch.completed/batch.failed events completeJob/failJob
+	// emit through w.Webhooks.
+	deliveryWorker := webhooks.NewDeliveryWorker(database, w.Webhooks)
+	deliveryCtx, cancelDelivery := context.WithCancel(context.Background())
+	defer cancelDelivery()
+	go deliveryWorker.Start(deliveryCtx)
+
+	// Kept as dispatch's fallback for csv.ingest jobs when no csv.ingest
+	// Handlers entry is registered. The handler registered below takes
+	// precedence, for pre-Type jobs as well, so with it in place this is
+	// not called.
 	w.ProcessJobFunc = func(job *worker.Job) error {
 		return processor.ProcessJob(job, database, w)
 	}
 
+	w.RegisterHandler(worker.JobTypeCSVIngest, func(job *worker.Job, _ worker.JobPayload) error {
+		return processor.ProcessJob(job, database, w)
+	})
+	w.RegisterHandler(worker.JobTypeRematchBatch, func(job *worker.Job, payload worker.JobPayload) error {
+		return worker.NewPermanentJobError(fmt.Errorf("rematch.batch handler not implemented yet (batch_id=%s)", payload.(*worker.RematchBatchPayload).BatchID))
+	})
+	w.RegisterHandler(worker.JobTypeExportResults, func(job *worker.Job, payload worker.JobPayload) error {
+		p := payload.(*worker.ExportResultsPayload)
+		return worker.NewPermanentJobError(fmt.Errorf("export.results handler not implemented yet (batch_id=%s, format=%s)", p.BatchID, p.Format))
+	})
+	w.RegisterHandler(worker.JobTypeRescanUnmatched, func(job *worker.Job, payload worker.JobPayload) error {
+		return worker.NewPermanentJobError(fmt.Errorf("rescan.unmatched handler not implemented yet (batch_id=%s)", payload.(*worker.RescanUnmatchedPayload).BatchID))
+	})
+
+	// Nightly batch counter recompute sweep.
+	counterCtx, cancelCounters := context.WithCancel(context.Background())
+	defer cancelCounters()
+	go runBatchCounterSweep(counterCtx, database)
+
 	// Handle graceful shutdown
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
@@ -38,5 +85,64 @@ func main() {
 
 	// Wait for interrupt
 	<-sigChan
-	log.Println("Shutting down worker...")
+	log.Println("Shutdown signal received, draining in-flight job...")
+
+	grace := defaultShutdownGrace
+	if g := os.Getenv("WORKER_SHUTDOWN_GRACE"); g != "" {
+		if parsed, err := time.ParseDuration(g); err == nil {
+			grace = parsed
+		} else {
+			log.Printf("Warning: invalid WORKER_SHUTDOWN_GRACE %q, using default %v", g, grace)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), grace)
+	defer cancel()
+
+	// A second interrupt while we're draining means "force quit now" -
+	// don't wait for Shutdown, just exit.
+	forceQuit := make(chan struct{})
+	go func() {
+		<-sigChan
+		log.Println("Second interrupt received, forcing immediate shutdown")
+		close(forceQuit)
+	}()
+
+	shutdownDone := make(chan error, 1)
+	go func() { shutdownDone <- w.Shutdown(ctx) }()
+
+	select {
+	case err := <-shutdownDone:
+		if err != nil {
+			log.Printf("Error during shutdown: %v", err)
+		}
+	case <-forceQuit:
+		database.Close()
+		os.Exit(1)
+	}
+
+	log.Println("Worker stopped")
+}
+
+// runBatchCounterSweep recomputes every batch's counters from
+// bank_transactions once on startup, then every
+// batchCounterRecomputeInterval, until ctx is cancelled.
+func runBatchCounterSweep(ctx context.Context, database *sqlx.DB) {
+	recompute := func() {
+		if err := batchcounters.RecomputeAll(database); err != nil {
+			log.Printf("Warning: batch counter recompute sweep finished with errors: %v", err)
+		}
+	}
+
+	recompute()
+	ticker := time.NewTicker(batchCounterRecomputeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			recompute()
+		}
+	}
 }
@@ -0,0 +1,91 @@
+// Package batchcounters recounts reconciliation_batches' five per-status
+// counters directly from bank_transactions. updateBatchCounters-style
+// incremental maintenance (see internal/handlers/actions.go) is fast but
+// trusts every caller to apply exactly one delta per transition; Recompute
+// is the ground truth to fall back on when that trust is misplaced - after
+// a bug, a manual SQL fix, or just on a schedule. See cmd/worker/main.go for
+// the nightly sweep and BatchHandler.RecountBatch for the admin endpoint.
+package batchcounters
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Recompute recounts batchID's bank_transactions by status and overwrites
+// reconciliation_batches' counters with the result, all inside one
+// transaction holding pg_advisory_xact_lock(hashtext(batchID)) so a
+// concurrent ConfirmMatch/BulkConfirm can't land an increment between the
+// recount and the write and have it immediately drift again. The lock is
+// released automatically when the transaction ends.
+func Recompute(db *sqlx.DB, batchID string) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext($1))`, batchID); err != nil {
+		return fmt.Errorf("failed to acquire advisory lock: %w", err)
+	}
+
+	var counts struct {
+		AutoMatched int `db:"auto_matched"`
+		NeedsReview int `db:"needs_review"`
+		Unmatched   int `db:"unmatched"`
+		Confirmed   int `db:"confirmed"`
+		External    int `db:"external"`
+	}
+	err = tx.Get(&counts, `
+		SELECT
+			COUNT(*) FILTER (WHERE status = 'auto_matched') AS auto_matched,
+			COUNT(*) FILTER (WHERE status = 'needs_review') AS needs_review,
+			COUNT(*) FILTER (WHERE status = 'unmatched') AS unmatched,
+			COUNT(*) FILTER (WHERE status = 'confirmed') AS confirmed,
+			COUNT(*) FILTER (WHERE status = 'external') AS external
+		FROM bank_transactions
+		WHERE upload_batch_id = $1
+	`, batchID)
+	if err != nil {
+		return fmt.Errorf("failed to recount transactions: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE reconciliation_batches
+		SET auto_matched_count = $1,
+		    needs_review_count = $2,
+		    unmatched_count = $3,
+		    confirmed_count = $4,
+		    external_count = $5
+		WHERE id = $6
+	`, counts.AutoMatched, counts.NeedsReview, counts.Unmatched, counts.Confirmed, counts.External, batchID)
+	if err != nil {
+		return fmt.Errorf("failed to update batch counters: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// RecomputeAll runs Recompute against every batch in reconciliation_batches -
+// the nightly sweep cmd/worker/main.go schedules. A single batch's failure
+// is logged and skipped rather than aborting the rest; the first error
+// encountered is returned once every batch has been attempted.
+func RecomputeAll(db *sqlx.DB) error {
+	var batchIDs []string
+	if err := db.Select(&batchIDs, `SELECT id FROM reconciliation_batches`); err != nil {
+		return fmt.Errorf("failed to list batches: %w", err)
+	}
+
+	var firstErr error
+	for _, batchID := range batchIDs {
+		if err := Recompute(db, batchID); err != nil {
+			log.Printf("Warning: failed to recompute counters for batch %s: %v", batchID, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,233 @@
+// Package cache provides an optional Redis-backed progress cache for
+// reconciliation batches, used to avoid hammering Postgres when a frontend
+// polls batch status frequently.
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// BatchSnapshot mirrors the fields a poller needs from BatchResponse,
+// serialized as JSON into one field of a Redis hash so a reader can fetch
+// the whole thing atomically with one HGET.
+type BatchSnapshot struct {
+	Status            string  `json:"status"`
+	ProcessedCount    int     `json:"processedCount"`
+	TotalTransactions *int    `json:"totalTransactions"`
+	AutoMatchedCount  int     `json:"autoMatchedCount"`
+	NeedsReviewCount  int     `json:"needsReviewCount"`
+	UnmatchedCount    int     `json:"unmatchedCount"`
+	ConfirmedCount    int     `json:"confirmedCount"`
+	ExternalCount     int     `json:"externalCount"`
+	AutoMatchedTotal  float64 `json:"autoMatchedTotal"`
+	NeedsReviewTotal  float64 `json:"needsReviewTotal"`
+	UnmatchedTotal    float64 `json:"unmatchedTotal"`
+	ConfirmedTotal    float64 `json:"confirmedTotal"`
+	ExternalTotal     float64 `json:"externalTotal"`
+	NetTotal          float64 `json:"netTotal"` // VAT-exclusive total of matched invoices; recomputed on each Postgres fallback, not kept live during processing
+	TaxTotal          float64 `json:"taxTotal"`
+	CompletedAt       *string `json:"completedAt"`
+	UpdatedAt         string  `json:"updatedAt"`
+}
+
+// TTL is how long a batch snapshot survives in Redis after its last write.
+// Batches that complete get a shorter TTL (see SetSnapshot) since there's
+// no reason to keep polling a finished batch around indefinitely.
+const (
+	activeTTL    = 30 * time.Minute
+	completedTTL = 10 * time.Minute
+)
+
+type Client struct {
+	rdb *redis.Client
+}
+
+// NewFromEnv connects to REDIS_URL if set. It returns (nil, false) when the
+// env var is absent so callers can treat the cache as a pure opt-in.
+func NewFromEnv() (*Client, bool) {
+	url := os.Getenv("REDIS_URL")
+	if url == "" {
+		return nil, false
+	}
+
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, false
+	}
+
+	return &Client{rdb: redis.NewClient(opts)}, true
+}
+
+func snapshotKey(batchID string) string {
+	return fmt.Sprintf("batch:%s:snapshot", batchID)
+}
+
+func updateChannel(batchID string) string {
+	return fmt.Sprintf("batch:%s:updated", batchID)
+}
+
+// SetSnapshot writes the whole snapshot with a single HSET and refreshes the
+// key's TTL, then publishes the snapshot on the batch's update channel so
+// SSE/websocket subscribers get pushed the new state.
+func (c *Client) SetSnapshot(ctx context.Context, batchID string, snap BatchSnapshot) error {
+	payload, err := json.Marshal(snap)
+	if err != nil {
+		return fmt.Errorf("failed to marshal batch snapshot: %w", err)
+	}
+
+	key := snapshotKey(batchID)
+	ttl := activeTTL
+	if snap.CompletedAt != nil {
+		ttl = completedTTL
+	}
+
+	pipe := c.rdb.TxPipeline()
+	pipe.HSet(ctx, key, "data", payload)
+	pipe.Expire(ctx, key, ttl)
+	pipe.Publish(ctx, updateChannel(batchID), payload)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// GetSnapshot fetches the cached snapshot for a batch. redis.Nil is returned
+// unwrapped so callers can check errors.Is(err, redis.Nil) for a cache miss.
+func (c *Client) GetSnapshot(ctx context.Context, batchID string) (*BatchSnapshot, error) {
+	payload, err := c.rdb.HGet(ctx, snapshotKey(batchID), "data").Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	var snap BatchSnapshot
+	if err := json.Unmarshal(payload, &snap); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal batch snapshot: %w", err)
+	}
+	return &snap, nil
+}
+
+// Subscribe returns a pub/sub subscription to a batch's update channel. The
+// caller is responsible for closing it.
+func (c *Client) Subscribe(ctx context.Context, batchID string) *redis.PubSub {
+	return c.rdb.Subscribe(ctx, updateChannel(batchID))
+}
+
+// batchEventChannel is the channel a processor's progress events (stage,
+// counters, sequence number) are published on - distinct from
+// updateChannel's whole-snapshot-on-every-write, since an event carries
+// finer-grained stage/ordering info a WebSocket client needs that a
+// snapshot doesn't.
+func batchEventChannel(batchID string) string {
+	return fmt.Sprintf("batch_update:%s", batchID)
+}
+
+func eventSeqKey(batchID string) string {
+	return fmt.Sprintf("batch:%s:event_seq", batchID)
+}
+
+func recentEventsKey(batchID string) string {
+	return fmt.Sprintf("batch:%s:events", batchID)
+}
+
+// recentEventsLimit bounds how many of a batch's most recent events stay
+// available for a reconnecting client to replay; older ones are assumed
+// superseded by the snapshot a fresh subscribe already fetches.
+const recentEventsLimit = 200
+
+// NextBatchEventSeq atomically hands out the next per-batch sequence
+// number, so events published by any API replica's processor still order
+// consistently for a client subscribed through a different replica.
+func (c *Client) NextBatchEventSeq(ctx context.Context, batchID string) (uint64, error) {
+	key := eventSeqKey(batchID)
+	seq, err := c.rdb.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to allocate batch event sequence: %w", err)
+	}
+	c.rdb.Expire(ctx, key, activeTTL)
+	return uint64(seq), nil
+}
+
+// PublishBatchEvent appends payload to batchID's recent-events buffer
+// (capped at recentEventsLimit, for reconnect replay) and publishes it on
+// the batch's event channel for any currently-subscribed client.
+func (c *Client) PublishBatchEvent(ctx context.Context, batchID string, payload []byte) error {
+	key := recentEventsKey(batchID)
+	pipe := c.rdb.TxPipeline()
+	pipe.RPush(ctx, key, payload)
+	pipe.LTrim(ctx, key, -recentEventsLimit, -1)
+	pipe.Expire(ctx, key, activeTTL)
+	pipe.Publish(ctx, batchEventChannel(batchID), payload)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// RecentBatchEvents returns whatever of batchID's last recentEventsLimit
+// events are still buffered, oldest first, for a reconnecting client to
+// filter by sequence number and replay.
+func (c *Client) RecentBatchEvents(ctx context.Context, batchID string) ([][]byte, error) {
+	raw, err := c.rdb.LRange(ctx, recentEventsKey(batchID), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch recent batch events: %w", err)
+	}
+	events := make([][]byte, len(raw))
+	for i, s := range raw {
+		events[i] = []byte(s)
+	}
+	return events, nil
+}
+
+// SubscribeBatchEvents returns a pub/sub subscription to a batch's event
+// channel. The caller is responsible for closing it.
+func (c *Client) SubscribeBatchEvents(ctx context.Context, batchID string) *redis.PubSub {
+	return c.rdb.Subscribe(ctx, batchEventChannel(batchID))
+}
+
+// IsMiss reports whether err represents "key not found" rather than a real
+// Redis failure.
+func IsMiss(err error) bool {
+	return err == redis.Nil
+}
+
+// jobEnqueuedChannel is the pub/sub prefix a RedisDispatcher publishes on
+// and a worker's job-notification listener PSUBSCRIBEs to as
+// "job_enqueued:*", since it needs to react to whichever batch gets a job
+// next, not one it already knows about.
+func jobEnqueuedChannel(batchID string) string {
+	return fmt.Sprintf("job_enqueued:%s", batchID)
+}
+
+// PublishJobEnqueued notifies any PSUBSCRIBEd worker that batchID has a
+// freshly queued job, instead of leaving every worker to discover it only
+// once its poll interval comes back around.
+func (c *Client) PublishJobEnqueued(ctx context.Context, batchID string) error {
+	return c.rdb.Publish(ctx, jobEnqueuedChannel(batchID), batchID).Err()
+}
+
+// SubscribeJobEnqueued PSUBSCRIBEs to every batch's job_enqueued channel at
+// once. The caller is responsible for closing it.
+func (c *Client) SubscribeJobEnqueued(ctx context.Context) *redis.PubSub {
+	return c.rdb.PSubscribe(ctx, "job_enqueued:*")
+}
+
+func batchClaimKey(batchID string) string {
+	return fmt.Sprintf("batch_claim:%s", batchID)
+}
+
+// TryClaimNotification guards against every worker subscribed to
+// job_enqueued:* all reacting to the same notification - only the first
+// caller for a given batchID within ttl gets true. This is purely an
+// optimization to cut down on wasted claim attempts; it's claimJob's FOR
+// UPDATE SKIP LOCKED that actually makes processing exactly-once, so a
+// worker that never gets true here still has its batch processed by
+// whichever worker did.
+func (c *Client) TryClaimNotification(ctx context.Context, batchID string, ttl time.Duration) (bool, error) {
+	ok, err := c.rdb.SetNX(ctx, batchClaimKey(batchID), 1, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim job notification: %w", err)
+	}
+	return ok, nil
+}
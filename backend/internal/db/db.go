@@ -1,6 +1,7 @@
 package db
 
 import (
+	"fmt"
 	"net/url"
 	"os"
 	"strings"
@@ -10,12 +11,28 @@ import (
 	_ "github.com/lib/pq"
 )
 
+// Connect opens the database pointed at by DATABASE_URL, picking the driver
+// and pool tuning from the URL scheme. Postgres is the default and the only
+// driver linked in by default; sqlite:// and mysql:// require building with
+// -tags sqlite / -tags mysql respectively so the default build doesn't pull
+// in cgo or an extra driver nobody's deploying with.
 func Connect() (*sqlx.DB, error) {
 	dbURL := os.Getenv("DATABASE_URL")
 	if dbURL == "" {
 		panic("DATABASE_URL environment variable is required")
 	}
-	
+
+	switch DetectDialect(dbURL) {
+	case DialectSQLite:
+		return connectSQLite(dbURL)
+	case DialectMySQL:
+		return connectMySQL(dbURL)
+	default:
+		return connectPostgres(dbURL)
+	}
+}
+
+func connectPostgres(dbURL string) (*sqlx.DB, error) {
 	// Add parameters to disable prepared statements for Neon pooler compatibility
 	// Neon's connection pooler doesn't support prepared statements
 	parsedURL, err := url.Parse(dbURL)
@@ -41,19 +58,58 @@ func Connect() (*sqlx.DB, error) {
 			dbURL = dbURL + separator + "binary_parameters=yes"
 		}
 	}
-	
+
 	db, err := sqlx.Connect("postgres", dbURL)
 	if err != nil {
 		return nil, err
 	}
-	
+
 	// Configure connection pool for cloud databases (Neon, etc.)
 	// Neon pooler works best with shorter connection lifetimes
 	db.SetMaxOpenConns(25)
 	db.SetMaxIdleConns(5)
 	db.SetConnMaxLifetime(30 * time.Second) // Shorter for Neon pooler compatibility
 	db.SetConnMaxIdleTime(10 * time.Second)  // Shorter idle time for Neon
-	
+
+	return db, nil
+}
+
+func connectSQLite(dbURL string) (*sqlx.DB, error) {
+	dsn := strings.TrimPrefix(dbURL, "sqlite://")
+
+	db, err := sqlx.Connect("sqlite3", dsn)
+	if err != nil {
+		if strings.Contains(err.Error(), "unknown driver") {
+			return nil, fmt.Errorf("sqlite3 driver not registered: build with -tags sqlite")
+		}
+		return nil, err
+	}
+
+	// SQLite only allows one writer at a time; a larger pool just produces
+	// "database is locked" errors under concurrent batch inserts.
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+
+	return db, nil
+}
+
+func connectMySQL(dbURL string) (*sqlx.DB, error) {
+	dsn := strings.TrimPrefix(dbURL, "mysql://")
+
+	db, err := sqlx.Connect("mysql", dsn)
+	if err != nil {
+		if strings.Contains(err.Error(), "unknown driver") {
+			return nil, fmt.Errorf("mysql driver not registered: build with -tags mysql")
+		}
+		return nil, err
+	}
+
+	// MySQL doesn't need the Neon-specific simple-protocol workaround, just
+	// sane defaults for a pooled connection.
+	db.SetMaxOpenConns(25)
+	db.SetMaxIdleConns(5)
+	db.SetConnMaxLifetime(5 * time.Minute)
+
 	return db, nil
 }
 
@@ -0,0 +1,64 @@
+package db
+
+import "strings"
+
+// Dialect identifies which SQL engine a DATABASE_URL points at, so callers
+// that still need engine-specific SQL (placeholder style, ON CONFLICT vs
+// INSERT OR IGNORE, casts) can branch on something narrower than a driver
+// name string.
+type Dialect string
+
+const (
+	DialectPostgres Dialect = "postgres"
+	DialectSQLite   Dialect = "sqlite"
+	DialectMySQL    Dialect = "mysql"
+)
+
+// DetectDialect maps a DATABASE_URL scheme to a Dialect. Unrecognized
+// schemes default to Postgres, since that's the only engine this project
+// originally supported.
+func DetectDialect(dbURL string) Dialect {
+	switch {
+	case strings.HasPrefix(dbURL, "sqlite://"), strings.HasPrefix(dbURL, "file:"):
+		return DialectSQLite
+	case strings.HasPrefix(dbURL, "mysql://"):
+		return DialectMySQL
+	case strings.HasPrefix(dbURL, "postgres://"), strings.HasPrefix(dbURL, "postgresql://"):
+		return DialectPostgres
+	default:
+		return DialectPostgres
+	}
+}
+
+// UpsertIgnore returns the engine-specific clause for "insert, skip on
+// conflict" used by the seeder's batch insert.
+func (d Dialect) UpsertIgnore(conflictColumn string) string {
+	switch d {
+	case DialectSQLite:
+		return "" // handled via "INSERT OR IGNORE INTO" prefix instead
+	case DialectMySQL:
+		return "ON DUPLICATE KEY UPDATE " + conflictColumn + " = " + conflictColumn
+	default:
+		return "ON CONFLICT (" + conflictColumn + ") DO NOTHING"
+	}
+}
+
+// InsertIgnorePrefix returns the "INSERT" keyword variant a given dialect
+// needs before the column list, since SQLite expresses "skip duplicates" as
+// INSERT OR IGNORE rather than an ON CONFLICT clause.
+func (d Dialect) InsertIgnorePrefix() string {
+	if d == DialectSQLite {
+		return "INSERT OR IGNORE INTO"
+	}
+	return "INSERT INTO"
+}
+
+// TextCast returns how to cast an expression to text for this dialect.
+// Postgres needs an explicit ::text cast to get UUID/enum columns back as
+// strings through sqlx; SQLite and MySQL return strings natively.
+func (d Dialect) TextCast(expr string) string {
+	if d == DialectPostgres {
+		return expr + "::text"
+	}
+	return expr
+}
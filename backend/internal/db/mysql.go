@@ -0,0 +1,5 @@
+//go:build mysql
+
+package db
+
+import _ "github.com/go-sql-driver/mysql"
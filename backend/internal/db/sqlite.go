@@ -0,0 +1,5 @@
+//go:build sqlite
+
+package db
+
+import _ "github.com/mattn/go-sqlite3"
@@ -0,0 +1,210 @@
+// Package events fans a reconciliation batch's progress out to WebSocket
+// subscribers as it's processed - finer-grained than the periodic
+// snapshots in internal/cache (a stage, not just counters), and ordered
+// with a per-batch sequence number so a reconnecting client can ask for
+// only what it missed.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"payment-reconciliation-engine/backend/internal/cache"
+)
+
+// SchemaVersion is stamped onto every Event as "v", so a client (or a
+// future server version) can tell which shape it's decoding.
+const SchemaVersion = 1
+
+// Stage names the phase of processing an Event describes. Terminal stages
+// are Complete and Error; every other stage is expected to be followed by
+// more events for the same batch.
+type Stage string
+
+const (
+	StageLoadingInvoices Stage = "loading_invoices"
+	StageMatching        Stage = "matching"
+	StagePersisting      Stage = "persisting"
+	StageComplete        Stage = "complete"
+	StageError           Stage = "error"
+)
+
+// Event is one progress update for a batch, versioned and sequenced so a
+// client can detect gaps (reconnect and missed a live event) and request a
+// replay from Bus.ReplayFrom.
+type Event struct {
+	V           int    `json:"v"`
+	Seq         uint64 `json:"seq"`
+	BatchID     string `json:"batchId"`
+	Stage       Stage  `json:"stage"`
+	Processed   int    `json:"processed"`
+	AutoMatched int    `json:"autoMatched"`
+	NeedsReview int    `json:"needsReview"`
+	Unmatched   int    `json:"unmatched"`
+	Error       string `json:"error,omitempty"`
+	Timestamp   string `json:"timestamp"`
+}
+
+// Bus publishes and subscribes to batch progress events. With no Redis
+// client it's purely in-process - fine for local dev and a single API
+// replica. With one, Publish and Subscribe both go through Redis (mirroring
+// internal/cache's existing snapshot pub/sub), so a processor running in
+// one replica's worker pod still reaches a client whose WebSocket landed
+// on a different API replica.
+type Bus struct {
+	cache *cache.Client // optional; nil means in-process only
+
+	mu   sync.Mutex
+	subs map[string]map[chan Event]struct{}
+	seq  map[string]uint64
+}
+
+// NewBus builds a Bus. Pass nil to run purely in-process (single replica,
+// no Redis configured); pass the same *cache.Client the worker/handlers
+// already use otherwise.
+func NewBus(redisCache *cache.Client) *Bus {
+	return &Bus{
+		cache: redisCache,
+		subs:  make(map[string]map[chan Event]struct{}),
+		seq:   make(map[string]uint64),
+	}
+}
+
+// Publish stamps e with its schema version, timestamp, and next sequence
+// number for its batch, then delivers it to every current subscriber.
+func (b *Bus) Publish(ctx context.Context, e Event) error {
+	e.V = SchemaVersion
+	e.Timestamp = time.Now().UTC().Format(time.RFC3339)
+
+	if b.cache != nil {
+		seq, err := b.cache.NextBatchEventSeq(ctx, e.BatchID)
+		if err != nil {
+			return err
+		}
+		e.Seq = seq
+
+		payload, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("failed to marshal batch event: %w", err)
+		}
+		return b.cache.PublishBatchEvent(ctx, e.BatchID, payload)
+	}
+
+	b.mu.Lock()
+	b.seq[e.BatchID]++
+	e.Seq = b.seq[e.BatchID]
+	for ch := range b.subs[e.BatchID] {
+		select {
+		case ch <- e:
+		default:
+			// Subscriber isn't keeping up - drop rather than block the
+			// processor. A client that notices a sequence gap can still
+			// fall back to polling GetBatch for current state.
+		}
+	}
+	b.mu.Unlock()
+	return nil
+}
+
+// Subscribe delivers every Event published for batchID onward, until the
+// returned cancel func is called (which the caller must always do, to
+// avoid leaking the subscription). In Redis mode this opens a dedicated
+// pub/sub connection per subscriber; in-process mode it registers a
+// buffered channel directly in the local fan-out set.
+func (b *Bus) Subscribe(ctx context.Context, batchID string) (<-chan Event, func()) {
+	if b.cache != nil {
+		return b.subscribeRedis(ctx, batchID)
+	}
+	return b.subscribeLocal(batchID)
+}
+
+func (b *Bus) subscribeLocal(batchID string) (<-chan Event, func()) {
+	ch := make(chan Event, 32)
+
+	b.mu.Lock()
+	if b.subs[batchID] == nil {
+		b.subs[batchID] = make(map[chan Event]struct{})
+	}
+	b.subs[batchID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	cancel := func() {
+		b.mu.Lock()
+		delete(b.subs[batchID], ch)
+		if len(b.subs[batchID]) == 0 {
+			delete(b.subs, batchID)
+		}
+		b.mu.Unlock()
+		close(ch)
+	}
+	return ch, cancel
+}
+
+func (b *Bus) subscribeRedis(ctx context.Context, batchID string) (<-chan Event, func()) {
+	sub := b.cache.SubscribeBatchEvents(ctx, batchID)
+	out := make(chan Event, 32)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(out)
+		msgCh := sub.Channel()
+		for {
+			select {
+			case <-done:
+				return
+			case msg, ok := <-msgCh:
+				if !ok {
+					return
+				}
+				var e Event
+				if err := json.Unmarshal([]byte(msg.Payload), &e); err != nil {
+					continue
+				}
+				select {
+				case out <- e:
+				case <-done:
+					return
+				}
+			}
+		}
+	}()
+
+	cancel := func() {
+		close(done)
+		sub.Close()
+	}
+	return out, cancel
+}
+
+// ReplayFrom returns batchID's buffered events with Seq > afterSeq, oldest
+// first, for a client reconnecting with the last sequence number it saw.
+// Always empty in in-process mode, since there's nowhere to buffer past
+// events without Redis - a reconnecting client there just resumes from the
+// next live event (and can fall back to GetBatch for anything it missed).
+func (b *Bus) ReplayFrom(ctx context.Context, batchID string, afterSeq uint64) ([]Event, error) {
+	if b.cache == nil {
+		return nil, nil
+	}
+
+	raw, err := b.cache.RecentBatchEvents(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]Event, 0, len(raw))
+	for _, payload := range raw {
+		var e Event
+		if err := json.Unmarshal(payload, &e); err != nil {
+			continue
+		}
+		if e.Seq > afterSeq {
+			events = append(events, e)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Seq < events[j].Seq })
+	return events, nil
+}
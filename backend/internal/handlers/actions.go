@@ -1,19 +1,61 @@
 package handlers
 
 import (
+	"bytes"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"github.com/labstack/echo/v4"
+	"payment-reconciliation-engine/backend/internal/auth"
+	"payment-reconciliation-engine/backend/internal/idempotency"
+	"payment-reconciliation-engine/backend/internal/ledger"
+	"payment-reconciliation-engine/backend/internal/webhooks"
 )
 
+// externalCurrency is what a MarkExternal posting is denominated in - a
+// transaction with no matched invoice has no currency of its own to fall
+// back to, so this mirrors Processor.BaseCurrency's default.
+const externalCurrency = "USD"
+
+// defaultUndoWindow is how long after an action lands in match_audit_logs
+// UndoMatch still allows reversing it. Override with MATCH_UNDO_WINDOW
+// (e.g. "12h") to match a team's own SLA for catching mis-clicks.
+const defaultUndoWindow = 24 * time.Hour
+
+// undoWindow reads MATCH_UNDO_WINDOW, falling back to defaultUndoWindow on
+// an absent or unparseable value - the same env-var-with-duration-fallback
+// pattern cmd/worker/main.go uses for WORKER_SHUTDOWN_GRACE.
+func undoWindow() time.Duration {
+	if v := os.Getenv("MATCH_UNDO_WINDOW"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil {
+			return parsed
+		}
+		log.Printf("Warning: invalid MATCH_UNDO_WINDOW %q, using default %v", v, defaultUndoWindow)
+	}
+	return defaultUndoWindow
+}
+
+// undoableActions is the set of match_audit_logs.action values UndoMatch
+// will reverse. "undone" itself is deliberately excluded - undoing an undo
+// would need to replay whatever the undone action had replaced, which this
+// table doesn't track two levels deep.
+var undoableActions = map[string]bool{
+	"confirmed":       true,
+	"manual_matched":  true,
+	"marked_external": true,
+}
+
 type ActionsHandler struct {
-	DB *sqlx.DB
+	DB       *sqlx.DB
+	Webhooks *webhooks.Emitter
 }
 
 type ConfirmRequest struct {
@@ -30,66 +72,117 @@ type BulkConfirmRequest struct {
 	Notes   string `json:"notes"`
 }
 
-func NewActionsHandler(db *sqlx.DB) *ActionsHandler {
-	return &ActionsHandler{DB: db}
+func NewActionsHandler(db *sqlx.DB, emitter *webhooks.Emitter) *ActionsHandler {
+	return &ActionsHandler{DB: db, Webhooks: emitter}
 }
 
-// updateBatchCounters updates batch counters when transaction status changes
-// Uses direct query formatting to avoid prepared statement issues with Neon pooler
+// statusCounterDeltas returns the {-1,0,+1} adjustment a transition from
+// oldStatus to newStatus makes to each of reconciliation_batches' five
+// per-status counters - -1 against oldStatus's own counter, +1 against
+// newStatus's, 0 everywhere else. "" (no previous status, e.g. a fresh
+// upload) touches nothing.
+func statusCounterDeltas(oldStatus, newStatus string) (autoMatched, needsReview, unmatched, confirmed, external int) {
+	adjust := func(status string, delta int) {
+		switch status {
+		case "auto_matched":
+			autoMatched += delta
+		case "needs_review":
+			needsReview += delta
+		case "unmatched":
+			unmatched += delta
+		case "confirmed":
+			confirmed += delta
+		case "external":
+			external += delta
+		}
+	}
+	adjust(oldStatus, -1)
+	adjust(newStatus, 1)
+	return
+}
+
+// updateBatchCounters applies oldStatus->newStatus's counter deltas to
+// batchID in a single parameterized UPDATE. Unlike a read-modify-write, two
+// concurrent calls for the same batch can never lose one another's
+// increment - Postgres serializes the two UPDATEs against the same row and
+// each sees the other's committed delta.
 func (h *ActionsHandler) updateBatchCounters(tx *sqlx.Tx, batchID string, oldStatus, newStatus string) error {
-	// Get current batch counters
-	var batch struct {
-		AutoMatchedCount  int `db:"auto_matched_count"`
-		NeedsReviewCount  int `db:"needs_review_count"`
-		UnmatchedCount    int `db:"unmatched_count"`
-		ConfirmedCount   int `db:"confirmed_count"`
-		ExternalCount     int `db:"external_count"`
-	}
-	err := tx.Get(&batch, `SELECT auto_matched_count, needs_review_count, unmatched_count, confirmed_count, external_count FROM reconciliation_batches WHERE id = $1`, batchID)
-	if err != nil {
-		return fmt.Errorf("failed to fetch batch counters: %w", err)
-	}
-
-	// Adjust counters based on status transition (decrease old status)
-	if oldStatus == "auto_matched" {
-		batch.AutoMatchedCount--
-	} else if oldStatus == "needs_review" {
-		batch.NeedsReviewCount--
-	} else if oldStatus == "unmatched" {
-		batch.UnmatchedCount--
-	} else if oldStatus == "confirmed" {
-		batch.ConfirmedCount--
-	} else if oldStatus == "external" {
-		batch.ExternalCount--
-	}
-
-	// Increase new status counter
-	if newStatus == "auto_matched" {
-		batch.AutoMatchedCount++
-	} else if newStatus == "needs_review" {
-		batch.NeedsReviewCount++
-	} else if newStatus == "unmatched" {
-		batch.UnmatchedCount++
-	} else if newStatus == "confirmed" {
-		batch.ConfirmedCount++
-	} else if newStatus == "external" {
-		batch.ExternalCount++
-	}
-
-	// Update batch counters (using direct query to avoid prepared statements)
-	// Use Exec with formatted query string - safe because batchID is validated UUID and counts are integers
-	query := fmt.Sprintf(`
+	autoMatched, needsReview, unmatched, confirmed, external := statusCounterDeltas(oldStatus, newStatus)
+	_, err := tx.Exec(`
 		UPDATE reconciliation_batches
-		SET auto_matched_count = %d,
-		    needs_review_count = %d,
-		    unmatched_count = %d,
-		    confirmed_count = %d,
-		    external_count = %d
-		WHERE id = '%s'
-	`, batch.AutoMatchedCount, batch.NeedsReviewCount, batch.UnmatchedCount, batch.ConfirmedCount, batch.ExternalCount, batchID)
-	
-	_, err = tx.Exec(query)
-	return err
+		SET auto_matched_count = auto_matched_count + $1,
+		    needs_review_count = needs_review_count + $2,
+		    unmatched_count = unmatched_count + $3,
+		    confirmed_count = confirmed_count + $4,
+		    external_count = external_count + $5
+		WHERE id = $6
+	`, autoMatched, needsReview, unmatched, confirmed, external, batchID)
+	if err != nil {
+		return fmt.Errorf("failed to update batch counters: %w", err)
+	}
+	return nil
+}
+
+// commitWithIdempotency marshals payload, stores it under idempKey (if one
+// was supplied) so a retried request replays it verbatim, and commits tx -
+// in that order, so a crash between the two never leaves a committed
+// mutation with nothing to replay from. Returns the marshaled bytes so the
+// caller can respond with the exact same JSON a replay would later return.
+func (h *ActionsHandler) commitWithIdempotency(tx *sqlx.Tx, idempKey, requestHash, userID string, status int, payload interface{}) ([]byte, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal response: %w", err)
+	}
+	if idempKey != "" {
+		if err := idempotency.Store(tx, idempKey, userID, requestHash, status, body); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// checkIdempotency looks up idempKey (a no-op if empty) inside tx, the same
+// transaction the caller is about to use for its mutation. handled=true
+// means a response (the cached replay, a 409 conflict, or a 500) has
+// already been written, and the caller should return err as-is instead of
+// running the handler's mutation at all.
+func checkIdempotency(c echo.Context, tx *sqlx.Tx, idempKey, requestHash, userID string) (handled bool, err error) {
+	if idempKey == "" {
+		return false, nil
+	}
+	cached, err := idempotency.Lookup(tx, idempKey, userID, requestHash)
+	if err == idempotency.ErrConflict {
+		return true, c.JSON(http.StatusConflict, map[string]string{"error": "Idempotency-Key already used with a different request body"})
+	}
+	if err != nil {
+		return true, c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to check idempotency key"})
+	}
+	if cached != nil {
+		return true, c.JSONBlob(cached.Status, cached.Body)
+	}
+	return false, nil
+}
+
+// readIdempotencyKey reads the optional Idempotency-Key header and hashes
+// the request body for comparison against anything already stored under
+// that key, then rewinds the body so the handler's own c.Bind still sees
+// every byte. An absent header returns an empty key - callers skip the
+// idempotency check entirely in that case, behaving exactly as before this
+// existed.
+func readIdempotencyKey(c echo.Context) (key, requestHash string, err error) {
+	key = c.Request().Header.Get("Idempotency-Key")
+	if key == "" {
+		return "", "", nil
+	}
+	raw, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read request body: %w", err)
+	}
+	c.Request().Body = io.NopCloser(bytes.NewReader(raw))
+	return key, idempotency.HashBody(raw), nil
 }
 
 // ConfirmMatch confirms a suggested match
@@ -100,25 +193,41 @@ func (h *ActionsHandler) ConfirmMatch(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid transaction id"})
 	}
 
+	idempKey, requestHash, err := readIdempotencyKey(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "failed to read request body"})
+	}
+
 	var req ConfirmRequest
 	if err := c.Bind(&req); err != nil {
 		// Notes are optional, continue with empty notes
 	}
 
+	user := auth.FromContext(c)
+	if user == nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "authentication required"})
+	}
+
 	tx, err := h.DB.Beginx()
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to begin transaction"})
 	}
 	defer tx.Rollback()
 
+	if handled, err := checkIdempotency(c, tx, idempKey, requestHash, user.ID.String()); handled {
+		return err
+	}
+
 	// Lock and get current transaction state
 	var current struct {
 		Status          string         `db:"status"`
 		MatchedInvoiceID sql.NullString `db:"matched_invoice_id"`
 		BatchID         string         `db:"upload_batch_id"`
+		Amount          string         `db:"amount"`
+		ConfidenceScore sql.NullFloat64 `db:"confidence_score"`
 	}
 	err = tx.Get(&current, `
-		SELECT status, matched_invoice_id, upload_batch_id
+		SELECT status, matched_invoice_id, upload_batch_id, amount::text AS amount, confidence_score
 		FROM bank_transactions
 		WHERE id = $1
 		FOR UPDATE
@@ -144,6 +253,14 @@ func (h *ActionsHandler) ConfirmMatch(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "transaction has no matched invoice"})
 	}
 
+	var invoice struct {
+		Status   string `db:"status"`
+		Currency string `db:"currency"`
+	}
+	if err := tx.Get(&invoice, `SELECT status, currency FROM invoices WHERE id = $1`, current.MatchedInvoiceID.String); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch invoice"})
+	}
+
 	// Update transaction
 	_, err = tx.Exec(`
 		UPDATE bank_transactions
@@ -154,6 +271,26 @@ func (h *ActionsHandler) ConfirmMatch(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to update transaction"})
 	}
 
+	// A confirmed match means the invoice has been paid. invoice.Status (its
+	// state right before this update) is recorded on the audit log below so
+	// UndoMatch can restore it.
+	_, err = tx.Exec(`
+		UPDATE invoices
+		SET status = 'paid', paid_at = NOW()
+		WHERE id = $1
+	`, current.MatchedInvoiceID.String)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to update invoice"})
+	}
+
+	// Post Dr Bank / Cr Accounts Receivable for the confirmed amount.
+	if _, err := ledger.Post(tx, "transaction confirmed", transactionID, current.MatchedInvoiceID.String, current.BatchID, []ledger.Entry{
+		{AccountCode: ledger.AccountBank, Direction: ledger.DirectionDebit, Amount: current.Amount, Currency: invoice.Currency},
+		{AccountCode: ledger.AccountAccountsReceivable, Direction: ledger.DirectionCredit, Amount: current.Amount, Currency: invoice.Currency},
+	}); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to post ledger entry"})
+	}
+
 	// Update batch counters
 	if err := h.updateBatchCounters(tx, current.BatchID, current.Status, "confirmed"); err != nil {
 		log.Printf("Warning: Failed to update batch counters: %v", err)
@@ -164,19 +301,38 @@ func (h *ActionsHandler) ConfirmMatch(c echo.Context) error {
 	_, err = tx.Exec(`
 		INSERT INTO match_audit_logs (
 			transaction_id, action, previous_invoice_id, new_invoice_id,
-			performed_by, reason, created_at
-		) VALUES ($1, $2, $3, $4, $5, $6, NOW())
+			previous_status, new_status, previous_confidence_score,
+			previous_invoice_status, performed_by, performed_by_user_id, reason, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NOW())
 	`, transactionID, "confirmed", current.MatchedInvoiceID.String, current.MatchedInvoiceID.String,
-		"system", req.Notes)
+		current.Status, "confirmed", current.ConfidenceScore,
+		invoice.Status, user.Email, user.ID, req.Notes)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create audit log"})
 	}
 
-	if err := tx.Commit(); err != nil {
+	if err := webhooks.WriteEvent(tx, webhooks.EventTransactionConfirmed, transactionID, current.BatchID, current.Status, "confirmed", user.Email, map[string]interface{}{
+		"transactionId": transactionID,
+		"batchId":       current.BatchID,
+		"invoiceId":     current.MatchedInvoiceID.String,
+		"action":        "confirmed",
+	}); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to record reconciliation event"})
+	}
+
+	body, err := h.commitWithIdempotency(tx, idempKey, requestHash, user.ID.String(), http.StatusOK, map[string]string{"message": "match confirmed"})
+	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to commit transaction"})
 	}
 
-	return c.JSON(http.StatusOK, map[string]string{"message": "match confirmed"})
+	h.Webhooks.Emit(webhooks.EventTransactionManuallyMatched, map[string]interface{}{
+		"transactionId": transactionID,
+		"batchId":       current.BatchID,
+		"invoiceId":     current.MatchedInvoiceID.String,
+		"action":        "confirmed",
+	})
+
+	return c.JSONBlob(http.StatusOK, body)
 }
 
 // RejectMatch rejects a suggested match
@@ -187,20 +343,35 @@ func (h *ActionsHandler) RejectMatch(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid transaction id"})
 	}
 
+	idempKey, requestHash, err := readIdempotencyKey(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "failed to read request body"})
+	}
+
+	user := auth.FromContext(c)
+	if user == nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "authentication required"})
+	}
+
 	tx, err := h.DB.Beginx()
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to begin transaction"})
 	}
 	defer tx.Rollback()
 
+	if handled, err := checkIdempotency(c, tx, idempKey, requestHash, user.ID.String()); handled {
+		return err
+	}
+
 	// Lock and get current state
 	var current struct {
-		Status          string         `db:"status"`
+		Status          string          `db:"status"`
 		MatchedInvoiceID sql.NullString `db:"matched_invoice_id"`
-		BatchID         string         `db:"upload_batch_id"`
+		BatchID         string          `db:"upload_batch_id"`
+		ConfidenceScore sql.NullFloat64 `db:"confidence_score"`
 	}
 	err = tx.Get(&current, `
-		SELECT status, matched_invoice_id, upload_batch_id
+		SELECT status, matched_invoice_id, upload_batch_id, confidence_score
 		FROM bank_transactions
 		WHERE id = $1
 		FOR UPDATE
@@ -226,6 +397,19 @@ func (h *ActionsHandler) RejectMatch(c echo.Context) error {
 		previousInvoiceID = nil
 	}
 
+	// Reverse any posting ConfirmMatch/ManualMatch already made for this
+	// transaction before it got here - reachable once a reopen action can
+	// move a confirmed transaction back to needs_review/auto_matched.
+	openPosting, err := ledger.OpenPostingForTransaction(tx, transactionID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to look up ledger posting"})
+	}
+	if openPosting != "" {
+		if _, err := ledger.Reverse(tx, openPosting, "match rejected by "+user.Email); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to reverse ledger entry"})
+		}
+	}
+
 	// Update transaction: clear match, set to unmatched
 	_, err = tx.Exec(`
 		UPDATE bank_transactions
@@ -248,18 +432,30 @@ func (h *ActionsHandler) RejectMatch(c echo.Context) error {
 	_, err = tx.Exec(`
 		INSERT INTO match_audit_logs (
 			transaction_id, action, previous_invoice_id, new_invoice_id,
-			performed_by, reason, created_at
-		) VALUES ($1, $2, $3, $4, $5, $6, NOW())
-	`, transactionID, "rejected", previousInvoiceID, nil, "system", "Match rejected by admin")
+			previous_status, new_status, previous_confidence_score,
+			performed_by, performed_by_user_id, reason, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW())
+	`, transactionID, "rejected", previousInvoiceID, nil,
+		current.Status, "unmatched", current.ConfidenceScore,
+		user.Email, user.ID, "Match rejected by "+user.Email)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create audit log"})
 	}
 
-	if err := tx.Commit(); err != nil {
+	if err := webhooks.WriteEvent(tx, webhooks.EventTransactionRejected, transactionID, current.BatchID, current.Status, "unmatched", user.Email, map[string]interface{}{
+		"transactionId": transactionID,
+		"batchId":       current.BatchID,
+		"action":        "rejected",
+	}); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to record reconciliation event"})
+	}
+
+	body, err := h.commitWithIdempotency(tx, idempKey, requestHash, user.ID.String(), http.StatusOK, map[string]string{"message": "match rejected"})
+	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to commit transaction"})
 	}
 
-	return c.JSON(http.StatusOK, map[string]string{"message": "match rejected"})
+	return c.JSONBlob(http.StatusOK, body)
 }
 
 // ManualMatch manually assigns an invoice to a transaction
@@ -270,6 +466,11 @@ func (h *ActionsHandler) ManualMatch(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid transaction id"})
 	}
 
+	idempKey, requestHash, err := readIdempotencyKey(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "failed to read request body"})
+	}
+
 	var req ManualMatchRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
@@ -279,20 +480,30 @@ func (h *ActionsHandler) ManualMatch(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid invoice id"})
 	}
 
+	user := auth.FromContext(c)
+	if user == nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "authentication required"})
+	}
+
 	tx, err := h.DB.Beginx()
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to begin transaction"})
 	}
 	defer tx.Rollback()
 
+	if handled, err := checkIdempotency(c, tx, idempKey, requestHash, user.ID.String()); handled {
+		return err
+	}
+
 	// Verify invoice exists and is eligible
 	var invoice struct {
-		ID     string `db:"id"`
-		Status string `db:"status"`
-		PaidAt sql.NullTime `db:"paid_at"`
+		ID       string         `db:"id"`
+		Status   string         `db:"status"`
+		PaidAt   sql.NullTime   `db:"paid_at"`
+		Currency string         `db:"currency"`
 	}
 	err = tx.Get(&invoice, `
-		SELECT id, status, paid_at
+		SELECT id, status, paid_at, currency
 		FROM invoices
 		WHERE id = $1
 	`, req.InvoiceID)
@@ -308,12 +519,14 @@ func (h *ActionsHandler) ManualMatch(c echo.Context) error {
 
 	// Lock and get current transaction state
 	var current struct {
-		Status          string         `db:"status"`
+		Status          string          `db:"status"`
 		MatchedInvoiceID sql.NullString `db:"matched_invoice_id"`
-		BatchID         string         `db:"upload_batch_id"`
+		BatchID         string          `db:"upload_batch_id"`
+		Amount          string          `db:"amount"`
+		ConfidenceScore sql.NullFloat64 `db:"confidence_score"`
 	}
 	err = tx.Get(&current, `
-		SELECT status, matched_invoice_id, upload_batch_id
+		SELECT status, matched_invoice_id, upload_batch_id, amount::text AS amount, confidence_score
 		FROM bank_transactions
 		WHERE id = $1
 		FOR UPDATE
@@ -344,28 +557,67 @@ func (h *ActionsHandler) ManualMatch(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to update transaction"})
 	}
 
+	// A manual match means the invoice has been paid.
+	_, err = tx.Exec(`
+		UPDATE invoices
+		SET status = 'paid', paid_at = NOW()
+		WHERE id = $1
+	`, req.InvoiceID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to update invoice"})
+	}
+
+	// Post Dr Bank / Cr Accounts Receivable for the matched amount.
+	if _, err := ledger.Post(tx, "transaction manually matched", transactionID, req.InvoiceID, current.BatchID, []ledger.Entry{
+		{AccountCode: ledger.AccountBank, Direction: ledger.DirectionDebit, Amount: current.Amount, Currency: invoice.Currency},
+		{AccountCode: ledger.AccountAccountsReceivable, Direction: ledger.DirectionCredit, Amount: current.Amount, Currency: invoice.Currency},
+	}); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to post ledger entry"})
+	}
+
 	// Update batch counters
 	if err := h.updateBatchCounters(tx, current.BatchID, current.Status, "confirmed"); err != nil {
 		log.Printf("Warning: Failed to update batch counters: %v", err)
 		// Continue anyway - counters are eventually consistent
 	}
 
-	// Insert audit log
+	// Insert audit log - invoice.Status (its state right before the update
+	// above) lets UndoMatch restore it.
 	_, err = tx.Exec(`
 		INSERT INTO match_audit_logs (
 			transaction_id, action, previous_invoice_id, new_invoice_id,
-			performed_by, reason, created_at
-		) VALUES ($1, $2, $3, $4, $5, $6, NOW())
-	`, transactionID, "manual_matched", previousInvoiceID, req.InvoiceID, "system", req.Notes)
+			previous_status, new_status, previous_confidence_score,
+			previous_invoice_status, performed_by, performed_by_user_id, reason, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NOW())
+	`, transactionID, "manual_matched", previousInvoiceID, req.InvoiceID,
+		current.Status, "confirmed", current.ConfidenceScore,
+		invoice.Status, user.Email, user.ID, req.Notes)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create audit log"})
 	}
 
-	if err := tx.Commit(); err != nil {
+	if err := webhooks.WriteEvent(tx, webhooks.EventTransactionManuallyMatched, transactionID, current.BatchID, current.Status, "confirmed", user.Email, map[string]interface{}{
+		"transactionId": transactionID,
+		"batchId":       current.BatchID,
+		"invoiceId":     req.InvoiceID,
+		"action":        "manual_matched",
+	}); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to record reconciliation event"})
+	}
+
+	body, err := h.commitWithIdempotency(tx, idempKey, requestHash, user.ID.String(), http.StatusOK, map[string]string{"message": "invoice manually matched"})
+	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to commit transaction"})
 	}
 
-	return c.JSON(http.StatusOK, map[string]string{"message": "invoice manually matched"})
+	h.Webhooks.Emit(webhooks.EventTransactionManuallyMatched, map[string]interface{}{
+		"transactionId": transactionID,
+		"batchId":       current.BatchID,
+		"invoiceId":     req.InvoiceID,
+		"action":        "manual_matched",
+	})
+
+	return c.JSONBlob(http.StatusOK, body)
 }
 
 // MarkExternal marks a transaction as external (no invoice in system)
@@ -376,20 +628,36 @@ func (h *ActionsHandler) MarkExternal(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid transaction id"})
 	}
 
+	idempKey, requestHash, err := readIdempotencyKey(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "failed to read request body"})
+	}
+
+	user := auth.FromContext(c)
+	if user == nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "authentication required"})
+	}
+
 	tx, err := h.DB.Beginx()
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to begin transaction"})
 	}
 	defer tx.Rollback()
 
+	if handled, err := checkIdempotency(c, tx, idempKey, requestHash, user.ID.String()); handled {
+		return err
+	}
+
 	// Lock and get current state
 	var current struct {
-		Status          string         `db:"status"`
+		Status          string          `db:"status"`
 		MatchedInvoiceID sql.NullString `db:"matched_invoice_id"`
-		BatchID         string         `db:"upload_batch_id"`
+		BatchID         string          `db:"upload_batch_id"`
+		Amount          string          `db:"amount"`
+		ConfidenceScore sql.NullFloat64 `db:"confidence_score"`
 	}
 	err = tx.Get(&current, `
-		SELECT status, matched_invoice_id, upload_batch_id
+		SELECT status, matched_invoice_id, upload_batch_id, amount::text AS amount, confidence_score
 		FROM bank_transactions
 		WHERE id = $1
 		FOR UPDATE
@@ -413,6 +681,16 @@ func (h *ActionsHandler) MarkExternal(c echo.Context) error {
 		previousInvoiceID = nil
 	}
 
+	// Post Dr Bank / Cr Suspense - there's no invoice to post against an
+	// accounts-receivable for, so the amount sits in suspense until someone
+	// investigates it.
+	if _, err := ledger.Post(tx, "transaction marked external", transactionID, nil, current.BatchID, []ledger.Entry{
+		{AccountCode: ledger.AccountBank, Direction: ledger.DirectionDebit, Amount: current.Amount, Currency: externalCurrency},
+		{AccountCode: ledger.AccountSuspense, Direction: ledger.DirectionCredit, Amount: current.Amount, Currency: externalCurrency},
+	}); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to post ledger entry"})
+	}
+
 	// Update transaction
 	_, err = tx.Exec(`
 		UPDATE bank_transactions
@@ -434,22 +712,235 @@ func (h *ActionsHandler) MarkExternal(c echo.Context) error {
 	_, err = tx.Exec(`
 		INSERT INTO match_audit_logs (
 			transaction_id, action, previous_invoice_id, new_invoice_id,
-			performed_by, reason, created_at
-		) VALUES ($1, $2, $3, $4, $5, $6, NOW())
-	`, transactionID, "marked_external", previousInvoiceID, nil, "system", "Marked as external payment")
+			previous_status, new_status, previous_confidence_score,
+			performed_by, performed_by_user_id, reason, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW())
+	`, transactionID, "marked_external", previousInvoiceID, nil,
+		current.Status, "external", current.ConfidenceScore,
+		user.Email, user.ID, "Marked as external payment")
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create audit log"})
 	}
 
-	if err := tx.Commit(); err != nil {
+	if err := webhooks.WriteEvent(tx, webhooks.EventTransactionMarkedExternal, transactionID, current.BatchID, current.Status, "external", user.Email, map[string]interface{}{
+		"transactionId": transactionID,
+		"batchId":       current.BatchID,
+		"action":        "marked_external",
+	}); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to record reconciliation event"})
+	}
+
+	body, err := h.commitWithIdempotency(tx, idempKey, requestHash, user.ID.String(), http.StatusOK, map[string]string{"message": "marked as external"})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to commit transaction"})
+	}
+
+	return c.JSONBlob(http.StatusOK, body)
+}
+
+// UndoMatch reverses the most recent confirm/manual-match/mark-external
+// action on a transaction: it restores matched_invoice_id/confidence_score/
+// status from that action's match_audit_logs row, reverses whatever ledger
+// posting the action made, restores the matched invoice's status from
+// match_audit_logs.previous_invoice_status (ConfirmMatch/ManualMatch record
+// it before setting the invoice to 'paid'), and updates batch counters -
+// all provided the action is still within undoWindow() and nothing
+// downstream has already been exported.
+func (h *ActionsHandler) UndoMatch(c echo.Context) error {
+	transactionID := c.Param("id")
+
+	if _, err := uuid.Parse(transactionID); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid transaction id"})
+	}
+
+	idempKey, requestHash, err := readIdempotencyKey(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "failed to read request body"})
+	}
+
+	user := auth.FromContext(c)
+	if user == nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "authentication required"})
+	}
+
+	tx, err := h.DB.Beginx()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to begin transaction"})
+	}
+	defer tx.Rollback()
+
+	if handled, err := checkIdempotency(c, tx, idempKey, requestHash, user.ID.String()); handled {
+		return err
+	}
+
+	// Lock and get current state
+	var current struct {
+		Status          string          `db:"status"`
+		MatchedInvoiceID sql.NullString `db:"matched_invoice_id"`
+		BatchID         string          `db:"upload_batch_id"`
+		ConfidenceScore sql.NullFloat64 `db:"confidence_score"`
+	}
+	err = tx.Get(&current, `
+		SELECT status, matched_invoice_id, upload_batch_id, confidence_score
+		FROM bank_transactions
+		WHERE id = $1
+		FOR UPDATE
+	`, transactionID)
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "transaction not found"})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch transaction"})
+	}
+
+	// Find the most recent action taken on this transaction, by any user.
+	var lastAction struct {
+		ID                      string          `db:"id"`
+		Action                  string          `db:"action"`
+		PreviousInvoiceID       sql.NullString  `db:"previous_invoice_id"`
+		PreviousStatus          sql.NullString  `db:"previous_status"`
+		PreviousConfidenceScore sql.NullFloat64 `db:"previous_confidence_score"`
+		PreviousInvoiceStatus   sql.NullString  `db:"previous_invoice_status"`
+		CreatedAt               time.Time       `db:"created_at"`
+	}
+	err = tx.Get(&lastAction, `
+		SELECT id, action, previous_invoice_id, previous_status, previous_confidence_score,
+		       previous_invoice_status, created_at
+		FROM match_audit_logs
+		WHERE transaction_id = $1
+		ORDER BY created_at DESC
+		LIMIT 1
+	`, transactionID)
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "transaction has no action to undo"})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch audit log"})
+	}
+
+	if !undoableActions[lastAction.Action] {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("cannot undo a %s action", lastAction.Action),
+		})
+	}
+	if !lastAction.PreviousStatus.Valid {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "audit log has no prior state to restore"})
+	}
+	if time.Since(lastAction.CreatedAt) > undoWindow() {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "undo window has elapsed"})
+	}
+
+	// A posting that's already been exported has had whatever consumed it
+	// already book the numbers as they stood - reversing it now would leave
+	// that downstream system out of sync with no way to reconcile the diff.
+	var exportedCount int
+	if err := tx.Get(&exportedCount, `
+		SELECT COUNT(*) FROM ledger_postings
+		WHERE transaction_id = $1 AND exported_at IS NOT NULL
+	`, transactionID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to check export status"})
+	}
+	if exportedCount > 0 {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "cannot undo: a ledger posting for this transaction has already been exported"})
+	}
+
+	// Reverse whatever posting the action being undone made.
+	openPosting, err := ledger.OpenPostingForTransaction(tx, transactionID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to look up ledger posting"})
+	}
+	if openPosting != "" {
+		if _, err := ledger.Reverse(tx, openPosting, "match undone by "+user.Email); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to reverse ledger entry"})
+		}
+	}
+
+	restoredStatus := lastAction.PreviousStatus.String
+	var restoredConfidence interface{}
+	if lastAction.PreviousConfidenceScore.Valid {
+		restoredConfidence = lastAction.PreviousConfidenceScore.Float64
+	} else {
+		restoredConfidence = nil
+	}
+
+	_, err = tx.Exec(`
+		UPDATE bank_transactions
+		SET status = $1,
+		    matched_invoice_id = $2,
+		    confidence_score = $3
+		WHERE id = $4
+	`, restoredStatus, lastAction.PreviousInvoiceID, restoredConfidence, transactionID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to restore transaction"})
+	}
+
+	// confirmed/manual_matched set the matched invoice to 'paid'; restore it
+	// to whatever it was right before that, so the invoice isn't locked out
+	// of ever being matched again (ManualMatch refuses to match a 'paid'
+	// invoice). mark_external never touched an invoice, so there's nothing
+	// to restore.
+	if current.MatchedInvoiceID.Valid && lastAction.PreviousInvoiceStatus.Valid {
+		_, err = tx.Exec(`
+			UPDATE invoices
+			SET status = $1,
+			    paid_at = CASE WHEN $1 = 'paid' THEN paid_at ELSE NULL END
+			WHERE id = $2
+		`, lastAction.PreviousInvoiceStatus.String, current.MatchedInvoiceID.String)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to restore invoice status"})
+		}
+	}
+
+	if err := h.updateBatchCounters(tx, current.BatchID, current.Status, restoredStatus); err != nil {
+		log.Printf("Warning: Failed to update batch counters: %v", err)
+		// Continue anyway - counters are eventually consistent
+	}
+
+	// Insert audit log, referencing the action it undoes.
+	_, err = tx.Exec(`
+		INSERT INTO match_audit_logs (
+			transaction_id, action, previous_invoice_id, new_invoice_id,
+			previous_status, new_status, previous_confidence_score,
+			performed_by, performed_by_user_id, reason, undoes_log_id, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NOW())
+	`, transactionID, "undone", current.MatchedInvoiceID, lastAction.PreviousInvoiceID,
+		current.Status, restoredStatus, current.ConfidenceScore,
+		user.Email, user.ID, "Undid "+lastAction.Action+" by "+user.Email, lastAction.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create audit log"})
+	}
+
+	if err := webhooks.WriteEvent(tx, webhooks.EventTransactionUndone, transactionID, current.BatchID, current.Status, restoredStatus, user.Email, map[string]interface{}{
+		"transactionId": transactionID,
+		"batchId":       current.BatchID,
+		"action":        "undone",
+		"undidAction":   lastAction.Action,
+	}); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to record reconciliation event"})
+	}
+
+	body, err := h.commitWithIdempotency(tx, idempKey, requestHash, user.ID.String(), http.StatusOK, map[string]string{"message": "match undone"})
+	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to commit transaction"})
 	}
 
-	return c.JSON(http.StatusOK, map[string]string{"message": "marked as external"})
+	h.Webhooks.Emit(webhooks.EventTransactionUndone, map[string]interface{}{
+		"transactionId": transactionID,
+		"batchId":       current.BatchID,
+		"action":        "undone",
+		"undidAction":   lastAction.Action,
+	})
+
+	return c.JSONBlob(http.StatusOK, body)
 }
 
 // BulkConfirm confirms all auto_matched transactions in a batch
 func (h *ActionsHandler) BulkConfirm(c echo.Context) error {
+	idempKey, requestHash, err := readIdempotencyKey(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "failed to read request body"})
+	}
+
 	var req BulkConfirmRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
@@ -459,6 +950,11 @@ func (h *ActionsHandler) BulkConfirm(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid batch id"})
 	}
 
+	user := auth.FromContext(c)
+	if user == nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "authentication required"})
+	}
+
 	startTime := time.Now()
 
 	tx, err := h.DB.Beginx()
@@ -467,66 +963,125 @@ func (h *ActionsHandler) BulkConfirm(c echo.Context) error {
 	}
 	defer tx.Rollback()
 
-	// Use CTE for set-based update + audit log insert
+	if handled, err := checkIdempotency(c, tx, idempKey, requestHash, user.ID.String()); handled {
+		return err
+	}
+
+	// Use CTE for set-based update + audit log insert. The final SELECT off
+	// updated (rather than relying on Exec's RowsAffected) gives us back the
+	// ids bulk-confirmed, plus amount/currency so each row can get its own
+	// ledger posting, the same one-row-per-transaction shape the
+	// single-transaction actions write.
 	query := `
 		WITH updated AS (
 			UPDATE bank_transactions
 			SET status = 'confirmed'
 			WHERE upload_batch_id = $1 AND status = 'auto_matched'
-			RETURNING id, matched_invoice_id
+			RETURNING id, matched_invoice_id, confidence_score, amount::text AS amount
+		),
+		audit_ins AS (
+			INSERT INTO match_audit_logs (
+				transaction_id, action, previous_invoice_id, new_invoice_id,
+				previous_status, new_status, previous_confidence_score,
+				performed_by, performed_by_user_id, reason, created_at
+			)
+			SELECT
+				updated.id,
+				'confirmed',
+				updated.matched_invoice_id,
+				updated.matched_invoice_id,
+				'auto_matched',
+				'confirmed',
+				updated.confidence_score,
+				$2,
+				$3,
+				$4,
+				NOW()
+			FROM updated
 		)
-		INSERT INTO match_audit_logs (
-			transaction_id, action, previous_invoice_id, new_invoice_id,
-			performed_by, reason, created_at
-		)
-		SELECT 
-			updated.id,
-			'confirmed',
-			updated.matched_invoice_id,
-			updated.matched_invoice_id,
-			'system',
-			$2,
-			NOW()
+		SELECT updated.id, updated.matched_invoice_id, updated.amount, invoices.currency
 		FROM updated
+		LEFT JOIN invoices ON invoices.id = updated.matched_invoice_id
 	`
 
-	result, err := tx.Exec(query, req.BatchID, req.Notes)
-	if err != nil {
+	var confirmed []struct {
+		ID               string         `db:"id"`
+		MatchedInvoiceID sql.NullString `db:"matched_invoice_id"`
+		Amount           string         `db:"amount"`
+		Currency         sql.NullString `db:"currency"`
+	}
+	if err := tx.Select(&confirmed, query, req.BatchID, user.Email, user.ID, req.Notes); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to bulk confirm"})
 	}
 
-	rowsAffected, _ := result.RowsAffected()
+	// LEFT JOIN (not JOIN) so a row the UPDATE above confirmed but that has
+	// no resolvable invoice - unconfirmable via ConfirmMatch (line ~251),
+	// but not something the bulk WHERE clause filters out - still counts
+	// toward rowsAffected below instead of silently vanishing from the
+	// batch counter delta.
+	rowsAffected := int64(len(confirmed))
+	for _, row := range confirmed {
+		if !row.MatchedInvoiceID.Valid || !row.Currency.Valid {
+			log.Printf("Warning: bulk confirm: transaction %s confirmed with no resolvable invoice, skipping ledger posting", row.ID)
+			continue
+		}
 
-	// Update batch counters: decrease auto_matched_count and increase confirmed_count (bulk operation)
-	if rowsAffected > 0 {
-		var batch struct {
-			AutoMatchedCount int `db:"auto_matched_count"`
-			ConfirmedCount   int `db:"confirmed_count"`
+		// Post Dr Bank / Cr Accounts Receivable per confirmed transaction,
+		// the same posting ConfirmMatch makes for a single confirmation.
+		if _, err := ledger.Post(tx, "transaction bulk confirmed", row.ID, row.MatchedInvoiceID.String, req.BatchID, []ledger.Entry{
+			{AccountCode: ledger.AccountBank, Direction: ledger.DirectionDebit, Amount: row.Amount, Currency: row.Currency.String},
+			{AccountCode: ledger.AccountAccountsReceivable, Direction: ledger.DirectionCredit, Amount: row.Amount, Currency: row.Currency.String},
+		}); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to post ledger entry"})
 		}
-		err = tx.Get(&batch, `SELECT auto_matched_count, confirmed_count FROM reconciliation_batches WHERE id = $1`, req.BatchID)
-		if err == nil {
-			newAutoMatched := batch.AutoMatchedCount - int(rowsAffected)
-			if newAutoMatched < 0 {
-				newAutoMatched = 0
-			}
-			newConfirmed := batch.ConfirmedCount + int(rowsAffected)
-			// Use formatted query to avoid prepared statements (safe: validated UUID and integer)
-			updateQuery := fmt.Sprintf(`UPDATE reconciliation_batches SET auto_matched_count = %d, confirmed_count = %d WHERE id = '%s'`, newAutoMatched, newConfirmed, req.BatchID)
-			_, _ = tx.Exec(updateQuery) // Ignore error - counters are eventually consistent
+
+		if err := webhooks.WriteEvent(tx, webhooks.EventTransactionManuallyMatched, row.ID, req.BatchID, "auto_matched", "confirmed", user.Email, map[string]interface{}{
+			"transactionId": row.ID,
+			"batchId":       req.BatchID,
+			"invoiceId":     row.MatchedInvoiceID.String,
+			"action":        "bulk_confirmed",
+		}); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to record reconciliation event"})
 		}
 	}
 
-	if err := tx.Commit(); err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to commit transaction"})
+	// Update batch counters: every bulk-confirmed row moved from
+	// auto_matched to confirmed. A single parameterized delta UPDATE, same
+	// as updateBatchCounters - no read-modify-write race to lose rows to.
+	if rowsAffected > 0 {
+		_, err := tx.Exec(`
+			UPDATE reconciliation_batches
+			SET auto_matched_count = GREATEST(auto_matched_count - $1, 0),
+			    confirmed_count = confirmed_count + $1
+			WHERE id = $2
+		`, rowsAffected, req.BatchID)
+		if err != nil {
+			log.Printf("Warning: Failed to update batch counters: %v", err)
+			// Continue anyway - a nightly batchcounters.Recompute sweep
+			// (or POST .../recount) fixes any drift from this path.
+		}
 	}
 
 	duration := time.Since(startTime)
+	body, err := h.commitWithIdempotency(tx, idempKey, requestHash, user.ID.String(), http.StatusOK, map[string]interface{}{
+		"message":   "bulk confirm completed",
+		"confirmed": rowsAffected,
+		"duration":  duration.String(),
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to commit transaction"})
+	}
+
 	log.Printf("Bulk confirm: batch_id=%s, confirmed=%d, duration=%v", req.BatchID, rowsAffected, duration)
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"message":      "bulk confirm completed",
-		"confirmed":    rowsAffected,
-		"duration":     duration.String(),
-	})
+	if rowsAffected > 0 {
+		h.Webhooks.Emit(webhooks.EventTransactionManuallyMatched, map[string]interface{}{
+			"batchId":   req.BatchID,
+			"confirmed": rowsAffected,
+			"action":    "bulk_confirmed",
+		})
+	}
+
+	return c.JSONBlob(http.StatusOK, body)
 }
 
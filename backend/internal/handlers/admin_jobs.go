@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/labstack/echo/v4"
+)
+
+type AdminJobsHandler struct {
+	DB *sqlx.DB
+}
+
+func NewAdminJobsHandler(db *sqlx.DB) *AdminJobsHandler {
+	return &AdminJobsHandler{DB: db}
+}
+
+type AdminJobItem struct {
+	ID        string  `json:"id"`
+	BatchID   string  `json:"batchId"`
+	FilePath  string  `json:"filePath"`
+	Status    string  `json:"status"`
+	Priority  int     `json:"priority"`
+	Attempts  int     `json:"attempts"`
+	LastError *string `json:"lastError"`
+	UpdatedAt string  `json:"updatedAt"`
+}
+
+type AdminJobsResponse struct {
+	Items      []AdminJobItem `json:"items"`
+	NextCursor *string        `json:"nextCursor"`
+}
+
+// ListJobs lists reconciliation_jobs, optionally filtered by status (e.g.
+// "dead_letter" to find jobs waiting on a human decision), with the same
+// (cursor timestamp, id) keyset pagination ListTransactions uses - except
+// keyed on updated_at since that's what an admin polling for "what changed"
+// cares about here, not created_at.
+func (h *AdminJobsHandler) ListJobs(c echo.Context) error {
+	status := c.QueryParam("status")
+	limitStr := c.QueryParam("limit")
+	cursor := c.QueryParam("cursor")
+
+	limit := 50
+	if limitStr != "" {
+		parsedLimit, err := strconv.Atoi(limitStr)
+		if err != nil || parsedLimit < 1 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid limit"})
+		}
+		limit = parsedLimit
+		if limit > 200 {
+			limit = 200
+		}
+	}
+
+	var cursorUpdatedAt *time.Time
+	var cursorID *string
+	if cursor != "" {
+		updatedAt, id, err := decodeCursor(cursor)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid cursor"})
+		}
+		cursorUpdatedAt = &updatedAt
+		cursorID = &id
+	}
+
+	query := `
+		SELECT id::text, batch_id::text, file_path, status::text, priority, attempts, last_error, updated_at
+		FROM reconciliation_jobs
+		WHERE 1=1
+	`
+	args := []interface{}{}
+	argNum := 1
+
+	if status != "" {
+		query += ` AND status = $` + strconv.Itoa(argNum)
+		args = append(args, status)
+		argNum++
+	}
+
+	if cursorUpdatedAt != nil {
+		query += ` AND (updated_at, id) < ($` + strconv.Itoa(argNum) + `, $` + strconv.Itoa(argNum+1) + `)`
+		args = append(args, *cursorUpdatedAt, *cursorID)
+		argNum += 2
+	}
+
+	query += ` ORDER BY updated_at DESC, id DESC LIMIT $` + strconv.Itoa(argNum)
+	args = append(args, limit)
+
+	type dbRow struct {
+		ID        string         `db:"id"`
+		BatchID   string         `db:"batch_id"`
+		FilePath  string         `db:"file_path"`
+		Status    string         `db:"status"`
+		Priority  int            `db:"priority"`
+		Attempts  int            `db:"attempts"`
+		LastError sql.NullString `db:"last_error"`
+		UpdatedAt time.Time      `db:"updated_at"`
+	}
+
+	var rows []dbRow
+	if err := h.DB.Select(&rows, query, args...); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch jobs"})
+	}
+
+	items := make([]AdminJobItem, 0, len(rows))
+	for _, row := range rows {
+		item := AdminJobItem{
+			ID:        row.ID,
+			BatchID:   row.BatchID,
+			FilePath:  row.FilePath,
+			Status:    row.Status,
+			Priority:  row.Priority,
+			Attempts:  row.Attempts,
+			UpdatedAt: row.UpdatedAt.UTC().Format(time.RFC3339),
+		}
+		if row.LastError.Valid {
+			item.LastError = &row.LastError.String
+		}
+		items = append(items, item)
+	}
+
+	var nextCursor *string
+	if len(items) == limit && len(items) > 0 {
+		lastRow := rows[len(rows)-1]
+		encoded := encodeCursor(lastRow.UpdatedAt, lastRow.ID)
+		nextCursor = &encoded
+	}
+
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.JSON(http.StatusOK, AdminJobsResponse{Items: items, NextCursor: nextCursor})
+}
+
+// RequeueJob resets a job (typically one sitting in the dead letter queue)
+// back to a fresh, immediately-eligible queued state, for use after a human
+// has fixed whatever made it permanently fail (re-uploaded a corrected
+// file, patched the data it depended on).
+func (h *AdminJobsHandler) RequeueJob(c echo.Context) error {
+	jobID := c.Param("id")
+	if _, err := uuid.Parse(jobID); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid job id"})
+	}
+
+	result, err := h.DB.Exec(`
+		UPDATE reconciliation_jobs
+		SET status = 'queued',
+		    attempts = 0,
+		    scheduled_for = NOW(),
+		    last_error = NULL,
+		    updated_at = NOW()
+		WHERE id = $1
+	`, jobID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to requeue job"})
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to requeue job"})
+	}
+	if rowsAffected == 0 {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "job not found"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"status": "queued"})
+}
@@ -1,18 +1,24 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/labstack/echo/v4"
+	"payment-reconciliation-engine/backend/internal/batchcounters"
+	"payment-reconciliation-engine/backend/internal/cache"
 )
 
 type BatchHandler struct {
-	DB *sqlx.DB
+	DB    *sqlx.DB
+	Cache *cache.Client // optional, nil unless REDIS_URL is set
 }
 
 type BatchResponse struct {
@@ -33,20 +39,91 @@ type BatchResponse struct {
 		Unmatched    float64 `json:"unmatched"`
 		Confirmed    float64 `json:"confirmed"`
 		External     float64 `json:"external"`
+		// Net/Tax break down the matched totals above into VAT-exclusive and
+		// VAT portions (summed from the matched invoices' total_net/total_gross),
+		// so operators can reconcile VAT returns from this same view.
+		Net          float64 `json:"net"`
+		Tax          float64 `json:"tax"`
 	} `json:"totals"`
 	StartedAt   string  `json:"startedAt"`
 	CompletedAt *string `json:"completedAt"`
 	UpdatedAt   string  `json:"updatedAt"`
 	ProgressPercent *float64 `json:"progressPercent,omitempty"`
+	// JobStartedAt/JobEndedAt come from reconciliation_jobs rather than this
+	// batch's own started_at (stamped at upload time) - they're when a
+	// worker actually picked the job up and finished it, so operators can
+	// see true wall-clock processing time instead of inferring it from
+	// updated_at.
+	JobStartedAt *string `json:"jobStartedAt,omitempty"`
+	JobEndedAt   *string `json:"jobEndedAt,omitempty"`
 }
 
 func NewBatchHandler(db *sqlx.DB) *BatchHandler {
-	return &BatchHandler{DB: db}
+	redisCache, _ := cache.NewFromEnv()
+	return &BatchHandler{DB: db, Cache: redisCache}
+}
+
+// snapshotToResponse adapts a cached snapshot into the same BatchResponse
+// shape GetBatch returns from Postgres. A snapshot carries no start time,
+// so StartedAt is filled from its UpdatedAt, and JobStartedAt/JobEndedAt
+// are left unset.
+func snapshotToResponse(batchID string, snap *cache.BatchSnapshot) BatchResponse {
+	response := BatchResponse{
+		BatchID:           batchID,
+		Status:            snap.Status,
+		ProcessedCount:    snap.ProcessedCount,
+		TotalTransactions: snap.TotalTransactions,
+		StartedAt:         snap.UpdatedAt,
+		UpdatedAt:         snap.UpdatedAt,
+		CompletedAt:       snap.CompletedAt,
+	}
+	response.Counts.AutoMatched = snap.AutoMatchedCount
+	response.Counts.NeedsReview = snap.NeedsReviewCount
+	response.Counts.Unmatched = snap.UnmatchedCount
+	response.Counts.Confirmed = snap.ConfirmedCount
+	response.Counts.External = snap.ExternalCount
+	response.Totals.AutoMatched = snap.AutoMatchedTotal
+	response.Totals.NeedsReview = snap.NeedsReviewTotal
+	response.Totals.Unmatched = snap.UnmatchedTotal
+	response.Totals.Confirmed = snap.ConfirmedTotal
+	response.Totals.External = snap.ExternalTotal
+	response.Totals.Net = snap.NetTotal
+	response.Totals.Tax = snap.TaxTotal
+
+	if snap.TotalTransactions != nil && *snap.TotalTransactions > 0 {
+		percent := float64(snap.ProcessedCount) / float64(*snap.TotalTransactions) * 100.0
+		if percent > 100.0 {
+			percent = 100.0
+		}
+		response.ProgressPercent = &percent
+	}
+
+	return response
 }
 
 func (h *BatchHandler) GetBatch(c echo.Context) error {
 	batchID := c.Param("batchId")
 
+	if len(batchID) != 36 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid batch id format"})
+	}
+
+	// Try Redis first so a frontend polling every second doesn't hammer the
+	// Neon pooler with the SUM(CASE WHEN ...) aggregate below.
+	if h.Cache != nil {
+		ctx, cancel := context.WithTimeout(c.Request().Context(), 500*time.Millisecond)
+		snap, err := h.Cache.GetSnapshot(ctx, batchID)
+		cancel()
+		if err == nil {
+			c.Response().Header().Set("Cache-Control", "no-store")
+			c.Response().Header().Set("X-Cache", "HIT")
+			return c.JSON(http.StatusOK, snapshotToResponse(batchID, snap))
+		}
+		if !cache.IsMiss(err) {
+			c.Logger().Warnf("Redis lookup failed for batch %s, falling back to Postgres: %v", batchID, err)
+		}
+	}
+
 	// Query batch by PK only (fast, indexed)
 	var batch struct {
 		ID                string         `db:"id"`
@@ -61,17 +138,13 @@ func (h *BatchHandler) GetBatch(c echo.Context) error {
 		StartedAt         time.Time      `db:"started_at"`
 		CompletedAt       sql.NullTime   `db:"completed_at"`
 		CreatedAt        time.Time      `db:"created_at"`
-	}
-
-	// Validate batchID is a valid UUID format
-	if len(batchID) != 36 {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid batch id format"})
+		UpdatedAt        time.Time      `db:"updated_at"`
 	}
 
 	// Query batch - PostgreSQL will automatically convert string to UUID
 	// Use COALESCE to handle missing columns gracefully (if migration hasn't run)
 	err := h.DB.Get(&batch, `
-		SELECT 
+		SELECT
 			id::text as id,
 			status::text as status,
 			processed_count,
@@ -83,7 +156,8 @@ func (h *BatchHandler) GetBatch(c echo.Context) error {
 			COALESCE(external_count, 0) as external_count,
 			started_at,
 			completed_at,
-			created_at
+			created_at,
+			COALESCE(updated_at, created_at) as updated_at
 		FROM reconciliation_batches
 		WHERE id = $1
 	`, batchID)
@@ -102,7 +176,7 @@ func (h *BatchHandler) GetBatch(c echo.Context) error {
 		Status:         batch.Status,
 		ProcessedCount: batch.ProcessedCount,
 		StartedAt:      batch.StartedAt.Format(time.RFC3339),
-		UpdatedAt:      batch.CreatedAt.Format(time.RFC3339), // Using created_at as updated_at proxy
+		UpdatedAt:      batch.UpdatedAt.Format(time.RFC3339),
 	}
 
 	// Set total transactions (nullable)
@@ -127,6 +201,32 @@ func (h *BatchHandler) GetBatch(c echo.Context) error {
 	response.Counts.Confirmed = batch.ConfirmedCount
 	response.Counts.External = batch.ExternalCount
 
+	// Best-effort: pull the job's own started_at/ended_at, falling back to
+	// leaving these unset if the job row is gone or hasn't run yet.
+	var job struct {
+		StartedAt sql.NullTime `db:"started_at"`
+		EndedAt   sql.NullTime `db:"ended_at"`
+	}
+	jobErr := h.DB.Get(&job, `
+		SELECT started_at, ended_at
+		FROM reconciliation_jobs
+		WHERE batch_id = $1
+		ORDER BY created_at DESC
+		LIMIT 1
+	`, batchID)
+	if jobErr != nil && !errors.Is(jobErr, sql.ErrNoRows) {
+		c.Logger().Warnf("Failed to fetch job timestamps for batch %s: %v", batchID, jobErr)
+	} else if jobErr == nil {
+		if job.StartedAt.Valid {
+			startedAt := job.StartedAt.Time.UTC().Format(time.RFC3339)
+			response.JobStartedAt = &startedAt
+		}
+		if job.EndedAt.Valid {
+			endedAt := job.EndedAt.Time.UTC().Format(time.RFC3339)
+			response.JobEndedAt = &endedAt
+		}
+	}
+
 	// Calculate dollar totals by status
 	// Use direct query formatting for Neon pooler compatibility
 	var totals struct {
@@ -188,14 +288,140 @@ func (h *BatchHandler) GetBatch(c echo.Context) error {
 		}
 	}
 
+	// Break matched totals down into VAT-exclusive (net) and VAT (tax)
+	// portions, joining to the matched invoices' own net/gross totals since
+	// bank_transactions only stores the amount the transaction cleared for.
+	var vat struct {
+		NetTotal sql.NullFloat64 `db:"net_total"`
+		TaxTotal sql.NullFloat64 `db:"tax_total"`
+	}
+	vatQuery := fmt.Sprintf(`
+		SELECT
+			COALESCE(SUM(i.total_net), 0) as net_total,
+			COALESCE(SUM(i.total_gross - i.total_net), 0) as tax_total
+		FROM bank_transactions bt
+		JOIN invoices i ON i.id = bt.matched_invoice_id
+		WHERE bt.upload_batch_id = '%s'
+		AND bt.status IN ('auto_matched', 'needs_review', 'confirmed')
+	`, batchID)
+
+	if err := h.DB.Get(&vat, vatQuery); err != nil {
+		c.Logger().Warnf("Failed to fetch VAT breakdown for batch %s: %v", batchID, err)
+	} else {
+		response.Totals.Net = vat.NetTotal.Float64
+		response.Totals.Tax = vat.TaxTotal.Float64
+	}
+
 	// Set completed at (nullable)
 	if batch.CompletedAt.Valid {
 		completedAt := batch.CompletedAt.Time.Format(time.RFC3339)
 		response.CompletedAt = &completedAt
 	}
 
+	// Warm the cache on a Postgres fallback so the next poll is a hit.
+	if h.Cache != nil {
+		go h.cacheResponse(batchID, response)
+	}
+
 	// Set cache control header to prevent caching
 	c.Response().Header().Set("Cache-Control", "no-store")
 
 	return c.JSON(http.StatusOK, response)
 }
+
+func (h *BatchHandler) cacheResponse(batchID string, response BatchResponse) {
+	snap := cache.BatchSnapshot{
+		Status:            response.Status,
+		ProcessedCount:    response.ProcessedCount,
+		TotalTransactions: response.TotalTransactions,
+		AutoMatchedCount:  response.Counts.AutoMatched,
+		NeedsReviewCount:  response.Counts.NeedsReview,
+		UnmatchedCount:    response.Counts.Unmatched,
+		ConfirmedCount:    response.Counts.Confirmed,
+		ExternalCount:     response.Counts.External,
+		AutoMatchedTotal:  response.Totals.AutoMatched,
+		NeedsReviewTotal:  response.Totals.NeedsReview,
+		UnmatchedTotal:    response.Totals.Unmatched,
+		ConfirmedTotal:    response.Totals.Confirmed,
+		ExternalTotal:     response.Totals.External,
+		NetTotal:          response.Totals.Net,
+		TaxTotal:          response.Totals.Tax,
+		CompletedAt:       response.CompletedAt,
+		UpdatedAt:         response.UpdatedAt,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := h.Cache.SetSnapshot(ctx, batchID, snap); err != nil {
+		log.Printf("Warning: Failed to warm batch cache for %s: %v", batchID, err)
+	}
+}
+
+// StreamBatch pushes batch progress updates to a client over SSE as they're
+// published on the batch's Redis channel, so the frontend doesn't need to
+// poll GetBatch. Requires REDIS_URL to be configured.
+func (h *BatchHandler) StreamBatch(c echo.Context) error {
+	batchID := c.Param("batchId")
+	if len(batchID) != 36 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid batch id format"})
+	}
+	if h.Cache == nil {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "streaming is not enabled"})
+	}
+
+	ctx := c.Request().Context()
+	sub := h.Cache.Subscribe(ctx, batchID)
+	defer sub.Close()
+
+	w := c.Response()
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	// Send the current snapshot immediately so a client doesn't wait for the
+	// next update to render anything.
+	if snap, err := h.Cache.GetSnapshot(ctx, batchID); err == nil {
+		if payload, err := json.Marshal(snapshotToResponse(batchID, snap)); err == nil {
+			fmt.Fprintf(w, "data: %s\n\n", payload)
+			w.Flush()
+		}
+	}
+
+	heartbeat := time.NewTicker(15 * time.Second)
+	defer heartbeat.Stop()
+
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-heartbeat.C:
+			fmt.Fprintf(w, ": heartbeat\n\n")
+			w.Flush()
+		case msg, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			fmt.Fprintf(w, "data: %s\n\n", msg.Payload)
+			w.Flush()
+		}
+	}
+}
+
+// RecountBatch recomputes batchId's five status counters from
+// bank_transactions via batchcounters.Recompute, for an operator who
+// suspects drift (or just wants ground truth) instead of waiting on the
+// nightly sweep cmd/worker/main.go runs.
+func (h *BatchHandler) RecountBatch(c echo.Context) error {
+	batchID := c.Param("batchId")
+	if len(batchID) != 36 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid batch id format"})
+	}
+
+	if err := batchcounters.Recompute(h.DB, batchID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to recompute batch counters"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"status": "recounted"})
+}
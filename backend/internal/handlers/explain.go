@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/labstack/echo/v4"
+)
+
+type ExplainHandler struct {
+	DB *sqlx.DB
+}
+
+func NewExplainHandler(db *sqlx.DB) *ExplainHandler {
+	return &ExplainHandler{DB: db}
+}
+
+// ExplainStep mirrors processor.RationaleStep's JSON shape - this package
+// only ever reads it back out of the match_details JSONB column, so it has
+// no reason to import processor just for the struct.
+type ExplainStep struct {
+	Name     string  `json:"name"`
+	Before   float64 `json:"before"`
+	After    float64 `json:"after"`
+	Delta    float64 `json:"delta"`
+	Evidence string  `json:"evidence"`
+}
+
+// ExplainWhyNot mirrors processor.WhyNotCandidate's JSON shape.
+type ExplainWhyNot struct {
+	InvoiceID     string  `json:"invoiceId"`
+	InvoiceNumber string  `json:"invoiceNumber"`
+	Score         float64 `json:"score"`
+	Reason        string  `json:"reason"`
+}
+
+type ExplainResponse struct {
+	TransactionID   string          `json:"transactionId"`
+	Status          string          `json:"status"`
+	MatchedInvoiceID *string        `json:"matchedInvoiceId"`
+	Summary         string          `json:"summary"`
+	Steps           []ExplainStep   `json:"steps"`
+	WhyNot          []ExplainWhyNot `json:"whyNot"`
+}
+
+// GetExplanation renders MatchTransaction's stored match_details JSONB for
+// transaction :id into a structured explanation plus a one-paragraph
+// natural-language summary, for ops staff clearing the needs_review queue
+// and for compliance reviewers auditing a match after the fact. It reads
+// match_details as it was actually persisted at match time rather than
+// re-scoring, so the explanation always reflects what really happened, even
+// after a later ScoringConfig or candidate set change.
+func (h *ExplainHandler) GetExplanation(c echo.Context) error {
+	transactionID := c.Param("id")
+	if _, err := uuid.Parse(transactionID); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid transaction id"})
+	}
+
+	var row struct {
+		Status           string         `db:"status"`
+		Amount           string         `db:"amount"`
+		MatchedInvoiceID sql.NullString `db:"matched_invoice_id"`
+		MatchDetails     sql.NullString `db:"match_details"`
+	}
+	err := h.DB.Get(&row, `
+		SELECT status::text, amount::text, matched_invoice_id::text, match_details::text
+		FROM bank_transactions
+		WHERE id = $1
+	`, transactionID)
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "transaction not found"})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch transaction"})
+	}
+
+	var details map[string]interface{}
+	if row.MatchDetails.Valid && row.MatchDetails.String != "" {
+		_ = json.Unmarshal([]byte(row.MatchDetails.String), &details)
+	}
+
+	response := ExplainResponse{
+		TransactionID: transactionID,
+		Status:        row.Status,
+		Summary:       summarize(row.Status, details),
+		Steps:         extractSteps(details),
+		WhyNot:        extractWhyNot(details),
+	}
+	if row.MatchedInvoiceID.Valid {
+		response.MatchedInvoiceID = &row.MatchedInvoiceID.String
+	}
+
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.JSON(http.StatusOK, response)
+}
+
+// summarize renders the one-paragraph natural-language explanation from
+// match_details' name/date/ambiguity/finalScore keys, which historical rows
+// carry too. The rationale steps are not read here; GetExplanation returns
+// them separately as Steps.
+func summarize(status string, details map[string]interface{}) string {
+	if details == nil {
+		return fmt.Sprintf("Transaction is %s; no scoring details were recorded.", status)
+	}
+
+	name, _ := details["name"].(map[string]interface{})
+	date, _ := details["date"].(map[string]interface{})
+	ambiguity, _ := details["ambiguity"].(map[string]interface{})
+	finalScore, _ := details["finalScore"].(float64)
+	invoiceName, _ := name["invoiceName"].(string)
+	extracted, _ := name["extracted"].(string)
+	similarity, _ := name["similarity"].(float64)
+	deltaDays, _ := date["deltaDays"].(float64)
+	adjustment, _ := date["adjustment"].(float64)
+	ambiguityPenalty, _ := ambiguity["penalty"].(float64)
+
+	var sb strings.Builder
+	if invoiceName != "" {
+		fmt.Fprintf(&sb, "Matched %q with %.0f%% confidence: extracted name %q matched %q at %.0f similarity",
+			invoiceName, finalScore, extracted, invoiceName, similarity)
+	} else {
+		fmt.Fprintf(&sb, "%s with %.0f%% confidence: extracted name %q scored %.0f similarity against the best candidate",
+			strings.ReplaceAll(status, "_", " "), finalScore, extracted, similarity)
+	}
+
+	switch {
+	case deltaDays < 0:
+		fmt.Fprintf(&sb, ", posted %.0f day(s) before the due date (%+.1f)", -deltaDays, adjustment)
+	case deltaDays == 0:
+		sb.WriteString(", posted on the due date")
+	default:
+		fmt.Fprintf(&sb, ", posted %.0f day(s) after the due date (%+.1f)", deltaDays, adjustment)
+	}
+
+	if ambiguityPenalty > 0 {
+		fmt.Fprintf(&sb, ", %.1f point ambiguity penalty from competing candidates", ambiguityPenalty)
+	} else {
+		sb.WriteString(", no ambiguity penalty")
+	}
+	sb.WriteString(".")
+
+	return sb.String()
+}
+
+func extractSteps(details map[string]interface{}) []ExplainStep {
+	raw, ok := details["rationale"].([]interface{})
+	if !ok {
+		return nil
+	}
+	steps := make([]ExplainStep, 0, len(raw))
+	for _, item := range raw {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := m["name"].(string)
+		before, _ := m["before"].(float64)
+		after, _ := m["after"].(float64)
+		delta, _ := m["delta"].(float64)
+		evidence, _ := m["evidence"].(string)
+		steps = append(steps, ExplainStep{Name: name, Before: before, After: after, Delta: delta, Evidence: evidence})
+	}
+	return steps
+}
+
+func extractWhyNot(details map[string]interface{}) []ExplainWhyNot {
+	raw, ok := details["whyNot"].([]interface{})
+	if !ok {
+		return nil
+	}
+	out := make([]ExplainWhyNot, 0, len(raw))
+	for _, item := range raw {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		invoiceID, _ := m["invoiceId"].(string)
+		invoiceNumber, _ := m["invoiceNumber"].(string)
+		score, _ := m["score"].(float64)
+		reason, _ := m["reason"].(string)
+		out = append(out, ExplainWhyNot{InvoiceID: invoiceID, InvoiceNumber: invoiceNumber, Score: score, Reason: reason})
+	}
+	return out
+}
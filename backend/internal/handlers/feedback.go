@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/labstack/echo/v4"
+	"payment-reconciliation-engine/backend/internal/processor/learning"
+)
+
+type FeedbackHandler struct {
+	DB *sqlx.DB
+}
+
+func NewFeedbackHandler(db *sqlx.DB) *FeedbackHandler {
+	return &FeedbackHandler{DB: db}
+}
+
+type FeedbackRequest struct {
+	Decision string `json:"decision"`
+}
+
+var validFeedbackDecisions = map[string]learning.Decision{
+	"confirmed":  learning.DecisionConfirmed,
+	"rejected":   learning.DecisionRejected,
+	"reassigned": learning.DecisionReassigned,
+}
+
+// PostFeedback labels the scored match learning.RecordScored already logged
+// for transaction :id (every needs_review result - see
+// Processor.recordMatchFeedback) with the reviewer's decision, so a later
+// learning.RecalibrateWeights run can train on it. It doesn't itself
+// change the transaction's status - that's still ConfirmMatch/RejectMatch/
+// ManualMatch's job - so a reviewer calling this alongside one of those
+// endpoints labels the same action twice: once as an audited state
+// transition, once as a training example.
+func (h *FeedbackHandler) PostFeedback(c echo.Context) error {
+	transactionID := c.Param("id")
+	if _, err := uuid.Parse(transactionID); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid transaction id"})
+	}
+
+	var req FeedbackRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+
+	decision, ok := validFeedbackDecisions[req.Decision]
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "decision must be confirmed, rejected, or reassigned"})
+	}
+
+	err := learning.RecordDecision(h.DB, transactionID, decision)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "no scored match feedback found for this transaction"})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to record feedback"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "feedback recorded"})
+}
@@ -186,6 +186,11 @@ func (h *InvoicesHandler) buildSearchQuery(q string, amount *float64, status str
 	
 	args := []interface{}{}
 	argNum := 1
+	// similarityArg is the $N placeholder index bound to the search term
+	// when the customer-name branch below picks pg_trgm similarity() over
+	// ILIKE, so the ORDER BY clause can reference the same argument rather
+	// than rebinding q a second time. 0 means no similarity ordering.
+	similarityArg := 0
 
 	// Phase 1: Exact filters (btree indexes)
 	if amount != nil {
@@ -224,23 +229,27 @@ func (h *InvoicesHandler) buildSearchQuery(q string, amount *float64, status str
 			args = append(args, "%"+q+"%")
 			argNum++
 		} else {
-			// Fuzzy search on customer_name using trigram similarity
-			// Use ILIKE with trigram index for fast partial matching
-			query += ` AND customer_name ILIKE $` + strconv.Itoa(argNum)
-			args = append(args, "%"+q+"%")
+			// Fuzzy search on customer_name by pg_trgm similarity (backed by
+			// a GIN trigram index on customer_name), so ranking here agrees
+			// with the trigram Jaccard score the processor package's
+			// jaroWinkler ensemble uses on the matching side - rather than
+			// ILIKE's "contains the substring" threshold, which doesn't rank
+			// results at all.
+			query += ` AND similarity(customer_name, $` + strconv.Itoa(argNum) + `) > 0.3`
+			args = append(args, q)
+			similarityArg = argNum
 			argNum++
 		}
 	}
 
 	// Ordering: prioritize exact matches, then by due_date ascending
-	if amount != nil {
+	switch {
+	case amount != nil:
 		// If amount filter is present, results are already filtered by exact amount
 		query += ` ORDER BY due_date ASC`
-	} else if q != "" {
-		// If text search, order by similarity (trigram index helps here)
-		// For simplicity, order by due_date ASC (useful for matching)
-		query += ` ORDER BY due_date ASC`
-	} else {
+	case similarityArg != 0:
+		query += ` ORDER BY similarity(customer_name, $` + strconv.Itoa(similarityArg) + `) DESC`
+	default:
 		// Default: order by due_date ASC
 		query += ` ORDER BY due_date ASC`
 	}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/labstack/echo/v4"
+)
+
+type LedgerHandler struct {
+	DB *sqlx.DB
+}
+
+func NewLedgerHandler(db *sqlx.DB) *LedgerHandler {
+	return &LedgerHandler{DB: db}
+}
+
+type LedgerEntryItem struct {
+	ID          string  `json:"id" db:"id"`
+	PostingID   string  `json:"postingId" db:"posting_id"`
+	AccountCode string  `json:"accountCode" db:"account_code"`
+	Direction   string  `json:"direction" db:"direction"`
+	Amount      string  `json:"amount" db:"amount"`
+	Currency    string  `json:"currency" db:"currency"`
+	Description string  `json:"description" db:"description"`
+	CreatedAt   string  `json:"createdAt" db:"created_at"`
+	ReversedAt  *string `json:"reversedAt" db:"reversed_at"`
+}
+
+// GetInvoicePostings lists every ledger entry posted against invoiceId's
+// postings, oldest first - the invoice-side view of what ConfirmMatch,
+// ManualMatch, and their reversals have recorded.
+func (h *LedgerHandler) GetInvoicePostings(c echo.Context) error {
+	invoiceID := c.Param("id")
+	if _, err := uuid.Parse(invoiceID); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid invoice id"})
+	}
+
+	var items []LedgerEntryItem
+	err := h.DB.Select(&items, `
+		SELECT le.id, le.posting_id, la.code AS account_code, le.direction, le.amount, le.currency,
+		       lp.description, lp.created_at, lp.reversed_at
+		FROM ledger_entries le
+		JOIN ledger_postings lp ON lp.id = le.posting_id
+		JOIN ledger_accounts la ON la.id = le.account_id
+		WHERE lp.invoice_id = $1
+		ORDER BY lp.created_at ASC
+	`, invoiceID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch postings"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"items": items})
+}
+
+// GetTransactionPostings is GetInvoicePostings' transaction-side
+// equivalent.
+func (h *LedgerHandler) GetTransactionPostings(c echo.Context) error {
+	transactionID := c.Param("id")
+	if _, err := uuid.Parse(transactionID); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid transaction id"})
+	}
+
+	var items []LedgerEntryItem
+	err := h.DB.Select(&items, `
+		SELECT le.id, le.posting_id, la.code AS account_code, le.direction, le.amount, le.currency,
+		       lp.description, lp.created_at, lp.reversed_at
+		FROM ledger_entries le
+		JOIN ledger_postings lp ON lp.id = le.posting_id
+		JOIN ledger_accounts la ON la.id = le.account_id
+		WHERE lp.transaction_id = $1
+		ORDER BY lp.created_at ASC
+	`, transactionID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch postings"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"items": items})
+}
+
+type TrialBalanceRow struct {
+	AccountCode string `json:"accountCode" db:"account_code"`
+	Currency    string `json:"currency" db:"currency"`
+	Debits      string `json:"debits" db:"debits"`
+	Credits     string `json:"credits" db:"credits"`
+}
+
+// GetBatchTrialBalance sums batchId's ledger entries by account and
+// currency - a reconciled batch should show every row's debits equal to its
+// credits.
+func (h *LedgerHandler) GetBatchTrialBalance(c echo.Context) error {
+	batchID := c.Param("batchId")
+	if _, err := uuid.Parse(batchID); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid batch id"})
+	}
+
+	var rows []TrialBalanceRow
+	err := h.DB.Select(&rows, `
+		SELECT la.code AS account_code, le.currency,
+		       COALESCE(SUM(CASE WHEN le.direction = 'debit' THEN le.amount::numeric ELSE 0 END), 0)::text AS debits,
+		       COALESCE(SUM(CASE WHEN le.direction = 'credit' THEN le.amount::numeric ELSE 0 END), 0)::text AS credits
+		FROM ledger_entries le
+		JOIN ledger_postings lp ON lp.id = le.posting_id
+		JOIN ledger_accounts la ON la.id = le.account_id
+		WHERE lp.batch_id = $1
+		GROUP BY la.code, le.currency
+		ORDER BY la.code, le.currency
+	`, batchID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to compute trial balance"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"rows": rows})
+}
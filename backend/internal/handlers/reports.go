@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/labstack/echo/v4"
+)
+
+type ReportsHandler struct {
+	DB *sqlx.DB
+}
+
+func NewReportsHandler(db *sqlx.DB) *ReportsHandler {
+	return &ReportsHandler{DB: db}
+}
+
+// ReconciliationSummaryBucket aggregates matched/unmatched totals for one
+// (cost centre, VAT rate) bucket, built from invoice_line_items joined
+// against whatever match_allocation rows processor.allocateLineItems has
+// persisted for them.
+type ReconciliationSummaryBucket struct {
+	CostCentreID string  `json:"costCentreId"`
+	VATRateBps   int     `json:"vatRateBps"`
+	MatchedNet   float64 `json:"matchedNet"`
+	MatchedVAT   float64 `json:"matchedVat"`
+	ExemptNet    float64 `json:"exemptNet"` // matchedNet for buckets where vatRateBps = 0
+	UnmatchedNet float64 `json:"unmatchedNet"`
+	UnmatchedVAT float64 `json:"unmatchedVat"`
+}
+
+type ReconciliationSummaryResponse struct {
+	Buckets []ReconciliationSummaryBucket `json:"buckets"`
+}
+
+// GetReconciliationSummary aggregates every invoice line item by
+// (cost_centre_id, vat_rate_bps), splitting each bucket's total into what's
+// actually been matched (summed from match_allocation) and what's still
+// outstanding, so finance can close a VAT period straight from this
+// endpoint instead of a separate ETL pass over invoices/transactions.
+func (h *ReportsHandler) GetReconciliationSummary(c echo.Context) error {
+	var rows []struct {
+		CostCentreID string  `db:"cost_centre_id"`
+		VATRateBps   int     `db:"vat_rate_bps"`
+		LineNet      float64 `db:"line_net"`
+		LineVAT      float64 `db:"line_vat"`
+		MatchedNet   float64 `db:"matched_net"`
+		MatchedVAT   float64 `db:"matched_vat"`
+	}
+
+	err := h.DB.Select(&rows, `
+		SELECT
+			li.cost_centre_id,
+			li.vat_rate_bps,
+			COALESCE(SUM(li.net_amount), 0) AS line_net,
+			COALESCE(SUM(li.vat_amount), 0) AS line_vat,
+			COALESCE(SUM(a.allocated_net), 0) AS matched_net,
+			COALESCE(SUM(a.allocated_vat), 0) AS matched_vat
+		FROM invoice_line_items li
+		LEFT JOIN match_allocation a ON a.line_item_id = li.id
+		GROUP BY li.cost_centre_id, li.vat_rate_bps
+		ORDER BY li.cost_centre_id, li.vat_rate_bps
+	`)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to aggregate reconciliation summary"})
+	}
+
+	buckets := make([]ReconciliationSummaryBucket, 0, len(rows))
+	for _, r := range rows {
+		bucket := ReconciliationSummaryBucket{
+			CostCentreID: r.CostCentreID,
+			VATRateBps:   r.VATRateBps,
+			MatchedNet:   r.MatchedNet,
+			MatchedVAT:   r.MatchedVAT,
+			UnmatchedNet: r.LineNet - r.MatchedNet,
+			UnmatchedVAT: r.LineVAT - r.MatchedVAT,
+		}
+		if r.VATRateBps == 0 {
+			bucket.ExemptNet = r.MatchedNet
+		}
+		buckets = append(buckets, bucket)
+	}
+
+	return c.JSON(http.StatusOK, ReconciliationSummaryResponse{Buckets: buckets})
+}
@@ -28,6 +28,11 @@ type TransactionDetailResponse struct {
 	MatchDetails      map[string]interface{} `json:"matchDetails"`
 	CreatedAt         string                 `json:"createdAt"`
 	UpdatedAt         string                 `json:"updatedAt"`
+	// JobStartedAt/JobEndedAt are the reconciliation_jobs timestamps for the
+	// job that produced this transaction, so an operator can see true
+	// wall-clock processing time rather than inferring it from updatedAt.
+	JobStartedAt      *string                `json:"jobStartedAt,omitempty"`
+	JobEndedAt        *string                `json:"jobEndedAt,omitempty"`
 	Invoice           *InvoiceDetail         `json:"invoice,omitempty"`
 	CanConfirm        bool                   `json:"canConfirm"`
 	CanReject         bool                   `json:"canReject"`
@@ -71,7 +76,9 @@ func (h *TransactionDetailHandler) GetTransaction(c echo.Context) error {
 		MatchedInvoiceID  sql.NullString  `db:"matched_invoice_id"`
 		MatchDetails      sql.NullString  `db:"match_details"` // JSONB as string
 		CreatedAt         time.Time       `db:"created_at"`
-		
+		JobStartedAt      sql.NullTime    `db:"job_started_at"`
+		JobEndedAt        sql.NullTime    `db:"job_ended_at"`
+
 		// Invoice fields (nullable)
 		InvoiceID         sql.NullString `db:"invoice_id"`
 		InvoiceNumber     sql.NullString `db:"invoice_number"`
@@ -95,6 +102,8 @@ func (h *TransactionDetailHandler) GetTransaction(c echo.Context) error {
 			bt.matched_invoice_id::text,
 			bt.match_details::text,
 			bt.created_at,
+			j.started_at AS job_started_at,
+			j.ended_at AS job_ended_at,
 			i.id::text AS invoice_id,
 			i.invoice_number AS invoice_number,
 			i.customer_name AS invoice_customer_name,
@@ -104,6 +113,7 @@ func (h *TransactionDetailHandler) GetTransaction(c echo.Context) error {
 			i.status::text AS invoice_status
 		FROM bank_transactions bt
 		LEFT JOIN invoices i ON bt.matched_invoice_id = i.id
+		LEFT JOIN reconciliation_jobs j ON j.batch_id = bt.upload_batch_id
 		WHERE bt.id = $1
 	`
 
@@ -128,6 +138,16 @@ func (h *TransactionDetailHandler) GetTransaction(c echo.Context) error {
 		UpdatedAt:       row.CreatedAt.Format(time.RFC3339), // Using created_at as proxy
 	}
 
+	if row.JobStartedAt.Valid {
+		jobStartedAt := row.JobStartedAt.Time.UTC().Format(time.RFC3339)
+		response.JobStartedAt = &jobStartedAt
+	}
+
+	if row.JobEndedAt.Valid {
+		jobEndedAt := row.JobEndedAt.Time.UTC().Format(time.RFC3339)
+		response.JobEndedAt = &jobEndedAt
+	}
+
 	if row.ReferenceNumber.Valid {
 		response.ReferenceNumber = &row.ReferenceNumber.String
 	}
@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/labstack/echo/v4"
+)
+
+// tusResumableVersion is the only tus.io protocol version this handler
+// implements (the core protocol plus the creation extension).
+const tusResumableVersion = "1.0.0"
+
+// uploadSessionTTL bounds how long after creation a resumable upload can
+// still be resumed: PATCH refuses a session past its expires_at (HEAD still
+// reports its offset, and PATCH does not extend the deadline), so an abandoned session
+// doesn't hold its partial file on disk forever. Nothing sweeps expired
+// rows/files yet - that's the same "a future cleanup job can reap these"
+// tradeoff the dead letter queue started with before anything read it.
+const uploadSessionTTL = 24 * time.Hour
+
+// uploadSession mirrors a row of the upload_sessions table: one resumable
+// upload, tracked from creation through however many PATCH chunks it
+// takes to reach TotalSize.
+type uploadSession struct {
+	ID             string    `db:"id"`
+	Filename       string    `db:"filename"`
+	ContentType    string    `db:"content_type"`
+	TotalSize      int64     `db:"total_size"`
+	Offset         int64     `db:"offset"`
+	ExpiresAt      time.Time `db:"expires_at"`
+	ChecksumSHA256 *string   `db:"checksum_sha256"`
+	FilePath       string    `db:"file_path"`
+	SchemaHint     *string   `db:"schema_hint"`
+	Status         string    `db:"status"`
+}
+
+// TusUploadHandler implements the subset of the tus.io 1.0 resumable
+// upload protocol this API needs for large statement files: POST to
+// create a session, PATCH to append a chunk, HEAD to query how much has
+// landed so far, and DELETE to cancel. The final chunk is handed to
+// Upload.ingestContent - the same validate/store/dispatch path a
+// single-request multipart upload goes through - so a reconciliation
+// batch looks identical regardless of which upload path produced it.
+type TusUploadHandler struct {
+	DB        *sqlx.DB
+	Upload    *UploadHandler
+	UploadDir string
+}
+
+func NewTusUploadHandler(db *sqlx.DB, uploadHandler *UploadHandler, uploadDir string) *TusUploadHandler {
+	return &TusUploadHandler{DB: db, Upload: uploadHandler, UploadDir: uploadDir}
+}
+
+// Create handles POST /api/reconciliation/uploads: reserves a session for
+// Upload-Length bytes and returns its id in the Location header, per the
+// tus creation extension.
+func (h *TusUploadHandler) Create(c echo.Context) error {
+	c.Response().Header().Set("Tus-Resumable", tusResumableVersion)
+
+	totalSize, err := strconv.ParseInt(c.Request().Header.Get("Upload-Length"), 10, 64)
+	if err != nil || totalSize <= 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "missing or invalid Upload-Length header"})
+	}
+	if totalSize > h.Upload.MaxSize {
+		return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{"error": fmt.Sprintf("upload exceeds maximum size of %d bytes", h.Upload.MaxSize)})
+	}
+
+	metadata := parseUploadMetadata(c.Request().Header.Get("Upload-Metadata"))
+	filename := metadata["filename"]
+	if filename == "" {
+		filename = "upload"
+	}
+
+	if err := os.MkdirAll(h.UploadDir, 0755); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create upload directory"})
+	}
+
+	id := uuid.New().String()
+	filePath := filepath.Join(h.UploadDir, "tus-"+id+".part")
+	f, err := os.Create(filePath)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create upload session file"})
+	}
+	f.Close()
+
+	var schemaHint *string
+	if hint := metadata["schema_hint"]; hint != "" {
+		schemaHint = &hint
+	}
+
+	_, err = h.DB.Exec(`
+		INSERT INTO upload_sessions (id, filename, content_type, total_size, "offset", expires_at, file_path, schema_hint, status)
+		VALUES ($1, $2, $3, $4, 0, $5, $6, $7, 'active')
+	`, id, filename, metadata["content_type"], totalSize, time.Now().Add(uploadSessionTTL), filePath, schemaHint)
+	if err != nil {
+		os.Remove(filePath)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create upload session"})
+	}
+
+	c.Response().Header().Set("Location", fmt.Sprintf("/api/reconciliation/uploads/%s", id))
+	return c.NoContent(http.StatusCreated)
+}
+
+// Head handles HEAD /api/reconciliation/uploads/:id: reports how much of
+// the upload has landed so far, so a resuming client knows where to seek
+// its next PATCH to.
+func (h *TusUploadHandler) Head(c echo.Context) error {
+	c.Response().Header().Set("Tus-Resumable", tusResumableVersion)
+
+	session, err := h.loadSession(c.Param("id"))
+	if err != nil {
+		return c.NoContent(http.StatusNotFound)
+	}
+
+	c.Response().Header().Set("Upload-Offset", strconv.FormatInt(session.Offset, 10))
+	c.Response().Header().Set("Upload-Length", strconv.FormatInt(session.TotalSize, 10))
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.NoContent(http.StatusOK)
+}
+
+// Patch handles PATCH /api/reconciliation/uploads/:id: appends the
+// request body to the session's file starting at Upload-Offset. Once the
+// appended bytes bring the file up to TotalSize, it finalizes the upload
+// into a reconciliation batch instead of just acknowledging the chunk.
+func (h *TusUploadHandler) Patch(c echo.Context) error {
+	c.Response().Header().Set("Tus-Resumable", tusResumableVersion)
+
+	session, err := h.loadSession(c.Param("id"))
+	if err != nil {
+		return c.NoContent(http.StatusNotFound)
+	}
+	if session.Status != "active" {
+		return c.JSON(http.StatusGone, map[string]string{"error": "upload session is no longer active"})
+	}
+	if time.Now().After(session.ExpiresAt) {
+		return c.JSON(http.StatusGone, map[string]string{"error": "upload session has expired"})
+	}
+
+	offset, err := strconv.ParseInt(c.Request().Header.Get("Upload-Offset"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "missing or invalid Upload-Offset header"})
+	}
+	if offset != session.Offset {
+		// The client's view of how much it's already sent has diverged
+		// from ours - tell it where we actually are so it can resync
+		// instead of silently corrupting the file with a misaligned write.
+		return c.JSON(http.StatusConflict, map[string]string{"error": fmt.Sprintf("offset mismatch: expected %d", session.Offset)})
+	}
+
+	f, err := os.OpenFile(session.FilePath, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to open upload session file"})
+	}
+	defer f.Close()
+
+	written, err := io.Copy(f, c.Request().Body)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to write chunk"})
+	}
+
+	newOffset := offset + written
+	if newOffset > session.TotalSize {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "upload exceeds declared Upload-Length"})
+	}
+
+	if _, err := h.DB.Exec(`UPDATE upload_sessions SET "offset" = $1, updated_at = NOW() WHERE id = $2`, newOffset, session.ID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to record upload progress"})
+	}
+	c.Response().Header().Set("Upload-Offset", strconv.FormatInt(newOffset, 10))
+
+	if newOffset < session.TotalSize {
+		return c.NoContent(http.StatusNoContent)
+	}
+
+	session.Offset = newOffset
+	return h.finalize(c, session)
+}
+
+// Delete handles DELETE /api/reconciliation/uploads/:id: discards a
+// session's partial file and row outright, the way a client abandoning an
+// upload (or retrying with a fresh one) is expected to clean up after
+// itself.
+func (h *TusUploadHandler) Delete(c echo.Context) error {
+	c.Response().Header().Set("Tus-Resumable", tusResumableVersion)
+
+	session, err := h.loadSession(c.Param("id"))
+	if err != nil {
+		return c.NoContent(http.StatusNotFound)
+	}
+
+	os.Remove(session.FilePath)
+	if _, err := h.DB.Exec(`DELETE FROM upload_sessions WHERE id = $1`, session.ID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to delete upload session"})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// finalize runs once session's last byte has landed: checksum the
+// completed file, hand it to ingestContent for the same validation/store/
+// dispatch path a regular upload goes through, and record the outcome on
+// the session row's status so a later lookup of that row can tell a
+// completed upload from a failed one instead of both just vanishing (HEAD
+// reports only the offset and length, not the status).
+func (h *TusUploadHandler) finalize(c echo.Context, session *uploadSession) error {
+	content, err := os.ReadFile(session.FilePath)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to read completed upload"})
+	}
+
+	sum := sha256.Sum256(content)
+	checksum := hex.EncodeToString(sum[:])
+
+	schemaHint := ""
+	if session.SchemaHint != nil {
+		schemaHint = *session.SchemaHint
+	}
+
+	batchID, ingestErr := h.Upload.ingestContent(c.Request().Context(), session.Filename, session.ContentType, content, schemaHint)
+	os.Remove(session.FilePath)
+
+	if ingestErr != nil {
+		h.DB.Exec(`UPDATE upload_sessions SET status = 'failed', checksum_sha256 = $1, updated_at = NOW() WHERE id = $2`, checksum, session.ID)
+		return respondUploadError(c, ingestErr)
+	}
+
+	h.DB.Exec(`UPDATE upload_sessions SET status = 'completed', checksum_sha256 = $1, updated_at = NOW() WHERE id = $2`, checksum, session.ID)
+
+	return c.JSON(http.StatusCreated, UploadResponse{
+		BatchID: batchID,
+		Status:  "processing",
+	})
+}
+
+func (h *TusUploadHandler) loadSession(id string) (*uploadSession, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("invalid upload id")
+	}
+	var session uploadSession
+	if err := h.DB.Get(&session, `SELECT * FROM upload_sessions WHERE id = $1`, id); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// parseUploadMetadata decodes a tus Upload-Metadata header: a
+// comma-separated list of "key base64(value)" pairs (a bare key with no
+// base64 part means an empty-string value), per the tus creation
+// extension.
+func parseUploadMetadata(header string) map[string]string {
+	result := make(map[string]string)
+	if header == "" {
+		return result
+	}
+	for _, pair := range strings.Split(header, ",") {
+		parts := strings.SplitN(strings.TrimSpace(pair), " ", 2)
+		key := parts[0]
+		if key == "" {
+			continue
+		}
+		if len(parts) == 1 {
+			result[key] = ""
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(parts[1])
+		if err != nil {
+			continue
+		}
+		result[key] = string(decoded)
+	}
+	return result
+}
@@ -1,7 +1,10 @@
 package handlers
 
 import (
+	"bytes"
+	"context"
 	"encoding/csv"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -12,12 +15,44 @@ import (
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/jmoiron/sqlx"
+	"payment-reconciliation-engine/backend/internal/jobs"
+	"payment-reconciliation-engine/backend/internal/processor"
 )
 
+// uploadError carries the HTTP status code an ingestContent failure
+// should be reported with, so every caller - the single-request Upload
+// handler and the resumable TusUploadHandler alike - translates the same
+// failure into the same response instead of each guessing its own status.
+type uploadError struct {
+	status  int
+	message string
+}
+
+func (e *uploadError) Error() string { return e.message }
+
+func badRequestf(format string, args ...interface{}) *uploadError {
+	return &uploadError{status: http.StatusBadRequest, message: fmt.Sprintf(format, args...)}
+}
+
+func internalErrorf(format string, args ...interface{}) *uploadError {
+	return &uploadError{status: http.StatusInternalServerError, message: fmt.Sprintf(format, args...)}
+}
+
+// respondUploadError renders err as JSON with its uploadError status, or
+// 500 for anything ingestContent returned that isn't one (shouldn't
+// happen, but a plain error is safer to surface than a panic).
+func respondUploadError(c echo.Context, err error) error {
+	if ue, ok := err.(*uploadError); ok {
+		return c.JSON(ue.status, map[string]string{"error": ue.message})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+}
+
 type UploadHandler struct {
-	DB        *sqlx.DB
-	UploadDir string
-	MaxSize   int64 // Max file size in bytes (50MB default)
+	DB         *sqlx.DB
+	Dispatcher jobs.Dispatcher
+	UploadDir  string
+	MaxSize    int64 // Max file size in bytes (50MB default)
 }
 
 type UploadResponse struct {
@@ -25,11 +60,29 @@ type UploadResponse struct {
 	Status  string `json:"status"`
 }
 
+// storedExtensions maps a detected format to the extension its file is
+// stored under in UploadDir, so an admin poking around the upload
+// directory can tell a batch's format without opening it.
+var storedExtensions = map[string]string{
+	processor.FormatCSV:     ".csv",
+	processor.FormatOFX:     ".ofx",
+	processor.FormatMT940:   ".mt940",
+	processor.FormatCAMT053: ".xml",
+	processor.FormatXLSX:    ".xlsx",
+}
+
+// csvRequiredColumns mirrors the columns the processor's CSV reader
+// actually requires (see NewStatementReader's csvReader), so an upload
+// that would fail to parse is rejected immediately with a useful error
+// instead of surfacing as a failed batch a minute later.
+var csvRequiredColumns = []string{"transaction_date", "description", "amount"}
+
 func NewUploadHandler(db *sqlx.DB, uploadDir string) *UploadHandler {
 	return &UploadHandler{
-		DB:        db,
-		UploadDir: uploadDir,
-		MaxSize:   50 * 1024 * 1024, // 50MB
+		DB:         db,
+		Dispatcher: jobs.NewFromEnv(db),
+		UploadDir:  uploadDir,
+		MaxSize:    50 * 1024 * 1024, // 50MB
 	}
 }
 
@@ -40,130 +93,186 @@ func (h *UploadHandler) Upload(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "no file provided"})
 	}
 
-	// Validate filename
-	if !strings.HasSuffix(strings.ToLower(file.Filename), ".csv") {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "file must be a CSV"})
-	}
-
 	// Validate file size
 	if file.Size > h.MaxSize {
 		return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{"error": fmt.Sprintf("file exceeds maximum size of %d bytes", h.MaxSize)})
 	}
 
-	// Open uploaded file for header validation
 	src, err := file.Open()
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to open uploaded file"})
 	}
-
-	// Validate CSV header (read first line)
-	reader := csv.NewReader(src)
-	header, err := reader.Read()
-	src.Close() // Close after reading header
+	content, err := io.ReadAll(src)
+	src.Close()
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid CSV: cannot read header"})
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to read uploaded file"})
 	}
 
-	// Validate required columns
-	requiredCols := map[string]bool{
-		"id":                false,
-		"transaction_date":  false,
-		"description":       false,
-		"amount":            false,
-		"reference_number": false,
+	batchID, err := h.ingestContent(c.Request().Context(), file.Filename, file.Header.Get("Content-Type"), content, c.FormValue("schema_hint"))
+	if err != nil {
+		return respondUploadError(c, err)
 	}
-	for _, col := range header {
-		colLower := strings.ToLower(strings.TrimSpace(col))
-		if _, exists := requiredCols[colLower]; exists {
-			requiredCols[colLower] = true
-		}
+
+	return c.JSON(http.StatusCreated, UploadResponse{
+		BatchID: batchID,
+		Status:  "processing",
+	})
+}
+
+// ingestContent takes a complete statement file - however it arrived,
+// single-request multipart or the last chunk of a resumable tus upload -
+// and turns it into a queued reconciliation batch: detect the format,
+// apply an optional CSV schema_hint, validate, write it to UploadDir, and
+// dispatch the ingest job once the batch row is durably committed. Shared
+// by UploadHandler.Upload and TusUploadHandler.finalize so both upload
+// paths are guaranteed to treat a file the same way.
+func (h *UploadHandler) ingestContent(ctx context.Context, filename, contentType string, content []byte, schemaHint string) (string, error) {
+	if len(content) == 0 {
+		return "", badRequestf("file is empty")
 	}
 
-	missingCols := []string{}
-	for col, found := range requiredCols {
-		if !found {
-			missingCols = append(missingCols, col)
+	format := processor.DetectFormat(filename, contentType, content)
+
+	// schema_hint lets a caller remap a nonstandard CSV's columns (e.g. a
+	// bank export with "debit_eur" instead of "amount") onto the names the
+	// processor's CSV reader actually looks for, instead of the upload
+	// being rejected outright. Only meaningful for CSV - every other
+	// format's column names come from its own spec, not a header row.
+	if schemaHint != "" {
+		if format != processor.FormatCSV {
+			return "", badRequestf("schema_hint is only supported for CSV uploads")
 		}
-	}
-	if len(missingCols) > 0 {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": fmt.Sprintf("missing required columns: %s", strings.Join(missingCols, ", ")),
-		})
+		var mapping map[string]string
+		if err := json.Unmarshal([]byte(schemaHint), &mapping); err != nil {
+			return "", badRequestf("invalid schema_hint: must be a JSON object of column_name -> actual_column_name")
+		}
+		remapped, err := applySchemaHint(content, mapping)
+		if err != nil {
+			return "", badRequestf("invalid schema_hint: %v", err)
+		}
+		content = remapped
 	}
 
-	// Reopen file for streaming write
-	src, err = file.Open()
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to reopen file"})
+	if format == processor.FormatCSV {
+		if err := validateCSVHeader(content); err != nil {
+			return "", badRequestf("%s", err.Error())
+		}
 	}
-	defer src.Close()
 
 	// Generate batch ID
 	batchID := uuid.New().String()
 
 	// Ensure upload directory exists
 	if err := os.MkdirAll(h.UploadDir, 0755); err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create upload directory"})
+		return "", internalErrorf("failed to create upload directory")
 	}
 
-	// Stream write file to disk
-	filePath := filepath.Join(h.UploadDir, batchID+".csv")
-	dst, err := os.Create(filePath)
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create file"})
+	ext, ok := storedExtensions[format]
+	if !ok {
+		ext = filepath.Ext(filename)
 	}
-
-	bytesWritten, err := io.Copy(dst, src)
-	if err != nil {
-		dst.Close()
-		os.Remove(filePath)
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to write file"})
+	filePath := filepath.Join(h.UploadDir, batchID+ext)
+	if err := os.WriteFile(filePath, content, 0644); err != nil {
+		return "", internalErrorf("failed to write file")
 	}
-	dst.Close()
 
-	if bytesWritten == 0 {
-		os.Remove(filePath)
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "file is empty"})
-	}
-
-	// Create batch and job in transaction
+	// Create batch
 	tx, err := h.DB.Beginx()
 	if err != nil {
 		os.Remove(filePath)
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to begin transaction"})
+		return "", internalErrorf("failed to begin transaction")
 	}
 
 	// Insert batch
 	_, err = tx.Exec(`
 		INSERT INTO reconciliation_batches (id, filename, status, processed_count, auto_matched_count, needs_review_count, unmatched_count, started_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
-	`, batchID, file.Filename, "processing", 0, 0, 0, 0)
+	`, batchID, filename, "processing", 0, 0, 0, 0)
 	if err != nil {
 		tx.Rollback()
 		os.Remove(filePath)
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create batch"})
-	}
-
-	// Insert job
-	_, err = tx.Exec(`
-		INSERT INTO reconciliation_jobs (batch_id, file_path, status, attempts)
-		VALUES ($1, $2, $3, $4)
-	`, batchID, filePath, "queued", 0)
-	if err != nil {
-		tx.Rollback()
-		os.Remove(filePath)
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create job"})
+		return "", internalErrorf("failed to create batch")
 	}
 
 	// Commit transaction
 	if err := tx.Commit(); err != nil {
 		os.Remove(filePath)
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to commit transaction"})
+		return "", internalErrorf("failed to commit transaction")
 	}
 
-	return c.JSON(http.StatusCreated, UploadResponse{
-		BatchID: batchID,
-		Status:  "processing",
-	})
+	// Dispatch the job after the batch is durably committed, so a worker
+	// (woken immediately by the redis backend's notification, or finding it
+	// on its next poll with the postgres backend) never sees a job whose
+	// batch row isn't there yet.
+	if err := h.Dispatcher.Enqueue(ctx, jobs.Job{BatchID: batchID, FilePath: filePath, Format: format}); err != nil {
+		return "", internalErrorf("failed to create job")
+	}
+
+	return batchID, nil
+}
+
+// validateCSVHeader rejects a CSV upfront when it's missing a column the
+// processor's CSV reader will require anyway, rather than letting it fail
+// a minute later as a batch the uploader has already navigated away from.
+func validateCSVHeader(content []byte) error {
+	r := csv.NewReader(bytes.NewReader(content))
+	header, err := r.Read()
+	if err != nil {
+		return fmt.Errorf("invalid CSV: cannot read header")
+	}
+
+	present := make(map[string]bool, len(header))
+	for _, col := range header {
+		present[strings.ToLower(strings.TrimSpace(col))] = true
+	}
+
+	var missing []string
+	for _, req := range csvRequiredColumns {
+		if !present[req] {
+			missing = append(missing, req)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing required columns: %s", strings.Join(missing, ", "))
+	}
+	return nil
 }
 
+// applySchemaHint renames content's CSV header row in place according to
+// mapping (logical name -> actual column name present in the file),
+// leaving every other row untouched. An actual column name mapping does
+// not exist in the header is an error, not a silent no-op - a typo'd
+// schema_hint should surface immediately rather than leave the upload
+// looking just as rejected as if no hint had been supplied.
+func applySchemaHint(content []byte, mapping map[string]string) ([]byte, error) {
+	r := csv.NewReader(bytes.NewReader(content))
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read CSV: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("file has no header row")
+	}
+
+	header := rows[0]
+	colIdx := make(map[string]int, len(header))
+	for i, col := range header {
+		colIdx[strings.ToLower(strings.TrimSpace(col))] = i
+	}
+
+	for logical, actual := range mapping {
+		idx, exists := colIdx[strings.ToLower(strings.TrimSpace(actual))]
+		if !exists {
+			return nil, fmt.Errorf("column %q not found in file", actual)
+		}
+		header[idx] = logical
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.WriteAll(rows); err != nil {
+		return nil, fmt.Errorf("failed to rewrite header: %w", err)
+	}
+	w.Flush()
+	return buf.Bytes(), nil
+}
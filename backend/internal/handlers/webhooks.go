@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/labstack/echo/v4"
+	"payment-reconciliation-engine/backend/internal/webhooks"
+)
+
+type WebhooksHandler struct {
+	DB *sqlx.DB
+}
+
+func NewWebhooksHandler(db *sqlx.DB) *WebhooksHandler {
+	return &WebhooksHandler{DB: db}
+}
+
+type CreateWebhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+}
+
+type WebhookItem struct {
+	ID        string   `json:"id"`
+	URL       string   `json:"url"`
+	Events    []string `json:"events"`
+	CreatedAt string   `json:"createdAt"`
+}
+
+// CreateWebhook registers url to be POSTed whenever any of events occurs.
+// The generated secret is only ever returned here, at creation time - it's
+// never included in GET /api/webhooks, so losing it means re-creating the
+// webhook.
+func (h *WebhooksHandler) CreateWebhook(c echo.Context) error {
+	var req CreateWebhookRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	if req.URL == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "url is required"})
+	}
+	if len(req.Events) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "events is required"})
+	}
+	for _, e := range req.Events {
+		if !isValidEventType(e) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("unknown event type %q", e)})
+		}
+	}
+
+	secret, err := generateSecret()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to generate webhook secret"})
+	}
+
+	eventsJSON, err := json.Marshal(req.Events)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to encode events"})
+	}
+
+	id := uuid.New().String()
+	_, err = h.DB.Exec(`
+		INSERT INTO webhooks (id, url, secret, event_types, created_at, updated_at)
+		VALUES ($1, $2, $3, $4::jsonb, NOW(), NOW())
+	`, id, req.URL, secret, eventsJSON)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create webhook"})
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"id":     id,
+		"url":    req.URL,
+		"events": req.Events,
+		"secret": secret,
+	})
+}
+
+// ListWebhooks lists every registered webhook, without its secret.
+func (h *WebhooksHandler) ListWebhooks(c echo.Context) error {
+	type row struct {
+		ID         string `db:"id"`
+		URL        string `db:"url"`
+		EventTypes []byte `db:"event_types"`
+		CreatedAt  string `db:"created_at"`
+	}
+	var rows []row
+	err := h.DB.Select(&rows, `SELECT id, url, event_types, created_at FROM webhooks ORDER BY created_at DESC`)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to list webhooks"})
+	}
+
+	items := make([]WebhookItem, 0, len(rows))
+	for _, r := range rows {
+		var events []string
+		if err := json.Unmarshal(r.EventTypes, &events); err != nil {
+			events = []string{}
+		}
+		items = append(items, WebhookItem{ID: r.ID, URL: r.URL, Events: events, CreatedAt: r.CreatedAt})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"items": items})
+}
+
+// DeleteWebhook removes a registered webhook. Its past deliveries stay in
+// webhook_deliveries for audit purposes.
+func (h *WebhooksHandler) DeleteWebhook(c echo.Context) error {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid webhook id"})
+	}
+
+	result, err := h.DB.Exec(`DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to delete webhook"})
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "webhook not found"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "webhook deleted"})
+}
+
+type DeliveryItem struct {
+	ID          string  `json:"id" db:"id"`
+	EventID     string  `json:"eventId" db:"event_id"`
+	EventType   string  `json:"eventType" db:"event_type"`
+	Status      string  `json:"status" db:"status"`
+	Attempts    int     `json:"attempts" db:"attempts"`
+	LastError   *string `json:"lastError" db:"last_error"`
+	NextAttempt *string `json:"nextAttemptAt" db:"next_attempt_at"`
+	CreatedAt   string  `json:"createdAt" db:"created_at"`
+}
+
+// ListDeliveries returns a webhook's most recent delivery attempts, newest
+// first, for debugging a receiver that isn't getting events. An optional
+// ?status= filter (e.g. "failed") narrows this to deliveries that have
+// exhausted their retries and are waiting on ReplayDelivery.
+func (h *WebhooksHandler) ListDeliveries(c echo.Context) error {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid webhook id"})
+	}
+
+	var exists bool
+	if err := h.DB.Get(&exists, `SELECT EXISTS(SELECT 1 FROM webhooks WHERE id = $1)`, id); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to look up webhook"})
+	}
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "webhook not found"})
+	}
+
+	status := c.QueryParam("status")
+
+	var items []DeliveryItem
+	var err error
+	if status != "" {
+		err = h.DB.Select(&items, `
+			SELECT id, event_id, event_type, status, attempts, last_error, next_attempt_at, created_at
+			FROM webhook_deliveries
+			WHERE webhook_id = $1 AND status = $2
+			ORDER BY created_at DESC
+			LIMIT 100
+		`, id, status)
+	} else {
+		err = h.DB.Select(&items, `
+			SELECT id, event_id, event_type, status, attempts, last_error, next_attempt_at, created_at
+			FROM webhook_deliveries
+			WHERE webhook_id = $1
+			ORDER BY created_at DESC
+			LIMIT 100
+		`, id)
+	}
+	if err != nil && err != sql.ErrNoRows {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to list deliveries"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"items": items})
+}
+
+// ReplayDelivery resets a delivery sitting in 'failed' (exhausted its
+// MaxAttempts retries) back to a fresh, immediately-due 'pending' state, the
+// webhook_deliveries equivalent of AdminJobsHandler.RequeueJob - for use
+// after a human has fixed whatever made the receiver reject every attempt.
+func (h *WebhooksHandler) ReplayDelivery(c echo.Context) error {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid delivery id"})
+	}
+
+	result, err := h.DB.Exec(`
+		UPDATE webhook_deliveries
+		SET status = 'pending',
+		    attempts = 0,
+		    next_attempt_at = NOW(),
+		    last_error = NULL,
+		    updated_at = NOW()
+		WHERE id = $1 AND status = 'failed'
+	`, id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to replay delivery"})
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to replay delivery"})
+	}
+	if rowsAffected == 0 {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "no failed delivery with that id"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"status": "pending"})
+}
+
+func isValidEventType(e string) bool {
+	switch e {
+	case webhooks.EventBatchCompleted, webhooks.EventBatchFailed,
+		webhooks.EventTransactionAutoMatched, webhooks.EventTransactionNeedsReview,
+		webhooks.EventTransactionManuallyMatched, webhooks.EventTransactionConfirmed,
+		webhooks.EventTransactionRejected, webhooks.EventTransactionMarkedExternal,
+		webhooks.EventTransactionUndone:
+		return true
+	default:
+		return false
+	}
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+	"payment-reconciliation-engine/backend/internal/cache"
+	"payment-reconciliation-engine/backend/internal/events"
+)
+
+type WSHandler struct {
+	Events *events.Bus
+}
+
+// NewWSHandler builds its own events.Bus the same way BatchHandler and
+// others build their own *cache.Client - Redis-backed if REDIS_URL is set,
+// in-process otherwise. Redis is what lets this handler see progress
+// events published by a processor running in a different pod/replica.
+func NewWSHandler() *WSHandler {
+	redisCache, _ := cache.NewFromEnv()
+	return &WSHandler{Events: events.NewBus(redisCache)}
+}
+
+// wsUpgrader allows any origin, matching main.go's CORS config (the
+// frontend and API aren't assumed to share an origin in this app's
+// deployments) rather than gorilla's default same-origin check.
+var wsUpgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+const (
+	wsHeartbeatInterval = 15 * time.Second
+	wsWriteTimeout      = 5 * time.Second
+)
+
+// StreamReconciliation upgrades to a WebSocket and pushes versioned batch
+// progress events (events.Event, "v":1) as the reconciliation job for
+// batchId runs, ending with a "complete" or "error" stage event. A client
+// reconnecting after a drop can pass ?lastSeq=<n> to replay whatever
+// events it missed since, before live events resume - see
+// events.Bus.ReplayFrom (Redis-backed deployments only; in-process mode
+// just resumes from the next live event).
+func (h *WSHandler) StreamReconciliation(c echo.Context) error {
+	batchID := c.Param("batchId")
+	if len(batchID) != 36 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid batch id format"})
+	}
+
+	var lastSeq uint64
+	if raw := c.QueryParam("lastSeq"); raw != "" {
+		parsed, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid lastSeq"})
+		}
+		lastSeq = parsed
+	}
+
+	conn, err := wsUpgrader.Upgrade(c.Response(), c.Request(), nil)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ctx := c.Request().Context()
+
+	if lastSeq > 0 {
+		replay, err := h.Events.ReplayFrom(ctx, batchID, lastSeq)
+		if err != nil {
+			log.Printf("Warning: Failed to replay batch events for %s: %v", batchID, err)
+		}
+		for _, e := range replay {
+			if err := conn.WriteJSON(e); err != nil {
+				return nil
+			}
+		}
+	}
+
+	evCh, cancel := h.Events.Subscribe(ctx, batchID)
+	defer cancel()
+
+	// The client only ever sends us control frames (pong replies, close) -
+	// read in a goroutine purely to drive gorilla's control-frame handling
+	// and notice an abrupt disconnect, since nothing here otherwise reads
+	// from the connection.
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	heartbeat := time.NewTicker(wsHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-closed:
+			return nil
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				return nil
+			}
+		case e, ok := <-evCh:
+			if !ok {
+				return nil
+			}
+			if err := conn.WriteJSON(e); err != nil {
+				return nil
+			}
+			if e.Stage == events.StageComplete || e.Stage == events.StageError {
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,95 @@
+// Package idempotency lets a mutating endpoint treat a client's retried
+// request as a no-op replay instead of repeating its side effects,
+// keyed off the caller-supplied Idempotency-Key header.
+package idempotency
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// TTL is how long a cached response stays replayable before a repeated
+// key is treated as a brand new request - long enough to cover a
+// client's retry storm after a dropped connection, short enough that a
+// key doesn't have to stay unique forever.
+const TTL = 24 * time.Hour
+
+// ErrConflict means key was already recorded for this user with a
+// different request body - the caller is reusing a key across two
+// logically different requests, which is refused rather than replayed.
+var ErrConflict = errors.New("idempotency key reused with a different request body")
+
+// Cached is a previously stored response to replay verbatim instead of
+// re-running the handler's mutation.
+type Cached struct {
+	Status int
+	Body   []byte
+}
+
+// HashBody hashes a request body so two calls under the same
+// Idempotency-Key can be compared for exact equality.
+func HashBody(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// Lookup checks whether key/userID has already been recorded inside tx -
+// it must run in the same transaction the caller is about to use for its
+// mutation, so a crash between the check and the mutation can't leave the
+// two disagreeing. A nil, nil result means this is a fresh key: proceed
+// with the mutation and call Store before committing. A non-nil Cached
+// means the caller should replay it unchanged. ErrConflict means the
+// same key arrived with a different body than the one it was first used
+// with.
+func Lookup(tx *sqlx.Tx, key, userID, requestHash string) (*Cached, error) {
+	var row struct {
+		RequestHash    string `db:"request_hash"`
+		ResponseStatus int    `db:"response_status"`
+		ResponseBody   []byte `db:"response_body"`
+	}
+	err := tx.Get(&row, `
+		SELECT request_hash, response_status, response_body
+		FROM idempotency_keys
+		WHERE key = $1 AND user_id = $2 AND expires_at > NOW()
+	`, key, userID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up idempotency key: %w", err)
+	}
+	if row.RequestHash != requestHash {
+		return nil, ErrConflict
+	}
+	return &Cached{Status: row.ResponseStatus, Body: row.ResponseBody}, nil
+}
+
+// Store records key/userID's outcome inside the same tx as the mutation
+// it guards - call it right before tx.Commit() so either both the
+// mutation and this row land, or neither does, and a retry is never left
+// with a committed mutation but nothing to replay from. ON CONFLICT
+// overwrites a prior row for this key/user - reachable only once that
+// row has already expired, since Lookup returns ErrConflict for any
+// still-live mismatch before Store is ever called.
+func Store(tx *sqlx.Tx, key, userID, requestHash string, status int, body []byte) error {
+	_, err := tx.Exec(`
+		INSERT INTO idempotency_keys (key, user_id, request_hash, response_status, response_body, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, NOW(), NOW() + $6::interval)
+		ON CONFLICT (key, user_id) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash,
+		    response_status = EXCLUDED.response_status,
+		    response_body = EXCLUDED.response_body,
+		    created_at = EXCLUDED.created_at,
+		    expires_at = EXCLUDED.expires_at
+	`, key, userID, requestHash, status, body, fmt.Sprintf("%d seconds", int(TTL.Seconds())))
+	if err != nil {
+		return fmt.Errorf("failed to store idempotency key: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+// Package jobs decouples "a reconciliation job exists and should be
+// worked" from how a worker finds out about it. The postgres backend is
+// the original poll-the-table behavior; the redis backend additionally
+// PUBLISHes a notification so a subscribed worker reacts immediately
+// instead of waiting out its poll interval. See NewFromEnv.
+package jobs
+
+import "context"
+
+// Job is what a caller hands a Dispatcher to enqueue - currently always a
+// csv.ingest job, so it carries exactly what worker.CSVIngestPayload needs.
+// FilePath-less job types (rematch, export, ...) still go through
+// worker.EnqueueTypedJob directly; Dispatcher only fronts the upload path.
+type Job struct {
+	BatchID  string
+	FilePath string
+	// Format is the statement format UploadHandler detected (see
+	// processor.DetectFormat), or "" to have the worker sniff it from the
+	// file's own bytes instead.
+	Format string
+}
+
+// Dispatcher makes a queued job durable and visible to whatever's waiting
+// to claim it. Enqueue must insert the reconciliation_jobs row itself -
+// the postgres backend stops there, the redis backend also publishes a
+// notification afterward.
+type Dispatcher interface {
+	Enqueue(ctx context.Context, job Job) error
+}
@@ -0,0 +1,24 @@
+package jobs
+
+import (
+	"log"
+	"os"
+
+	"github.com/jmoiron/sqlx"
+	"payment-reconciliation-engine/backend/internal/cache"
+)
+
+// NewFromEnv builds the Dispatcher JOB_BACKEND selects: "redis" for the
+// pub/sub backend, falling back to the postgres one if REDIS_URL isn't
+// also set (a pub/sub notification is pointless without a Redis
+// connection to publish it on). Anything else, including unset, gets the
+// original DB-polled behavior.
+func NewFromEnv(db *sqlx.DB) Dispatcher {
+	if os.Getenv("JOB_BACKEND") == "redis" {
+		if redisCache, ok := cache.NewFromEnv(); ok {
+			return NewRedisDispatcher(db, redisCache)
+		}
+		log.Println("Warning: JOB_BACKEND=redis but REDIS_URL is not set; falling back to postgres job dispatcher")
+	}
+	return NewPostgresDispatcher(db)
+}
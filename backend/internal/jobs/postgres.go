@@ -0,0 +1,25 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"payment-reconciliation-engine/backend/internal/worker"
+)
+
+// PostgresDispatcher is the original polling backend: Enqueue inserts a
+// queued reconciliation_jobs row and does nothing else - every worker's
+// own claimJob loop picks it up on its next poll.
+type PostgresDispatcher struct {
+	DB *sqlx.DB
+}
+
+func NewPostgresDispatcher(db *sqlx.DB) *PostgresDispatcher {
+	return &PostgresDispatcher{DB: db}
+}
+
+func (d *PostgresDispatcher) Enqueue(ctx context.Context, job Job) error {
+	payload := worker.CSVIngestPayload{FilePath: job.FilePath, Format: job.Format}
+	return worker.EnqueueTypedJob(d.DB, job.BatchID, payload, worker.PriorityInteractive, time.Now(), worker.JSONCodec)
+}
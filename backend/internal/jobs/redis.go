@@ -0,0 +1,32 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"payment-reconciliation-engine/backend/internal/cache"
+	"payment-reconciliation-engine/backend/internal/worker"
+)
+
+// RedisDispatcher still inserts the same reconciliation_jobs row the
+// postgres backend does - a worker that's still on the postgres backend
+// mid-rollout, or one whose notification got dropped, must still find the
+// job by polling - but also PUBLISHes a job_enqueued:<batchId> message so
+// a PSUBSCRIBEd worker wakes immediately instead.
+type RedisDispatcher struct {
+	DB    *sqlx.DB
+	Cache *cache.Client
+}
+
+func NewRedisDispatcher(db *sqlx.DB, redisCache *cache.Client) *RedisDispatcher {
+	return &RedisDispatcher{DB: db, Cache: redisCache}
+}
+
+func (d *RedisDispatcher) Enqueue(ctx context.Context, job Job) error {
+	payload := worker.CSVIngestPayload{FilePath: job.FilePath, Format: job.Format}
+	if err := worker.EnqueueTypedJob(d.DB, job.BatchID, payload, worker.PriorityInteractive, time.Now(), worker.JSONCodec); err != nil {
+		return err
+	}
+	return d.Cache.PublishJobEnqueued(ctx, job.BatchID)
+}
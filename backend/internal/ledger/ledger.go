@@ -0,0 +1,249 @@
+// Package ledger is a lightweight double-entry bookkeeping layer underneath
+// reconciliation: every dollar a bank transaction's lifecycle moves
+// (confirmed, manually matched, marked external) posts here as a balanced
+// set of debit/credit entries against ledger_accounts, so a batch's books
+// can be proven to balance independently of reconciliation_batches' running
+// counters. See ledger_accounts, ledger_postings, and ledger_entries.
+package ledger
+
+import (
+	"database/sql"
+	"fmt"
+	"math/big"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"payment-reconciliation-engine/backend/internal/processor"
+)
+
+// Well-known account codes. Post creates these lazily on first use via
+// ensureAccount - this repo has no seed migration to pre-populate
+// ledger_accounts with them.
+const (
+	AccountBank               = "bank"
+	AccountAccountsReceivable = "accounts_receivable"
+	AccountSuspense           = "suspense"
+)
+
+// Entry directions. A posting's entries must net to zero per currency -
+// see validateBalanced.
+const (
+	DirectionDebit  = "debit"
+	DirectionCredit = "credit"
+)
+
+var accountMeta = map[string]struct {
+	name        string
+	accountType string
+}{
+	AccountBank:               {"Bank", "asset"},
+	AccountAccountsReceivable: {"Accounts Receivable", "asset"},
+	AccountSuspense:           {"Suspense", "liability"},
+}
+
+// Entry is one leg of a posting - accountCode debited or credited by amount,
+// denominated in currency.
+type Entry struct {
+	AccountCode string
+	Direction   string
+	Amount      string
+	Currency    string
+}
+
+// Post writes description and entries as one posting inside tx, the
+// caller's own transaction, so the posting commits or rolls back atomically
+// with whatever mutation it documents. transactionID, invoiceID, and
+// batchID may be nil - pass sql.NullString-compatible values the same way
+// webhooks.WriteEvent's callers do. entries must balance per currency or
+// Post returns an error without writing anything.
+func Post(tx *sqlx.Tx, description string, transactionID, invoiceID, batchID interface{}, entries []Entry) (string, error) {
+	if err := validateBalanced(entries); err != nil {
+		return "", err
+	}
+
+	postingID := uuid.New().String()
+	_, err := tx.Exec(`
+		INSERT INTO ledger_postings (id, transaction_id, invoice_id, batch_id, description, created_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+	`, postingID, transactionID, invoiceID, batchID, description)
+	if err != nil {
+		return "", fmt.Errorf("failed to create posting: %w", err)
+	}
+
+	for _, e := range entries {
+		accountID, err := ensureAccount(tx, e.AccountCode)
+		if err != nil {
+			return "", err
+		}
+		_, err = tx.Exec(`
+			INSERT INTO ledger_entries (id, posting_id, account_id, direction, amount, currency, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		`, uuid.New().String(), postingID, accountID, e.Direction, e.Amount, e.Currency)
+		if err != nil {
+			return "", fmt.Errorf("failed to create ledger entry: %w", err)
+		}
+	}
+
+	return postingID, nil
+}
+
+// Reverse posts a mirror-image posting of postingID - every debit becomes a
+// credit and vice versa, same accounts and amounts - against the same
+// transaction/invoice/batch, and marks postingID reversed, so RejectMatch
+// can undo a posting ConfirmMatch or ManualMatch already made without
+// deleting the audit trail. Reversing an already-reversed posting is a
+// no-op and returns "", nil.
+func Reverse(tx *sqlx.Tx, postingID, reason string) (string, error) {
+	var original struct {
+		TransactionID sql.NullString `db:"transaction_id"`
+		InvoiceID     sql.NullString `db:"invoice_id"`
+		BatchID       sql.NullString `db:"batch_id"`
+		ReversedAt    sql.NullTime   `db:"reversed_at"`
+	}
+	err := tx.Get(&original, `
+		SELECT transaction_id, invoice_id, batch_id, reversed_at
+		FROM ledger_postings
+		WHERE id = $1
+		FOR UPDATE
+	`, postingID)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("posting %s not found", postingID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch posting: %w", err)
+	}
+	if original.ReversedAt.Valid {
+		return "", nil
+	}
+
+	var entries []struct {
+		AccountID string `db:"account_id"`
+		Direction string `db:"direction"`
+		Amount    string `db:"amount"`
+		Currency  string `db:"currency"`
+	}
+	if err := tx.Select(&entries, `SELECT account_id, direction, amount, currency FROM ledger_entries WHERE posting_id = $1`, postingID); err != nil {
+		return "", fmt.Errorf("failed to fetch posting entries: %w", err)
+	}
+
+	reversalID := uuid.New().String()
+	_, err = tx.Exec(`
+		INSERT INTO ledger_postings (id, transaction_id, invoice_id, batch_id, description, created_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+	`, reversalID, original.TransactionID, original.InvoiceID, original.BatchID, reason)
+	if err != nil {
+		return "", fmt.Errorf("failed to create reversal posting: %w", err)
+	}
+
+	for _, e := range entries {
+		direction := DirectionCredit
+		if e.Direction == DirectionCredit {
+			direction = DirectionDebit
+		}
+		_, err = tx.Exec(`
+			INSERT INTO ledger_entries (id, posting_id, account_id, direction, amount, currency, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		`, uuid.New().String(), reversalID, e.AccountID, direction, e.Amount, e.Currency)
+		if err != nil {
+			return "", fmt.Errorf("failed to create reversal entry: %w", err)
+		}
+	}
+
+	if _, err := tx.Exec(`UPDATE ledger_postings SET reversed_at = NOW() WHERE id = $1`, postingID); err != nil {
+		return "", fmt.Errorf("failed to mark posting reversed: %w", err)
+	}
+
+	return reversalID, nil
+}
+
+// OpenPostingForTransaction returns the id of transactionID's most recent
+// not-yet-reversed posting, or "", nil if it has none. RejectMatch uses this
+// to find what Reverse should undo.
+func OpenPostingForTransaction(tx *sqlx.Tx, transactionID string) (string, error) {
+	var id string
+	err := tx.Get(&id, `
+		SELECT id FROM ledger_postings
+		WHERE transaction_id = $1 AND reversed_at IS NULL
+		ORDER BY created_at DESC
+		LIMIT 1
+	`, transactionID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to look up open posting: %w", err)
+	}
+	return id, nil
+}
+
+// validateBalanced checks that entries' debits equal its credits, currency
+// by currency, using processor.ParseMoney's exact-rational parsing so a
+// rounding artifact can never pass as balanced.
+func validateBalanced(entries []Entry) error {
+	if len(entries) == 0 {
+		return fmt.Errorf("a posting needs at least one entry")
+	}
+
+	totals := map[string]*big.Rat{}
+	for _, e := range entries {
+		if e.Direction != DirectionDebit && e.Direction != DirectionCredit {
+			return fmt.Errorf("invalid entry direction %q", e.Direction)
+		}
+		precision := (processor.CurrencyRegistry{}).PrecisionFor(e.Currency)
+		amt, err := processor.ParseMoney(e.Amount, precision)
+		if err != nil {
+			return fmt.Errorf("entry amount: %w", err)
+		}
+		total, ok := totals[e.Currency]
+		if !ok {
+			total = new(big.Rat)
+			totals[e.Currency] = total
+		}
+		if e.Direction == DirectionDebit {
+			total.Add(total, amt)
+		} else {
+			total.Sub(total, amt)
+		}
+	}
+
+	for currency, total := range totals {
+		if total.Sign() != 0 {
+			return fmt.Errorf("posting does not balance for currency %s: debits and credits differ by %s", currency, total.RatString())
+		}
+	}
+	return nil
+}
+
+// ensureAccount returns accountCode's ledger_accounts id, creating the
+// account from accountMeta on first use.
+func ensureAccount(tx *sqlx.Tx, code string) (string, error) {
+	var id string
+	err := tx.Get(&id, `SELECT id FROM ledger_accounts WHERE code = $1`, code)
+	if err == nil {
+		return id, nil
+	}
+	if err != sql.ErrNoRows {
+		return "", fmt.Errorf("failed to look up ledger account %q: %w", code, err)
+	}
+
+	meta, ok := accountMeta[code]
+	if !ok {
+		return "", fmt.Errorf("unknown ledger account code %q", code)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO ledger_accounts (id, code, name, type, created_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (code) DO NOTHING
+	`, uuid.New().String(), code, meta.name, meta.accountType)
+	if err != nil {
+		return "", fmt.Errorf("failed to create ledger account %q: %w", code, err)
+	}
+
+	// Someone else may have created it between the SELECT and this INSERT
+	// under concurrent first-use; re-select to get the winning row's id.
+	if err := tx.Get(&id, `SELECT id FROM ledger_accounts WHERE code = $1`, code); err != nil {
+		return "", fmt.Errorf("failed to look up ledger account %q after insert: %w", code, err)
+	}
+	return id, nil
+}
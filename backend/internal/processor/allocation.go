@@ -0,0 +1,146 @@
+package processor
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// InvoiceLineItem is one billable line on an invoice, each potentially
+// carrying its own VAT rate and cost centre - unlike the invoice-level
+// VATRateBps/Amount on InvoiceCandidate, which assume one flat rate across
+// the whole invoice. Most invoices in this repo's seed data have none, in
+// which case AllocateProportionally has nothing to split.
+type InvoiceLineItem struct {
+	ID           string `db:"id"`
+	InvoiceID    string `db:"invoice_id"`
+	CostCentreID string `db:"cost_centre_id"`
+	NetAmount    string `db:"net_amount"`
+	VATAmount    string `db:"vat_amount"`
+	VATRateBps   int    `db:"vat_rate_bps"`
+}
+
+// LoadLineItems fetches invoiceID's line items, ordered by id for
+// deterministic allocation. An invoice with no invoice_line_items rows
+// returns an empty slice, not an error.
+func LoadLineItems(db *sqlx.DB, invoiceID string) ([]InvoiceLineItem, error) {
+	var items []InvoiceLineItem
+	err := db.Select(&items, `
+		SELECT id::text, invoice_id::text, cost_centre_id, net_amount::text, vat_amount::text, vat_rate_bps
+		FROM invoice_line_items
+		WHERE invoice_id = $1
+		ORDER BY id
+	`, invoiceID)
+	return items, err
+}
+
+// Allocation is one line item's share of a matched transaction amount.
+type Allocation struct {
+	LineItemID   string
+	CostCentreID string
+	AllocatedNet string
+	AllocatedVAT string
+}
+
+// AllocateProportionally splits matchedAmount across items in proportion to
+// each item's share of the invoice's total gross (net+vat) amount, so a
+// partial payment (see AmountToleranceConfig) spreads across cost centres
+// the same way the invoice itself does, rather than crediting one item in
+// full before touching the next. The last item absorbs the rounding
+// remainder, so the allocations always sum to exactly matchedAmount.
+func AllocateProportionally(matchedAmount string, items []InvoiceLineItem, precision int) ([]Allocation, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	matched, err := ParseMoney(matchedAmount, precision)
+	if err != nil {
+		return nil, fmt.Errorf("invalid matched amount %q: %w", matchedAmount, err)
+	}
+
+	type parsedItem struct {
+		item InvoiceLineItem
+		net  *big.Rat
+		vat  *big.Rat
+	}
+	parsed := make([]parsedItem, 0, len(items))
+	total := new(big.Rat)
+	for _, item := range items {
+		net, err := ParseMoney(item.NetAmount, precision)
+		if err != nil {
+			return nil, fmt.Errorf("invalid line item net amount %q: %w", item.NetAmount, err)
+		}
+		vat, err := ParseMoney(item.VATAmount, precision)
+		if err != nil {
+			return nil, fmt.Errorf("invalid line item vat amount %q: %w", item.VATAmount, err)
+		}
+		gross := new(big.Rat).Add(net, vat)
+		parsed = append(parsed, parsedItem{item: item, net: net, vat: vat})
+		total.Add(total, gross)
+	}
+
+	if total.Sign() == 0 {
+		return nil, fmt.Errorf("invoice line items for invoice %s sum to zero, can't allocate proportionally", items[0].InvoiceID)
+	}
+
+	allocations := make([]Allocation, len(parsed))
+	allocatedSoFar := new(big.Rat)
+	for i, p := range parsed {
+		gross := new(big.Rat).Add(p.net, p.vat)
+
+		var share *big.Rat
+		if i == len(parsed)-1 {
+			share = new(big.Rat).Sub(matched, allocatedSoFar)
+		} else {
+			ratio := new(big.Rat).Quo(gross, total)
+			shareStr := new(big.Rat).Mul(matched, ratio).FloatString(precision)
+			share, _ = new(big.Rat).SetString(shareStr)
+			allocatedSoFar.Add(allocatedSoFar, share)
+		}
+
+		netShare := new(big.Rat)
+		if gross.Sign() != 0 {
+			netRatio := new(big.Rat).Quo(p.net, gross)
+			netShareStr := new(big.Rat).Mul(share, netRatio).FloatString(precision)
+			netShare, _ = new(big.Rat).SetString(netShareStr)
+		}
+		vatShare := new(big.Rat).Sub(share, netShare)
+
+		allocations[i] = Allocation{
+			LineItemID:   p.item.ID,
+			CostCentreID: p.item.CostCentreID,
+			AllocatedNet: netShare.FloatString(precision),
+			AllocatedVAT: vatShare.FloatString(precision),
+		}
+	}
+
+	return allocations, nil
+}
+
+// PersistAllocations writes allocations for (invoiceID, transactionID) into
+// match_allocation, so GET /reports/reconciliation-summary can aggregate
+// matched amounts per (cost centre, vat rate) without recomputing the split
+// at report time.
+func PersistAllocations(db *sqlx.DB, invoiceID, transactionID string, allocations []Allocation) error {
+	if len(allocations) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, 0, len(allocations))
+	args := make([]interface{}, 0, len(allocations)*5)
+	for _, a := range allocations {
+		n := len(args)
+		placeholders = append(placeholders, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5))
+		args = append(args, invoiceID, a.LineItemID, transactionID, a.AllocatedNet, a.AllocatedVAT)
+	}
+
+	query := `
+		INSERT INTO match_allocation (
+			invoice_id, line_item_id, transaction_id, allocated_net, allocated_vat
+		) VALUES ` + strings.Join(placeholders, ", ")
+
+	_, err := db.Exec(query, args...)
+	return err
+}
@@ -0,0 +1,64 @@
+package processor
+
+import "testing"
+
+func TestAllocateProportionally_NoLineItems(t *testing.T) {
+	allocations, err := AllocateProportionally("100.00", nil, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if allocations != nil {
+		t.Errorf("expected nil allocations for no line items, got %v", allocations)
+	}
+}
+
+func TestAllocateProportionally_SplitsProportionallyAndSumsExactly(t *testing.T) {
+	items := []InvoiceLineItem{
+		{ID: "li-1", CostCentreID: "cc-eng", NetAmount: "300.00", VATAmount: "60.00", VATRateBps: 2000},
+		{ID: "li-2", CostCentreID: "cc-sales", NetAmount: "100.00", VATAmount: "20.00", VATRateBps: 2000},
+	}
+
+	allocations, err := AllocateProportionally("288.00", items, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(allocations) != 2 {
+		t.Fatalf("expected 2 allocations, got %d", len(allocations))
+	}
+
+	// li-1 is 3/4 of the 480 total gross, so it should get 3/4 of 288 = 216.
+	if allocations[0].CostCentreID != "cc-eng" {
+		t.Errorf("expected first allocation for cc-eng, got %s", allocations[0].CostCentreID)
+	}
+
+	total, err := ParseMoney("0", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range allocations {
+		net, err := ParseMoney(a.AllocatedNet, 2)
+		if err != nil {
+			t.Fatalf("invalid allocated net %q: %v", a.AllocatedNet, err)
+		}
+		vat, err := ParseMoney(a.AllocatedVAT, 2)
+		if err != nil {
+			t.Fatalf("invalid allocated vat %q: %v", a.AllocatedVAT, err)
+		}
+		total.Add(total, net)
+		total.Add(total, vat)
+	}
+
+	expected, _ := ParseMoney("288.00", 2)
+	if total.Cmp(expected) != 0 {
+		t.Errorf("expected allocations to sum to exactly 288.00, got %v", total.FloatString(2))
+	}
+}
+
+func TestAllocateProportionally_RejectsZeroTotalLineItems(t *testing.T) {
+	items := []InvoiceLineItem{
+		{ID: "li-1", InvoiceID: "inv-1", NetAmount: "0.00", VATAmount: "0.00"},
+	}
+	if _, err := AllocateProportionally("100.00", items, 2); err == nil {
+		t.Error("expected an error when line items sum to zero")
+	}
+}
@@ -0,0 +1,194 @@
+package processor
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// BankTransaction is the minimal shape BatchReconcile needs to run
+// MatchTransaction across many transactions and aggregate the result. It
+// intentionally doesn't carry storage-specific fields (upload batch ID,
+// reference number) that TransactionRow does - callers that already have a
+// TransactionRow or an ofx.BankTransaction can convert to this with a
+// one-line literal.
+type BankTransaction struct {
+	Description     string
+	Amount          string
+	TransactionDate time.Time
+}
+
+// ReconcileErrorKind identifies why BatchReconcile couldn't confidently
+// match a single transaction.
+type ReconcileErrorKind string
+
+const (
+	// ErrNoCandidates means no invoices were supplied to reconcile against at all.
+	ErrNoCandidates ReconcileErrorKind = "no_candidates"
+	// ErrAmountMismatch means none of the supplied invoices share this
+	// transaction's exact amount (net or gross). classifyFailure also
+	// reports it when some did but the best of them scored too low to match
+	// and the failure was neither a weak name nor an ambiguous tie.
+	ErrAmountMismatch ReconcileErrorKind = "amount_mismatch"
+	// ErrNameUnextractable means the description didn't yield a usable
+	// payer name, so name similarity couldn't meaningfully contribute.
+	ErrNameUnextractable ReconcileErrorKind = "name_unextractable"
+	// ErrAmbiguousTie means two or more candidates scored within a hair of
+	// each other, so the top candidate isn't a confident, unique pick.
+	ErrAmbiguousTie ReconcileErrorKind = "ambiguous_tie"
+)
+
+// ReconcileError describes why BatchReconcile couldn't confidently match one
+// transaction. It implements error so a ReconcileReport's Errors slice is
+// usable as ordinary errors; callers that want to branch on the failure mode
+// should switch on Kind.
+type ReconcileError struct {
+	Index       int
+	Description string
+	Kind        ReconcileErrorKind
+	Detail      string
+}
+
+func (e *ReconcileError) Error() string {
+	return fmt.Sprintf("transaction %d (%q): %s: %s", e.Index, e.Description, e.Kind, e.Detail)
+}
+
+// ReconcileReport aggregates the outcome of a BatchReconcile run: every
+// individual MatchResult, the per-transaction failures that kept a result
+// from being a confident auto-match, and summary statistics a caller can use
+// without re-scanning Results itself.
+type ReconcileReport struct {
+	Results []MatchResult
+	Errors  []*ReconcileError
+
+	// StatusCounts maps "auto_matched"/"needs_review"/"unmatched" to how
+	// many transactions landed there.
+	StatusCounts map[string]int
+
+	// AmbiguityHistogram maps "how many amount-matching candidates this
+	// transaction had" to "how many transactions had that many", so a
+	// caller can see at a glance whether unmatched/needs_review results
+	// cluster around highly ambiguous amounts.
+	AmbiguityHistogram map[int]int
+
+	// InvoiceUsage maps invoice ID to how many transactions matched it
+	// (auto_matched or needs_review). A value >1 means two transactions
+	// both claimed the same invoice - MatchTransaction only prevents this
+	// within a single caller's dedup loop (see Processor.MatchedInvoices),
+	// so a caller driving BatchReconcile directly should check this map
+	// itself before acting on the results.
+	InvoiceUsage map[string]int
+}
+
+// BatchReconcile matches every transaction in txns against candidates,
+// accumulating a typed ReconcileError for each one that didn't cleanly
+// auto-match. It always returns a complete ReconcileReport; the error return
+// is non-nil only when len(report.Errors) > 0, so a caller that only cares
+// about "did everything reconcile cleanly" can check the error alone while
+// one that wants the detail can inspect report.Errors.
+func BatchReconcile(txns []BankTransaction, candidates []*InvoiceCandidate, opts ...MatchOptions) (*ReconcileReport, error) {
+	report := &ReconcileReport{
+		Results:            make([]MatchResult, 0, len(txns)),
+		StatusCounts:       make(map[string]int, 3),
+		AmbiguityHistogram: make(map[int]int),
+		InvoiceUsage:       make(map[string]int),
+	}
+
+	for i, txn := range txns {
+		filtered := make([]*InvoiceCandidate, 0, len(candidates))
+		for _, c := range candidates {
+			if c.Amount == txn.Amount || c.NetAmount == txn.Amount || c.GrossAmount == txn.Amount {
+				filtered = append(filtered, c)
+			}
+		}
+
+		match := MatchTransaction(txn.Description, txn.Amount, txn.TransactionDate, filtered, opts...)
+		report.Results = append(report.Results, match)
+		report.StatusCounts[match.Status]++
+		report.AmbiguityHistogram[len(filtered)]++
+
+		if match.InvoiceID != nil && (match.Status == "auto_matched" || match.Status == "needs_review") {
+			report.InvoiceUsage[*match.InvoiceID]++
+		}
+
+		if reconErr := classifyFailure(i, txn, len(candidates) == 0, filtered, match); reconErr != nil {
+			report.Errors = append(report.Errors, reconErr)
+		}
+	}
+
+	if len(report.Errors) > 0 {
+		return report, fmt.Errorf("%d of %d transactions failed to confidently reconcile", len(report.Errors), len(txns))
+	}
+	return report, nil
+}
+
+// classifyFailure decides why (if at all) a single MatchResult counts as a
+// BatchReconcile failure. auto_matched never fails; needs_review only fails
+// when it's an ambiguous tie rather than a merely-medium-confidence match;
+// unmatched always fails, bucketed by the most likely cause.
+func classifyFailure(index int, txn BankTransaction, poolEmpty bool, filtered []*InvoiceCandidate, match MatchResult) *ReconcileError {
+	switch match.Status {
+	case "auto_matched":
+		return nil
+	case "needs_review":
+		if isAmbiguousTie(match) {
+			return &ReconcileError{
+				Index:       index,
+				Description: txn.Description,
+				Kind:        ErrAmbiguousTie,
+				Detail:      fmt.Sprintf("%d candidates scored within a hair of each other", len(filtered)),
+			}
+		}
+		return nil
+	}
+
+	// unmatched
+	switch {
+	case poolEmpty:
+		return &ReconcileError{
+			Index:       index,
+			Description: txn.Description,
+			Kind:        ErrNoCandidates,
+			Detail:      "no invoices were supplied to reconcile against",
+		}
+	case len(filtered) == 0:
+		return &ReconcileError{
+			Index:       index,
+			Description: txn.Description,
+			Kind:        ErrAmountMismatch,
+			Detail:      "no invoice shares this transaction's amount",
+		}
+	case len(extractNameFromDescription(txn.Description)) < 3:
+		return &ReconcileError{
+			Index:       index,
+			Description: txn.Description,
+			Kind:        ErrNameUnextractable,
+			Detail:      "description didn't yield a usable payer name",
+		}
+	default:
+		return &ReconcileError{
+			Index:       index,
+			Description: txn.Description,
+			Kind:        ErrAmountMismatch,
+			Detail:      fmt.Sprintf("best candidate scored only %.2f", match.Confidence),
+		}
+	}
+}
+
+// isAmbiguousTie reports whether the top two entries in a MatchResult's
+// topCandidates detail scored within a rounding error of each other,
+// meaning the "best" candidate isn't a confident, unique pick.
+func isAmbiguousTie(match MatchResult) bool {
+	top, ok := match.MatchDetails["topCandidates"].([]interface{})
+	if !ok || len(top) < 2 {
+		return false
+	}
+	first, ok1 := top[0].(map[string]interface{})
+	second, ok2 := top[1].(map[string]interface{})
+	if !ok1 || !ok2 {
+		return false
+	}
+	s1, _ := first["score"].(float64)
+	s2, _ := second["score"].(float64)
+	return math.Abs(s1-s2) < 0.01
+}
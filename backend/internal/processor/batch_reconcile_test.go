@@ -0,0 +1,80 @@
+package processor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBatchReconcile_NoCandidatesErrorsEveryTransaction(t *testing.T) {
+	txns := []BankTransaction{
+		{Description: "SMITH JOHN", Amount: "450.00", TransactionDate: time.Now()},
+	}
+
+	report, err := BatchReconcile(txns, nil)
+	if err == nil {
+		t.Fatal("expected an error when no candidates are supplied")
+	}
+	if len(report.Errors) != 1 || report.Errors[0].Kind != ErrNoCandidates {
+		t.Fatalf("expected a single ErrNoCandidates failure, got %+v", report.Errors)
+	}
+	if report.StatusCounts["unmatched"] != 1 {
+		t.Errorf("expected 1 unmatched status, got %v", report.StatusCounts)
+	}
+}
+
+func TestBatchReconcile_AmountMismatch(t *testing.T) {
+	candidates := []*InvoiceCandidate{
+		{ID: "inv-1", InvoiceNumber: "INV-001", Amount: "100.00", DueDate: time.Now(), CustomerName: "John Smith", NormalizedName: "JOHN SMITH", Status: "sent"},
+	}
+	txns := []BankTransaction{
+		{Description: "SMITH JOHN", Amount: "450.00", TransactionDate: time.Now()},
+	}
+
+	report, err := BatchReconcile(txns, candidates)
+	if err == nil {
+		t.Fatal("expected an error when no candidate shares the transaction amount")
+	}
+	if len(report.Errors) != 1 || report.Errors[0].Kind != ErrAmountMismatch {
+		t.Fatalf("expected a single ErrAmountMismatch failure, got %+v", report.Errors)
+	}
+	if report.AmbiguityHistogram[0] != 1 {
+		t.Errorf("expected the zero-candidate bucket to count this transaction, got %v", report.AmbiguityHistogram)
+	}
+}
+
+func TestBatchReconcile_CleanMatchHasNoErrors(t *testing.T) {
+	dueDate := time.Date(2024, 12, 10, 0, 0, 0, 0, time.UTC)
+	candidates := []*InvoiceCandidate{
+		{ID: "inv-1", InvoiceNumber: "INV-001", Amount: "450.00", DueDate: dueDate, CustomerName: "John Smith", NormalizedName: "JOHN SMITH", Status: "sent"},
+	}
+	txns := []BankTransaction{
+		{Description: "JOHN SMITH PAYMENT", Amount: "450.00", TransactionDate: dueDate},
+	}
+
+	report, err := BatchReconcile(txns, candidates)
+	if err != nil {
+		t.Fatalf("expected no error for a clean match, got %v", err)
+	}
+	if report.StatusCounts["auto_matched"] != 1 {
+		t.Errorf("expected 1 auto_matched status, got %v", report.StatusCounts)
+	}
+	if report.InvoiceUsage["inv-1"] != 1 {
+		t.Errorf("expected invoice usage of 1, got %v", report.InvoiceUsage)
+	}
+}
+
+func TestBatchReconcile_DoubleClaimedInvoiceIsVisibleInUsage(t *testing.T) {
+	dueDate := time.Date(2024, 12, 10, 0, 0, 0, 0, time.UTC)
+	candidates := []*InvoiceCandidate{
+		{ID: "inv-1", InvoiceNumber: "INV-001", Amount: "450.00", DueDate: dueDate, CustomerName: "John Smith", NormalizedName: "JOHN SMITH", Status: "sent"},
+	}
+	txns := []BankTransaction{
+		{Description: "JOHN SMITH PAYMENT", Amount: "450.00", TransactionDate: dueDate},
+		{Description: "JOHN SMITH PAYMENT", Amount: "450.00", TransactionDate: dueDate},
+	}
+
+	report, _ := BatchReconcile(txns, candidates)
+	if report.InvoiceUsage["inv-1"] != 2 {
+		t.Errorf("expected invoice inv-1 to show 2 claims, got %v", report.InvoiceUsage)
+	}
+}
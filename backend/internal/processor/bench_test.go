@@ -0,0 +1,77 @@
+package processor
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// syntheticCSV builds a CSV statement with n rows, used to measure
+// rows/sec for both reading and bulk-insert row building at a scale large
+// enough for COPY's advantage over multi-row INSERT to show up.
+func syntheticCSV(n int) []byte {
+	var b strings.Builder
+	b.WriteString("transaction_date,description,amount,reference_number\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "2024-01-%02d,ACME CORP PAYMENT %d,%d.%02d,REF%d\n",
+			(i%28)+1, i, i%10000, i%100, i)
+	}
+	return []byte(b.String())
+}
+
+// BenchmarkCSVStatementRead measures StatementReader.Next's rows/sec over a
+// synthetic 1M-row CSV, the read side of the ingestion path bulkWriter's
+// COPY backend speeds up on the write side.
+func BenchmarkCSVStatementRead(b *testing.B) {
+	content := syntheticCSV(1_000_000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		reader, err := NewStatementReader(FormatCSV, content, "USD")
+		if err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+		rows := 0
+		for {
+			if _, err := reader.Next(); err != nil {
+				if err != io.EOF {
+					b.Fatalf("unexpected error: %v", err)
+				}
+				break
+			}
+			rows++
+		}
+		if rows != 1_000_000 {
+			b.Fatalf("expected 1000000 rows, got %d", rows)
+		}
+	}
+	b.StopTimer()
+	b.ReportMetric(float64(1_000_000)*float64(b.N)/b.Elapsed().Seconds(), "rows/sec")
+}
+
+// BenchmarkBuildBankTransactionRows measures buildBankTransactionRows'
+// rows/sec, the per-batch reduction both bulkWriter backends pay before
+// their INSERT/COPY call.
+func BenchmarkBuildBankTransactionRows(b *testing.B) {
+	const n = 100_000
+	rows := make([]TransactionRow, n)
+	matches := make([]MatchResult, n)
+	ids := make([]string, n)
+	for i := 0; i < n; i++ {
+		rows[i] = TransactionRow{
+			TransactionDate: rows[i].TransactionDate,
+			Description:     fmt.Sprintf("ACME CORP PAYMENT %d", i),
+			Amount:          fmt.Sprintf("%d.%02d", i%10000, i%100),
+		}
+		matches[i] = MatchResult{Status: "unmatched"}
+		ids[i] = fmt.Sprintf("id-%d", i)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buildBankTransactionRows("batch-1", rows, matches, ids)
+	}
+	b.StopTimer()
+	b.ReportMetric(float64(n)*float64(b.N)/b.Elapsed().Seconds(), "rows/sec")
+}
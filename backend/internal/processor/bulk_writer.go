@@ -0,0 +1,215 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/jmoiron/sqlx"
+)
+
+// defaultBatchSize is how many rows Processor buffers between flushes when
+// no COPY-capable backend is available. copyBatchSize is the larger buffer
+// used once a copyWriter has been selected, since COPY amortizes its
+// per-statement overhead across far more rows than a multi-row INSERT can
+// before hitting PostgreSQL's bind-parameter ceiling.
+const (
+	defaultBatchSize = 500
+	copyBatchSize    = 5000
+
+	// copyThresholdBatchSize is the BatchSize at or above which
+	// selectBulkWriter switches to COPY whatever the driver. At this size a
+	// multi-row INSERT would bind 11,000 parameters (11 columns per row),
+	// still under PostgreSQL's 65535 ceiling; copyWriter itself only works
+	// on a pgx connection.
+	copyThresholdBatchSize = 1000
+)
+
+// bankTransactionColumns lists bank_transactions' insert columns in the
+// order bankTransactionRow's fields are written, shared by both
+// multiRowInsertWriter's placeholder build and copyWriter's CopyFrom call so
+// the two backends can't silently drift apart.
+var bankTransactionColumns = []string{
+	"id", "upload_batch_id", "transaction_date", "description", "amount",
+	"reference_number", "status", "matched_invoice_id", "confidence_score",
+	"match_details", "match_reason",
+}
+
+// bankTransactionRow is one bank_transactions row, already reduced to
+// driver-ready values (JSON-encoded match_details, nil-able foreign keys),
+// so both bulkWriter backends build it once and insert it their own way.
+type bankTransactionRow struct {
+	ID                string
+	BatchID           string
+	TransactionDate   interface{}
+	Description       string
+	Amount            string
+	ReferenceNumber   interface{}
+	Status            string
+	MatchedInvoiceID  interface{}
+	ConfidenceScore   interface{}
+	MatchDetailsJSON  string
+	AmountMatchReason interface{}
+}
+
+// buildBankTransactionRows reduces a flushed batch's TransactionRows and
+// MatchResults to the driver-ready form both bulkWriter backends insert,
+// generating one transaction id per row up front so it's known before
+// insert and can be used to link invoice_payments without depending on
+// RETURNING preserving row order.
+func buildBankTransactionRows(batchID string, rows []TransactionRow, matches []MatchResult, transactionIDs []string) []bankTransactionRow {
+	out := make([]bankTransactionRow, len(rows))
+	for i, row := range rows {
+		match := matches[i]
+
+		var invoiceID interface{}
+		if match.InvoiceID != nil {
+			invoiceID = *match.InvoiceID
+		}
+
+		var confidence interface{}
+		if match.Status != "unmatched" {
+			confidence = match.Confidence
+		}
+
+		matchDetailsJSON := "{}"
+		if match.MatchDetails != nil {
+			jsonBytes, err := json.Marshal(match.MatchDetails)
+			if err != nil {
+				log.Printf("Failed to marshal match_details: %v", err)
+			} else {
+				matchDetailsJSON = string(jsonBytes)
+			}
+		}
+
+		// "net"/"gross" when a VAT-carrying invoice matched, NULL otherwise
+		var matchReason interface{}
+		if match.AmountMatchReason != "" {
+			matchReason = match.AmountMatchReason
+		}
+
+		out[i] = bankTransactionRow{
+			ID:                transactionIDs[i],
+			BatchID:           batchID,
+			TransactionDate:   row.TransactionDate,
+			Description:       row.Description,
+			Amount:            row.Amount,
+			ReferenceNumber:   row.ReferenceNumber,
+			Status:            match.Status,
+			MatchedInvoiceID:  invoiceID,
+			ConfidenceScore:   confidence,
+			MatchDetailsJSON:  matchDetailsJSON,
+			AmountMatchReason: matchReason,
+		}
+	}
+	return out
+}
+
+// bulkWriter inserts one flushed batch of bank_transactions rows.
+// multiRowInsertWriter and copyWriter are its two implementations,
+// auto-selected by selectBulkWriter; see ProcessJob and flushBatch.
+type bulkWriter interface {
+	WriteBatch(batchID string, rows []TransactionRow, matches []MatchResult, transactionIDs []string) error
+}
+
+// selectBulkWriter picks copyWriter once BatchSize is large enough that a
+// multi-row INSERT's bind-parameter count becomes the bottleneck, or the
+// configured driver is pgx (the only one of db.go's supported drivers whose
+// *sql.Conn exposes a raw *pgx.Conn for CopyFrom). Anything else falls back
+// to multiRowInsertWriter, whose query uses PostgreSQL's $N placeholders and
+// a ::jsonb cast, as lib/pq accepts.
+func selectBulkWriter(db *sqlx.DB, batchSize int) bulkWriter {
+	if batchSize >= copyThresholdBatchSize || db.DriverName() == "pgx" {
+		return &copyWriter{db: db}
+	}
+	return &multiRowInsertWriter{db: db}
+}
+
+// multiRowInsertWriter is the original flushBatch behavior: one multi-row
+// INSERT per batch, inside its own transaction.
+type multiRowInsertWriter struct {
+	db *sqlx.DB
+}
+
+func (w *multiRowInsertWriter) WriteBatch(batchID string, rows []TransactionRow, matches []MatchResult, transactionIDs []string) error {
+	batch := buildBankTransactionRows(batchID, rows, matches, transactionIDs)
+
+	const colsPerRow = 11
+	args := make([]interface{}, 0, len(batch)*colsPerRow)
+	placeholders := make([]string, 0, len(batch))
+	for i, r := range batch {
+		base := i * colsPerRow
+		placeholders = append(placeholders, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d::jsonb, $%d)",
+			base+1, base+2, base+3, base+4, base+5, base+6, base+7, base+8, base+9, base+10, base+11))
+		args = append(args,
+			r.ID, r.BatchID, r.TransactionDate, r.Description, r.Amount, r.ReferenceNumber,
+			r.Status, r.MatchedInvoiceID, r.ConfidenceScore, r.MatchDetailsJSON, r.AmountMatchReason,
+		)
+	}
+
+	query := "INSERT INTO bank_transactions (" + strings.Join(bankTransactionColumns, ", ") + ") VALUES " +
+		strings.Join(placeholders, ", ")
+
+	tx, err := w.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(query, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// copyWriter streams a batch into bank_transactions over PostgreSQL's COPY
+// protocol via pgx, bypassing the ~65535 bind-parameter ceiling a multi-row
+// INSERT hits at a few thousand rows. COPY has no multi-statement
+// transaction of its own spanning tables - see the comment in flushBatch on
+// what that costs invoice_payments' atomicity.
+type copyWriter struct {
+	db *sqlx.DB
+}
+
+func (w *copyWriter) WriteBatch(batchID string, rows []TransactionRow, matches []MatchResult, transactionIDs []string) error {
+	batch := buildBankTransactionRows(batchID, rows, matches, transactionIDs)
+
+	source := make([][]interface{}, len(batch))
+	for i, r := range batch {
+		source[i] = []interface{}{
+			r.ID, r.BatchID, r.TransactionDate, r.Description, r.Amount, r.ReferenceNumber,
+			r.Status, r.MatchedInvoiceID, r.ConfidenceScore, r.MatchDetailsJSON, r.AmountMatchReason,
+		}
+	}
+
+	conn, err := w.db.Conn(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn interface{}) error {
+		pgxConn := driverConn.(*stdlib.Conn).Conn()
+		_, err := pgxConn.CopyFrom(
+			context.Background(),
+			pgx.Identifier{"bank_transactions"},
+			bankTransactionColumns,
+			pgx.CopyFromRows(source),
+		)
+		return err
+	})
+}
+
+// bulkWriter lazily falls back to a multiRowInsertWriter if flushBatch ever
+// runs against a Processor built outside ProcessJob (e.g. a test harness)
+// that never set BulkWriter explicitly.
+func (p *Processor) bulkWriter() bulkWriter {
+	if p.BulkWriter == nil {
+		p.BulkWriter = &multiRowInsertWriter{db: p.DB}
+	}
+	return p.BulkWriter
+}
@@ -0,0 +1,118 @@
+// Package camt053 parses ISO 20022 CAMT.053 ("BankToCustomerStatement") XML
+// bank statement files into the normalized transaction shape the
+// reconciliation matcher expects, the same role the ofx package plays for
+// OFX/QFX files.
+package camt053
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// BankTransaction is one parsed <Ntry> statement entry.
+type BankTransaction struct {
+	BookingDate    time.Time
+	Amount         *big.Rat // exact decimal amount, always non-negative - sign is CreditDebit, not Amount
+	Currency       string   // the <Amt Ccy="..."> attribute
+	CreditDebit    string   // <CdtDbtInd>: "CRDT" or "DBIT"
+	EntryReference string   // <NtryRef>, the bank's own entry identifier
+	Narrative      string   // the first <TxDtls><RmtInf><Ustrd>, if any
+}
+
+// Description returns the remittance narrative the way
+// extractNameFromDescription expects a bank statement line to read.
+func (t BankTransaction) Description() string {
+	return t.Narrative
+}
+
+// AmountString renders the exact decimal amount the way the rest of the
+// matcher expects: MatchTransaction and InvoiceCandidate.Amount both deal
+// in plain fixed-point decimal strings, not float64.
+func (t BankTransaction) AmountString() string {
+	return t.Amount.FloatString(2)
+}
+
+type xmlDocument struct {
+	XMLName xml.Name `xml:"Document"`
+	Stmt    xmlStmt  `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type xmlStmt struct {
+	Entries []xmlEntry `xml:"Ntry"`
+}
+
+type xmlEntry struct {
+	Amount      xmlAmount   `xml:"Amt"`
+	CdtDbtInd   string      `xml:"CdtDbtInd"`
+	BookingDate xmlDate     `xml:"BookgDt"`
+	NtryRef     string      `xml:"NtryRef"`
+	Details     []xmlTxDtls `xml:"NtryDtls>TxDtls"`
+}
+
+type xmlAmount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type xmlDate struct {
+	Date string `xml:"Dt"`
+}
+
+type xmlTxDtls struct {
+	RemittanceUnstructured string `xml:"RmtInf>Ustrd"`
+}
+
+// ParseStatement parses every <Ntry> entry out of a CAMT.053 document. An
+// entry whose amount or booking date fails to parse is collected as a field
+// error alongside whatever entries did parse (mirroring ofx.ParseStatement),
+// rather than aborting the whole statement.
+func ParseStatement(r io.Reader) ([]BankTransaction, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CAMT.053 data: %w", err)
+	}
+
+	var doc xmlDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse CAMT.053 document: %w", err)
+	}
+
+	var transactions []BankTransaction
+	var fieldErrs []string
+
+	for i, entry := range doc.Stmt.Entries {
+		amount, ok := new(big.Rat).SetString(strings.TrimSpace(entry.Amount.Value))
+		if !ok {
+			fieldErrs = append(fieldErrs, fmt.Sprintf("entry %d: invalid amount %q", i, entry.Amount.Value))
+			continue
+		}
+		bookingDate, err := time.Parse("2006-01-02", entry.BookingDate.Date)
+		if err != nil {
+			fieldErrs = append(fieldErrs, fmt.Sprintf("entry %d: invalid booking date %q: %v", i, entry.BookingDate.Date, err))
+			continue
+		}
+
+		var narrative string
+		if len(entry.Details) > 0 {
+			narrative = strings.TrimSpace(entry.Details[0].RemittanceUnstructured)
+		}
+
+		transactions = append(transactions, BankTransaction{
+			BookingDate:    bookingDate,
+			Amount:         amount,
+			Currency:       entry.Amount.Currency,
+			CreditDebit:    entry.CdtDbtInd,
+			EntryReference: entry.NtryRef,
+			Narrative:      narrative,
+		})
+	}
+
+	if len(fieldErrs) > 0 {
+		return transactions, fmt.Errorf("camt053: %d entry(ies) failed to parse: %s", len(fieldErrs), strings.Join(fieldErrs, "; "))
+	}
+	return transactions, nil
+}
@@ -0,0 +1,88 @@
+package camt053
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const sample = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02">
+  <BkToCstmrStmt>
+    <Stmt>
+      <Id>STMT0001</Id>
+      <Ntry>
+        <Amt Ccy="USD">1000.00</Amt>
+        <CdtDbtInd>CRDT</CdtDbtInd>
+        <BookgDt><Dt>2024-06-15</Dt></BookgDt>
+        <NtryRef>ENTRY001</NtryRef>
+        <NtryDtls>
+          <TxDtls>
+            <RmtInf><Ustrd>ACME CORP Invoice payment</Ustrd></RmtInf>
+          </TxDtls>
+        </NtryDtls>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="USD">50.50</Amt>
+        <CdtDbtInd>DBIT</CdtDbtInd>
+        <BookgDt><Dt>2024-06-16</Dt></BookgDt>
+        <NtryRef>ENTRY002</NtryRef>
+      </Ntry>
+    </Stmt>
+  </BkToCstmrStmt>
+</Document>
+`
+
+func TestParseStatement(t *testing.T) {
+	txns, err := ParseStatement(strings.NewReader(sample))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(txns) != 2 {
+		t.Fatalf("expected 2 transactions, got %d", len(txns))
+	}
+
+	first := txns[0]
+	if first.CreditDebit != "CRDT" {
+		t.Errorf("expected CRDT, got %s", first.CreditDebit)
+	}
+	if got := first.AmountString(); got != "1000.00" {
+		t.Errorf("expected amount 1000.00, got %s", got)
+	}
+	if first.Currency != "USD" {
+		t.Errorf("expected currency USD, got %s", first.Currency)
+	}
+	if !first.BookingDate.Equal(time.Date(2024, 6, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected booking date: %v", first.BookingDate)
+	}
+	if got := first.Description(); got != "ACME CORP Invoice payment" {
+		t.Errorf("unexpected description: %q", got)
+	}
+
+	second := txns[1]
+	if second.CreditDebit != "DBIT" {
+		t.Errorf("expected DBIT, got %s", second.CreditDebit)
+	}
+	if got := second.AmountString(); got != "50.50" {
+		t.Errorf("expected amount 50.50, got %s", got)
+	}
+	// No <NtryDtls> at all on this entry: Description is empty rather than
+	// a missing-field error.
+	if got := second.Description(); got != "" {
+		t.Errorf("expected empty description, got %q", got)
+	}
+}
+
+func TestParseStatement_BadAmountReportedButDoesNotDropOthers(t *testing.T) {
+	bad := strings.Replace(sample, `<Amt Ccy="USD">1000.00</Amt>`, `<Amt Ccy="USD">not-a-number</Amt>`, 1)
+	txns, err := ParseStatement(strings.NewReader(bad))
+	if err == nil {
+		t.Fatal("expected an error for an unparseable amount")
+	}
+	if len(txns) != 1 {
+		t.Fatalf("expected the remaining valid entry despite the bad one, got %d", len(txns))
+	}
+	if txns[0].EntryReference != "ENTRY002" {
+		t.Errorf("expected the surviving entry to be ENTRY002, got %q", txns[0].EntryReference)
+	}
+}
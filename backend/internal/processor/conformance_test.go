@@ -0,0 +1,272 @@
+package processor
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// RECON_UPDATE_VECTORS regenerates every scenario's expected.json from the
+// matcher's current output instead of asserting against it - set this after
+// a deliberate matcher change, review the diff, and commit the updated
+// fixtures. SKIP_CONFORMANCE skips the whole corpus, for CI stages (e.g. a
+// fast unit-test gate) that don't need it.
+const (
+	updateVectorsEnv    = "RECON_UPDATE_VECTORS"
+	skipConformanceEnv  = "SKIP_CONFORMANCE"
+	testvectorsRootPath = "testvectors"
+)
+
+// vectorInvoice is the on-disk shape of testvectors/*/invoices.json: a
+// hand-editable fixture format, independent of InvoiceCandidate's own field
+// names, that loadVectorCache converts into real InvoiceCandidates.
+type vectorInvoice struct {
+	ID            string `json:"id"`
+	InvoiceNumber string `json:"invoiceNumber"`
+	Amount        string `json:"amount"`
+	NetAmount     string `json:"netAmount"`
+	GrossAmount   string `json:"grossAmount"`
+	VATRateBps    int    `json:"vatRateBps"`
+	Currency      string `json:"currency"`
+	DueDate       string `json:"dueDate"` // "2006-01-02"
+	CustomerName  string `json:"customerName"`
+	Status        string `json:"status"`
+}
+
+// vectorExpectation is one row of testvectors/*/expected.json: the outcome
+// Processor.matchRow is expected to produce for the corresponding row of
+// transactions.csv. InvoiceID is set for an ordinary single-invoice result;
+// InvoiceIDs (sorted) is set instead for a split payment, where no single
+// InvoiceID applies.
+type vectorExpectation struct {
+	Row              int      `json:"row"`
+	Status           string   `json:"status"`
+	InvoiceID        string   `json:"invoiceId,omitempty"`
+	InvoiceIDs       []string `json:"invoiceIds,omitempty"`
+	ConfidenceBucket string   `json:"confidenceBucket"`
+}
+
+// TestConformance runs every scenario under testvectors/ - each a folder of
+// invoices.json, transactions.csv, and expected.json - through the same
+// StatementReader/matchRow path processStatementFromContent drives in
+// production, and diffs the actual per-row outcome against expected.json.
+// Persistence
+// (flushBatch's writes to bank_transactions/invoice_payments) is
+// deliberately not exercised here; this corpus is about matching being
+// deterministic, not about the database layer.
+func TestConformance(t *testing.T) {
+	if os.Getenv(skipConformanceEnv) == "1" {
+		t.Skip("SKIP_CONFORMANCE=1")
+	}
+
+	entries, err := os.ReadDir(testvectorsRootPath)
+	if err != nil {
+		t.Fatalf("reading testvectors corpus: %v", err)
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		scenario := entry.Name()
+		t.Run(scenario, func(t *testing.T) {
+			runConformanceScenario(t, filepath.Join(testvectorsRootPath, scenario))
+		})
+	}
+}
+
+func runConformanceScenario(t *testing.T, dir string) {
+	t.Helper()
+
+	cache, fx := loadVectorCache(t, dir)
+	p := &Processor{
+		InvoiceCache:    cache,
+		MatchedInvoices: make(map[string]float64),
+		BaseCurrency:    "USD",
+		FXProvider:      fx,
+	}
+
+	actual := matchVectorTransactions(t, p, filepath.Join(dir, "transactions.csv"))
+
+	expectedPath := filepath.Join(dir, "expected.json")
+	if os.Getenv(updateVectorsEnv) == "1" {
+		writeVectorExpectations(t, expectedPath, actual)
+		return
+	}
+
+	var expected []vectorExpectation
+	readVectorJSON(t, expectedPath, &expected)
+
+	if len(actual) != len(expected) {
+		t.Fatalf("got %d result row(s), expected.json has %d", len(actual), len(expected))
+	}
+	for i := range expected {
+		if !reflect.DeepEqual(actual[i], expected[i]) {
+			t.Errorf("row %d: got %+v, want %+v", expected[i].Row, actual[i], expected[i])
+		}
+	}
+}
+
+// loadVectorCache builds an InvoiceCache straight from dir/invoices.json -
+// the same buildInvoiceCache LoadInvoiceCache uses, just fed hand-authored
+// fixtures instead of a database row set - plus an optional FXProvider from
+// dir/fx_rates.json, a flat "FROM/TO": rate map for StaticRatesProvider.
+// Scenarios with no fx_rates.json get a nil FXProvider, same as a batch run
+// with no FX configured.
+func loadVectorCache(t *testing.T, dir string) (*InvoiceCache, FXProvider) {
+	t.Helper()
+
+	var vectors []vectorInvoice
+	readVectorJSON(t, filepath.Join(dir, "invoices.json"), &vectors)
+
+	candidates := make([]*InvoiceCandidate, 0, len(vectors))
+	for _, v := range vectors {
+		dueDate, err := time.Parse("2006-01-02", v.DueDate)
+		if err != nil {
+			t.Fatalf("invoice %s: invalid dueDate %q: %v", v.ID, v.DueDate, err)
+		}
+		candidates = append(candidates, &InvoiceCandidate{
+			ID:                v.ID,
+			InvoiceNumber:     v.InvoiceNumber,
+			Amount:            v.Amount,
+			NetAmount:         v.NetAmount,
+			GrossAmount:       v.GrossAmount,
+			VATRateBps:        v.VATRateBps,
+			Currency:          v.Currency,
+			CurrencyPrecision: currencyPrecision(v.Currency),
+			DueDate:           dueDate,
+			CustomerName:      v.CustomerName,
+			NormalizedName:    normalizeName(v.CustomerName),
+			Status:            v.Status,
+		})
+	}
+
+	var fx FXProvider
+	rates, ok := readOptionalRates(t, filepath.Join(dir, "fx_rates.json"))
+	if ok {
+		fx = StaticRatesProvider{Rates: rates}
+	}
+
+	return buildInvoiceCache(candidates), fx
+}
+
+// matchVectorTransactions streams dir's transactions.csv through the same
+// csvReader/Processor.matchRow calls processStatementFromContent makes per
+// row, collecting one vectorExpectation per row in file order.
+func matchVectorTransactions(t *testing.T, p *Processor, csvPath string) []vectorExpectation {
+	t.Helper()
+
+	content, err := os.ReadFile(csvPath)
+	if err != nil {
+		t.Fatalf("reading %s: %v", csvPath, err)
+	}
+
+	reader, err := NewStatementReader(FormatCSV, content, p.BaseCurrency)
+	if err != nil {
+		t.Fatalf("building statement reader for %s: %v", csvPath, err)
+	}
+
+	var results []vectorExpectation
+	rowNum := 0
+	for {
+		row, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("reading row %d of %s: %v", rowNum+1, csvPath, err)
+		}
+		rowNum++
+
+		match, _ := p.matchRow(row)
+		results = append(results, vectorExpectationFromMatch(rowNum, match))
+	}
+	return results
+}
+
+func vectorExpectationFromMatch(row int, match MatchResult) vectorExpectation {
+	ve := vectorExpectation{
+		Row:              row,
+		Status:           match.Status,
+		ConfidenceBucket: confidenceBucket(match),
+	}
+	switch {
+	case match.SplitPayment != nil:
+		ids := make([]string, len(match.SplitPayment.Allocations))
+		for i, alloc := range match.SplitPayment.Allocations {
+			ids[i] = alloc.InvoiceID
+		}
+		sort.Strings(ids)
+		ve.InvoiceIDs = ids
+	case match.InvoiceID != nil:
+		ve.InvoiceID = *match.InvoiceID
+	}
+	return ve
+}
+
+// confidenceBucket buckets a MatchResult the way a reviewer would read it:
+// "high" for an auto-matched result, "medium" for one flagged needs_review,
+// "low" for an unmatched result that at least scored some candidate above
+// zero, and "none" when nothing was even in contention (e.g. no candidate
+// shared the transaction's amount).
+func confidenceBucket(match MatchResult) string {
+	switch match.Status {
+	case "auto_matched":
+		return "high"
+	case "needs_review":
+		return "medium"
+	default:
+		if match.Confidence > 0 {
+			return "low"
+		}
+		return "none"
+	}
+}
+
+func readVectorJSON(t *testing.T, path string, v interface{}) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading %s: %v", path, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("parsing %s: %v", path, err)
+	}
+}
+
+// readOptionalRates reads dir/fx_rates.json if present, returning (nil,
+// false) when the scenario doesn't define one rather than treating a
+// missing file as an error.
+func readOptionalRates(t *testing.T, path string) (map[string]float64, bool) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, false
+		}
+		t.Fatalf("reading %s: %v", path, err)
+	}
+	var rates map[string]float64
+	if err := json.Unmarshal(data, &rates); err != nil {
+		t.Fatalf("parsing %s: %v", path, err)
+	}
+	return rates, true
+}
+
+func writeVectorExpectations(t *testing.T, path string, actual []vectorExpectation) {
+	t.Helper()
+	data, err := json.MarshalIndent(actual, "", "  ")
+	if err != nil {
+		t.Fatalf("marshaling %s: %v", path, err)
+	}
+	data = append(data, '\n')
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("writing %s: %v", path, err)
+	}
+	t.Logf("%s=1: wrote %d expectation(s) to %s", updateVectorsEnv, len(actual), path)
+}
@@ -0,0 +1,71 @@
+package processor
+
+import (
+	"strings"
+	"time"
+)
+
+// CurrencyRegistry bundles the two things multi-currency matching needs
+// per currency - decimal precision and an FX rate provider - mirroring how
+// ledger/account tooling typically keeps both next to each other instead of
+// threading a precision table and a rate provider through separately.
+// MatchOptions.Currencies is optional; when nil, MatchTransaction falls back
+// to the package-level currencyPrecision table and matchOpts.FX directly,
+// reproducing pre-CurrencyRegistry behavior exactly.
+type CurrencyRegistry struct {
+	// Precision overrides currencyPrecision's built-in table for specific
+	// currency codes; a code absent here falls back to currencyPrecision.
+	Precision map[string]int
+
+	// FX converts between currencies. Nil disables cross-currency matching,
+	// same as MatchOptions.FX being nil.
+	FX FXProvider
+
+	// MaxRateAge bounds how old an FX rate may be, relative to the
+	// transaction date, before Convert refuses to use it. Only enforced
+	// when FX also implements RateAsOfProvider - a provider with no notion
+	// of a rate's date (e.g. StaticRatesProvider) can't be judged stale, so
+	// MaxRateAge has no effect against one. Zero means no staleness check.
+	MaxRateAge time.Duration
+}
+
+// PrecisionFor returns how many decimal places currency uses, checking
+// r.Precision before falling back to currencyPrecision's built-in table.
+func (r CurrencyRegistry) PrecisionFor(currency string) int {
+	if p, ok := r.Precision[strings.ToUpper(strings.TrimSpace(currency))]; ok {
+		return p
+	}
+	return currencyPrecision(currency)
+}
+
+// Convert converts amount (denominated in fromCurrency) into toCurrency as
+// of txnDate, rounded to toCurrency's precision. ok is false if r.FX is nil,
+// no rate is available for the pair, the rate is older than r.MaxRateAge
+// (only checked when FX implements RateAsOfProvider), or the conversion
+// itself fails - any of which should be treated as "can't compare this
+// candidate's amount", not a zero-value match.
+func (r CurrencyRegistry) Convert(amount, fromCurrency, toCurrency string, txnDate time.Time) (converted string, rate float64, ok bool) {
+	if r.FX == nil {
+		return "", 0, false
+	}
+
+	var rateDate time.Time
+	var err error
+	if aware, isAware := r.FX.(RateAsOfProvider); isAware {
+		rate, rateDate, err = aware.RateAsOf(fromCurrency, toCurrency, txnDate)
+		if err == nil && r.MaxRateAge > 0 && txnDate.Sub(rateDate) > r.MaxRateAge {
+			return "", 0, false
+		}
+	} else {
+		rate, err = r.FX.Rate(fromCurrency, toCurrency, txnDate)
+	}
+	if err != nil {
+		return "", 0, false
+	}
+
+	converted, err = convertAmount(amount, rate, r.PrecisionFor(toCurrency))
+	if err != nil {
+		return "", 0, false
+	}
+	return converted, rate, true
+}
@@ -0,0 +1,82 @@
+package processor
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeDatedFX is a minimal RateAsOfProvider for exercising CurrencyRegistry's
+// staleness check without a database.
+type fakeDatedFX struct {
+	rate     float64
+	rateDate time.Time
+}
+
+func (f fakeDatedFX) Rate(from, to string, asOf time.Time) (float64, error) {
+	rate, _, err := f.RateAsOf(from, to, asOf)
+	return rate, err
+}
+
+func (f fakeDatedFX) RateAsOf(from, to string, asOf time.Time) (float64, time.Time, error) {
+	return f.rate, f.rateDate, nil
+}
+
+func TestCurrencyRegistry_Convert_RejectsStaleRate(t *testing.T) {
+	txnDate := time.Date(2024, 6, 15, 0, 0, 0, 0, time.UTC)
+	registry := CurrencyRegistry{
+		FX:         fakeDatedFX{rate: 1.1, rateDate: txnDate.AddDate(0, 0, -10)},
+		MaxRateAge: 5 * 24 * time.Hour,
+	}
+
+	if _, _, ok := registry.Convert("100.00", "EUR", "USD", txnDate); ok {
+		t.Error("expected Convert to reject a rate older than MaxRateAge")
+	}
+}
+
+func TestCurrencyRegistry_Convert_AcceptsFreshRate(t *testing.T) {
+	txnDate := time.Date(2024, 6, 15, 0, 0, 0, 0, time.UTC)
+	registry := CurrencyRegistry{
+		FX:         fakeDatedFX{rate: 1.1, rateDate: txnDate.AddDate(0, 0, -1)},
+		MaxRateAge: 5 * 24 * time.Hour,
+	}
+
+	converted, rate, ok := registry.Convert("100.00", "EUR", "USD", txnDate)
+	if !ok {
+		t.Fatal("expected Convert to accept a rate within MaxRateAge")
+	}
+	if rate != 1.1 {
+		t.Errorf("expected rate 1.1, got %v", rate)
+	}
+	if converted != "110.00" {
+		t.Errorf("expected converted amount 110.00, got %v", converted)
+	}
+}
+
+func TestCurrencyRegistry_Convert_NoStalenessCheckWithoutRateAsOfProvider(t *testing.T) {
+	txnDate := time.Date(2024, 6, 15, 0, 0, 0, 0, time.UTC)
+	registry := CurrencyRegistry{
+		FX:         StaticRatesProvider{Rates: map[string]float64{"EUR/USD": 1.1}},
+		MaxRateAge: 24 * time.Hour,
+	}
+
+	if _, _, ok := registry.Convert("100.00", "EUR", "USD", txnDate); !ok {
+		t.Error("expected Convert to succeed when FX has no staleness information to check")
+	}
+}
+
+func TestCurrencyRegistry_Convert_NilFXRejects(t *testing.T) {
+	var registry CurrencyRegistry
+	if _, _, ok := registry.Convert("100.00", "EUR", "USD", time.Now()); ok {
+		t.Error("expected Convert to reject with no FX provider configured")
+	}
+}
+
+func TestCurrencyRegistry_PrecisionFor_OverridesDefault(t *testing.T) {
+	registry := CurrencyRegistry{Precision: map[string]int{"JPY": 2}}
+	if got := registry.PrecisionFor("JPY"); got != 2 {
+		t.Errorf("expected override precision 2 for JPY, got %d", got)
+	}
+	if got := registry.PrecisionFor("USD"); got != 2 {
+		t.Errorf("expected default precision 2 for USD, got %d", got)
+	}
+}
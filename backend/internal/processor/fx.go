@@ -0,0 +1,104 @@
+package processor
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// FXProvider converts an amount from one ISO 4217 currency to another as of
+// a given date, so MatchTransaction can compare a transaction posted in one
+// currency against an invoice denominated in another. asOf lets a
+// historical-rates backend pick the rate that was actually in effect when
+// the transaction posted, rather than today's rate.
+type FXProvider interface {
+	Rate(from, to string, asOf time.Time) (float64, error)
+}
+
+// RateAsOfProvider is an optional FXProvider extension that also reports the
+// date the returned rate was actually quoted for, so a caller (see
+// CurrencyRegistry) can reject a conversion whose rate is older than some
+// maximum age relative to the transaction date. StaticRatesProvider has no
+// notion of a rate going stale and doesn't implement this; DailyRatesProvider
+// does, since it already looks up the nearest rate_date on or before asOf.
+type RateAsOfProvider interface {
+	RateAsOf(from, to string, asOf time.Time) (rate float64, rateDate time.Time, err error)
+}
+
+// StaticRatesProvider is an in-memory FXProvider keyed by "FROM/TO" pairs
+// (e.g. "EUR/USD": 1.08), for tests and deployments happy with one fixed
+// conversion table - it ignores asOf, since it has no notion of a rate
+// changing over time. The inverse pair is derived automatically, so only
+// one direction needs to be configured per currency pair.
+type StaticRatesProvider struct {
+	Rates map[string]float64
+}
+
+func (p StaticRatesProvider) Rate(from, to string, asOf time.Time) (float64, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if from == to {
+		return 1.0, nil
+	}
+	if rate, ok := p.Rates[from+"/"+to]; ok {
+		return rate, nil
+	}
+	if rate, ok := p.Rates[to+"/"+from]; ok && rate != 0 {
+		return 1 / rate, nil
+	}
+	return 0, fmt.Errorf("no FX rate available for %s/%s", from, to)
+}
+
+// DailyRatesProvider backs FXProvider with a daily-granularity fx_rates
+// table (currency_from, currency_to, rate_date, rate), for deployments that
+// need the rate actually in effect on a given day rather than one static
+// table. Like the rest of this package, it has no migration of its own -
+// a deployment that hasn't populated fx_rates just sees Rate return an
+// error, same as a missing StaticRatesProvider entry.
+type DailyRatesProvider struct {
+	DB *sqlx.DB
+}
+
+func (p DailyRatesProvider) Rate(from, to string, asOf time.Time) (float64, error) {
+	rate, _, err := p.RateAsOf(from, to, asOf)
+	return rate, err
+}
+
+// RateAsOf is the same lookup as Rate, but also returns the rate_date the
+// quote actually came from (asOf itself for the from==to identity case),
+// so CurrencyRegistry can enforce a staleness window Rate alone can't
+// express.
+func (p DailyRatesProvider) RateAsOf(from, to string, asOf time.Time) (float64, time.Time, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if from == to {
+		return 1.0, asOf, nil
+	}
+
+	rate, rateDate, err := p.latestRate(from, to, asOf)
+	if err == nil {
+		return rate, rateDate, nil
+	}
+
+	// Fall back to the inverse pair before giving up, same as
+	// StaticRatesProvider.
+	inverse, invDate, invErr := p.latestRate(to, from, asOf)
+	if invErr != nil || inverse == 0 {
+		return 0, time.Time{}, fmt.Errorf("no FX rate available for %s/%s as of %s", from, to, asOf.Format("2006-01-02"))
+	}
+	return 1 / inverse, invDate, nil
+}
+
+func (p DailyRatesProvider) latestRate(from, to string, asOf time.Time) (float64, time.Time, error) {
+	var row struct {
+		Rate     float64   `db:"rate"`
+		RateDate time.Time `db:"rate_date"`
+	}
+	err := p.DB.Get(&row, `
+		SELECT rate, rate_date FROM fx_rates
+		WHERE currency_from = $1 AND currency_to = $2 AND rate_date <= $3
+		ORDER BY rate_date DESC
+		LIMIT 1
+	`, from, to, asOf)
+	return row.Rate, row.RateDate, err
+}
@@ -44,9 +44,21 @@ type InvoiceCandidate struct {
 	ID           string
 	InvoiceNumber string
 	Amount       string
+	NetAmount    string // total_net - set equal to Amount when vat_rate_bps is 0
+	GrossAmount  string // total_gross - what's actually owed, including VAT
+	VATRateBps   int
+	Currency     string
+	CurrencyPrecision int // decimal places Currency uses; see currencyPrecision
 	DueDate     time.Time
 	CustomerName string
 	NormalizedName string // Pre-normalized for matching
+	// TrigramSet is NormalizedName's padded character 3-gram set (see
+	// trigramSet), computed once here rather than once per transaction
+	// scored against this candidate. Candidates built by hand (tests,
+	// conformance fixtures) leave it nil, which is fine today - nothing
+	// in the hot path consumes it yet, the same "wired through for later"
+	// state WeightAmount was in before chunk3-3.
+	TrigramSet map[trigram]struct{}
 	Status       string
 }
 
@@ -60,10 +72,14 @@ func LoadInvoiceCache(db *sqlx.DB) (*InvoiceCache, error) {
 	
 	// Load eligible invoices: sent or overdue, not paid
 	query := `
-		SELECT 
+		SELECT
 			id::text,
 			invoice_number,
 			amount::text,
+			total_net::text,
+			total_gross::text,
+			vat_rate_bps,
+			currency,
 			due_date,
 			customer_name,
 			status
@@ -77,6 +93,10 @@ func LoadInvoiceCache(db *sqlx.DB) (*InvoiceCache, error) {
 		ID           string    `db:"id"`
 		InvoiceNumber string    `db:"invoice_number"`
 		Amount       string    `db:"amount"`
+		TotalNet     string    `db:"total_net"`
+		TotalGross   string    `db:"total_gross"`
+		VATRateBps   int       `db:"vat_rate_bps"`
+		Currency     string    `db:"currency"`
 		DueDate      time.Time `db:"due_date"`
 		CustomerName string    `db:"customer_name"`
 		Status       string    `db:"status"`
@@ -112,37 +132,25 @@ func LoadInvoiceCache(db *sqlx.DB) (*InvoiceCache, error) {
 	}
 	log.Printf("DEBUG: After Go sort, first 5 IDs: %s", goSortHash)
 
-	cache := &InvoiceCache{
-		ByAmount: make(map[string][]*InvoiceCandidate),
-		ByID:     make(map[string]*InvoiceCandidate),
-	}
-
+	candidates := make([]*InvoiceCandidate, 0, len(invoices))
 	for _, inv := range invoices {
-		candidate := &InvoiceCandidate{
-			ID:            inv.ID,
-			InvoiceNumber: inv.InvoiceNumber,
-			Amount:        inv.Amount,
-			DueDate:      inv.DueDate,
-			CustomerName: inv.CustomerName,
-			NormalizedName: normalizeName(inv.CustomerName),
-			Status:        inv.Status,
-		}
-
-		// Index by amount
-		cache.ByAmount[inv.Amount] = append(cache.ByAmount[inv.Amount], candidate)
-		cache.ByID[inv.ID] = candidate
-	}
-
-	// Explicitly sort each amount's candidate list for deterministic ordering
-	// This guarantees consistency regardless of database query order
-	for _, candidates := range cache.ByAmount {
-		sort.SliceStable(candidates, func(i, j int) bool {
-			if !candidates[i].DueDate.Equal(candidates[j].DueDate) {
-				return candidates[i].DueDate.Before(candidates[j].DueDate)
-			}
-			return candidates[i].ID < candidates[j].ID
+		candidates = append(candidates, &InvoiceCandidate{
+			ID:                inv.ID,
+			InvoiceNumber:     inv.InvoiceNumber,
+			Amount:            inv.Amount,
+			NetAmount:         inv.TotalNet,
+			GrossAmount:       inv.TotalGross,
+			VATRateBps:        inv.VATRateBps,
+			Currency:          inv.Currency,
+			CurrencyPrecision: currencyPrecision(inv.Currency),
+			DueDate:           inv.DueDate,
+			CustomerName:      inv.CustomerName,
+			NormalizedName:    normalizeName(inv.CustomerName),
+			TrigramSet:        trigramSet(normalizeName(inv.CustomerName)),
+			Status:            inv.Status,
 		})
 	}
+	cache := buildInvoiceCache(candidates)
 
 	// Log a sample amount bucket to verify ordering (pick a common amount like 1100.00)
 	if candidates, ok := cache.ByAmount["1100.00"]; ok && len(candidates) > 1 {
@@ -156,6 +164,41 @@ func LoadInvoiceCache(db *sqlx.DB) (*InvoiceCache, error) {
 	return cache, nil
 }
 
+// buildInvoiceCache indexes already-constructed candidates into an
+// InvoiceCache: by ID, and by whichever amount a bank transaction might
+// legitimately quote (net or gross - the same string when a candidate
+// carries no VAT, so a no-op duplicate in the common case), sorted
+// deterministically within each bucket. Factored out of LoadInvoiceCache so
+// the conformance test harness (testvectors/) can build a cache directly
+// from hand-authored fixtures without a database.
+func buildInvoiceCache(candidates []*InvoiceCandidate) *InvoiceCache {
+	cache := &InvoiceCache{
+		ByAmount: make(map[string][]*InvoiceCandidate),
+		ByID:     make(map[string]*InvoiceCandidate),
+	}
+
+	for _, cand := range candidates {
+		cache.ByAmount[cand.NetAmount] = append(cache.ByAmount[cand.NetAmount], cand)
+		if cand.GrossAmount != cand.NetAmount {
+			cache.ByAmount[cand.GrossAmount] = append(cache.ByAmount[cand.GrossAmount], cand)
+		}
+		cache.ByID[cand.ID] = cand
+	}
+
+	// Explicitly sort each amount's candidate list for deterministic ordering
+	// This guarantees consistency regardless of database query order
+	for _, bucket := range cache.ByAmount {
+		sort.SliceStable(bucket, func(i, j int) bool {
+			if !bucket[i].DueDate.Equal(bucket[j].DueDate) {
+				return bucket[i].DueDate.Before(bucket[j].DueDate)
+			}
+			return bucket[i].ID < bucket[j].ID
+		})
+	}
+
+	return cache
+}
+
 func normalizeName(name string) string {
 	// Uppercase, remove punctuation, collapse spaces
 	name = strings.ToUpper(name)
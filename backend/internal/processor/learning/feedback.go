@@ -0,0 +1,124 @@
+package learning
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ScoredInput is the subset of a scored candidate MatchTransaction already
+// computed that's worth persisting for later recalibration: the features
+// that went into finalScore, and finalScore itself. Logged for every
+// needs_review match (the population RecalibrateWeights trains on) via
+// RecordScored.
+type ScoredInput struct {
+	TransactionID    string
+	InvoiceID        *string
+	NameScore        float64
+	DateAdjustment   float64
+	AmbiguityPenalty float64
+	FinalScore       float64
+	ScoringVersion   string
+}
+
+// Decision is a reviewer's disposition of a needs_review match, recorded by
+// the POST /matches/{id}/feedback handler.
+type Decision string
+
+const (
+	DecisionConfirmed  Decision = "confirmed"
+	DecisionRejected   Decision = "rejected"
+	DecisionReassigned Decision = "reassigned"
+)
+
+// RecordScored inserts one row into match_feedback for a freshly scored
+// match, with decision left NULL until a reviewer acts on it (see
+// RecordDecision). Called once per needs_review result rather than for
+// every match, since auto_matched/unmatched outcomes never reach a human
+// reviewer and so can never become a labeled training example.
+func RecordScored(db *sqlx.DB, in ScoredInput) error {
+	_, err := db.Exec(`
+		INSERT INTO match_feedback (
+			transaction_id, invoice_id, name_score, date_adjustment,
+			ambiguity_penalty, final_score, scoring_version, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+	`, in.TransactionID, in.InvoiceID, in.NameScore, in.DateAdjustment,
+		in.AmbiguityPenalty, in.FinalScore, in.ScoringVersion)
+	if err != nil {
+		return fmt.Errorf("failed to record scored match feedback: %w", err)
+	}
+	return nil
+}
+
+// RecordDecision labels the most recently scored match_feedback row for
+// transactionID with the reviewer's decision, so RecalibrateWeights can
+// later train on it. Returns sql.ErrNoRows if transactionID was never
+// scored via RecordScored (e.g. it auto_matched and so was never logged).
+func RecordDecision(db *sqlx.DB, transactionID string, decision Decision) error {
+	result, err := db.Exec(`
+		UPDATE match_feedback
+		SET decision = $1, decided_at = NOW()
+		WHERE id = (
+			SELECT id FROM match_feedback
+			WHERE transaction_id = $2
+			ORDER BY created_at DESC
+			LIMIT 1
+		)
+	`, string(decision), transactionID)
+	if err != nil {
+		return fmt.Errorf("failed to record match feedback decision: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to confirm match feedback decision was recorded: %w", err)
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// LoadLatestWeights returns the most recently fitted ScoringWeights, or
+// sql.ErrNoRows if RecalibrateWeights has never committed one - callers
+// (e.g. ProcessJob at startup) should fall back to DefaultWeights in that
+// case rather than treat it as a failure.
+func LoadLatestWeights(db *sqlx.DB) (*ScoringWeights, error) {
+	var w ScoringWeights
+	err := db.Get(&w, `
+		SELECT version, name_weight, date_weight, ambiguity_weight,
+		       auto_threshold, review_threshold, fitted_at, training_examples
+		FROM scoring_weights
+		ORDER BY version DESC
+		LIMIT 1
+	`)
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// saveWeights persists a freshly fitted ScoringWeights as the next version,
+// so LoadLatestWeights picks it up on the next startup while every
+// MatchResult already scored under an earlier version stays reproducible.
+func saveWeights(db *sqlx.DB, w ScoringWeights) (*ScoringWeights, error) {
+	var nextVersion int
+	if err := db.Get(&nextVersion, `SELECT COALESCE(MAX(version), 0) + 1 FROM scoring_weights`); err != nil {
+		return nil, fmt.Errorf("failed to determine next scoring weights version: %w", err)
+	}
+	w.Version = nextVersion
+	w.FittedAt = time.Now()
+
+	_, err := db.Exec(`
+		INSERT INTO scoring_weights (
+			version, name_weight, date_weight, ambiguity_weight,
+			auto_threshold, review_threshold, fitted_at, training_examples
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, w.Version, w.NameWeight, w.DateWeight, w.AmbiguityWeight,
+		w.AutoThreshold, w.ReviewThreshold, w.FittedAt, w.TrainingExamples)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save scoring weights: %w", err)
+	}
+	return &w, nil
+}
@@ -0,0 +1,163 @@
+package learning
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// gradientDescentIterations is how many full passes RecalibrateWeights
+	// takes over the training set. A few hundred is enough for this
+	// three-feature logistic fit to converge well past the point where
+	// additional iterations change the decision boundary.
+	gradientDescentIterations = 300
+	// learningRate and l2Lambda are standard defaults for a small,
+	// low-dimensional logistic regression: aggressive enough to converge
+	// within gradientDescentIterations, regularized enough that a training
+	// set dominated by one reviewer's recent habits doesn't swing the fit
+	// to an extreme.
+	learningRate = 0.01
+	l2Lambda     = 0.01
+
+	// NameScoreScale, DateAdjustmentScale, and AmbiguityPenaltyScale bring
+	// nameScore (0-100), dateAdjustment (roughly -10..5), and
+	// ambiguityPenalty (roughly 0-6) onto comparable magnitudes before the
+	// dot product - without this, nameScore alone saturates the sigmoid and
+	// gradient descent never meaningfully moves the other two weights.
+	// Exported so ScoringConfig.WithLearnedWeights can undo the same
+	// scaling when it copies a fit's weights into the unscaled features
+	// matcher.go's finalScore actually multiplies them against.
+	NameScoreScale        = 100.0
+	DateAdjustmentScale   = 10.0
+	AmbiguityPenaltyScale = 10.0
+)
+
+// trainingExample is one labeled match_feedback row: the three features
+// that went into finalScore, and y - 1.0 if the reviewer confirmed the
+// match, 0.0 if they rejected or reassigned it away from the scored
+// invoice.
+type trainingExample struct {
+	nameScore        float64
+	dateAdjustment   float64
+	ambiguityPenalty float64
+	y                float64
+}
+
+// RecalibrateWeights fits a new ScoringWeights from every labeled row in
+// match_feedback (decision IS NOT NULL) via L2-regularized logistic
+// regression, and persists it as the next version for LoadLatestWeights to
+// pick up. It's meant to run offline/periodically (a cron job or manual
+// operator trigger), not inline with matching - a few hundred gradient
+// descent iterations over the whole training set isn't cheap enough to run
+// per-transaction.
+//
+// AutoThreshold and ReviewThreshold aren't refit directly (doing so well
+// needs a held-out validation set to pick a principled cutoff, which a
+// single offline job over the full training set can't provide); they carry
+// forward the prior fit's thresholds (or DefaultWeights', the first time
+// this runs) unchanged.
+func RecalibrateWeights(ctx context.Context, db *sqlx.DB) (*ScoringWeights, error) {
+	var rows []struct {
+		NameScore        float64 `db:"name_score"`
+		DateAdjustment   float64 `db:"date_adjustment"`
+		AmbiguityPenalty float64 `db:"ambiguity_penalty"`
+		Decision         string  `db:"decision"`
+	}
+	err := db.SelectContext(ctx, &rows, `
+		SELECT name_score, date_adjustment, ambiguity_penalty, decision
+		FROM match_feedback
+		WHERE decision IS NOT NULL
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load labeled match feedback: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("no labeled match feedback to recalibrate from")
+	}
+
+	examples := make([]trainingExample, len(rows))
+	for i, r := range rows {
+		y := 0.0
+		if Decision(r.Decision) == DecisionConfirmed {
+			y = 1.0
+		}
+		examples[i] = trainingExample{
+			nameScore:        r.NameScore,
+			dateAdjustment:   r.DateAdjustment,
+			ambiguityPenalty: r.AmbiguityPenalty,
+			y:                y,
+		}
+	}
+
+	prior := DefaultWeights()
+	if latest, err := LoadLatestWeights(db); err == nil {
+		prior = *latest
+	}
+
+	fitted := fitWeights(examples, prior)
+	fitted.TrainingExamples = len(examples)
+
+	return saveWeights(db, fitted)
+}
+
+// fitWeights runs gradient descent on examples, starting from prior's
+// feature weights, and returns a ScoringWeights carrying the fitted
+// weights and prior's unchanged thresholds. Separated from
+// RecalibrateWeights so the optimization itself can be tested without a
+// database.
+//
+// The update rule is standard L2-regularized logistic regression: for each
+// example, scale nameScore/dateAdjustment/ambiguityPenalty down to
+// comparable magnitudes and flip ambiguityPenalty's sign so the dot product
+// mirrors matcher.go's finalScore formula (ambiguityPenalty is subtracted,
+// not added), then compute the sigmoid of the weighted sum and nudge each
+// weight by -learningRate * (x_i*(sigma-y) + l2Lambda*w_i).
+func fitWeights(examples []trainingExample, prior ScoringWeights) ScoringWeights {
+	w := []float64{prior.NameWeight, prior.DateWeight, prior.AmbiguityWeight}
+
+	if len(examples) == 0 {
+		return ScoringWeights{
+			NameWeight:      w[0],
+			DateWeight:      w[1],
+			AmbiguityWeight: w[2],
+			AutoThreshold:   prior.AutoThreshold,
+			ReviewThreshold: prior.ReviewThreshold,
+		}
+	}
+
+	for iter := 0; iter < gradientDescentIterations; iter++ {
+		grad := make([]float64, len(w))
+		for _, ex := range examples {
+			features := []float64{
+				ex.nameScore / NameScoreScale,
+				ex.dateAdjustment / DateAdjustmentScale,
+				-ex.ambiguityPenalty / AmbiguityPenaltyScale,
+			}
+			z := 0.0
+			for i, f := range features {
+				z += w[i] * f
+			}
+			sigma := 1.0 / (1.0 + math.Exp(-z))
+			errTerm := sigma - ex.y
+			for i, f := range features {
+				grad[i] += f * errTerm
+			}
+		}
+		n := float64(len(examples))
+		for i := range w {
+			grad[i] = grad[i]/n + l2Lambda*w[i]
+			w[i] -= learningRate * grad[i]
+		}
+	}
+
+	return ScoringWeights{
+		NameWeight:      w[0],
+		DateWeight:      w[1],
+		AmbiguityWeight: w[2],
+		AutoThreshold:   prior.AutoThreshold,
+		ReviewThreshold: prior.ReviewThreshold,
+	}
+}
@@ -0,0 +1,39 @@
+package learning
+
+import "testing"
+
+func TestFitWeights_IncreasesWeightOnConfirmingFeature(t *testing.T) {
+	// Every confirmed example has a high nameScore and low ambiguity
+	// penalty; every rejected example has the reverse. Gradient descent
+	// should push nameWeight up from the prior, which is what is checked
+	// here (ambiguityWeight rises too, as the rejections carry the larger
+	// penalties).
+	examples := []trainingExample{
+		{nameScore: 95, dateAdjustment: 3, ambiguityPenalty: 0, y: 1.0},
+		{nameScore: 90, dateAdjustment: 2, ambiguityPenalty: 0, y: 1.0},
+		{nameScore: 92, dateAdjustment: 1, ambiguityPenalty: 0, y: 1.0},
+		{nameScore: 20, dateAdjustment: 0, ambiguityPenalty: 5, y: 0.0},
+		{nameScore: 15, dateAdjustment: -2, ambiguityPenalty: 6, y: 0.0},
+		{nameScore: 25, dateAdjustment: 0, ambiguityPenalty: 4, y: 0.0},
+	}
+
+	prior := DefaultWeights()
+	fitted := fitWeights(examples, prior)
+
+	if fitted.NameWeight <= prior.NameWeight {
+		t.Errorf("expected nameWeight to increase from %.4f, got %.4f", prior.NameWeight, fitted.NameWeight)
+	}
+	if fitted.AutoThreshold != prior.AutoThreshold || fitted.ReviewThreshold != prior.ReviewThreshold {
+		t.Errorf("expected thresholds to pass through unchanged, got auto=%.2f review=%.2f",
+			fitted.AutoThreshold, fitted.ReviewThreshold)
+	}
+}
+
+func TestFitWeights_NoExamplesLeavesWeightsAtPrior(t *testing.T) {
+	prior := DefaultWeights()
+	fitted := fitWeights(nil, prior)
+
+	if fitted.NameWeight != prior.NameWeight || fitted.DateWeight != prior.DateWeight || fitted.AmbiguityWeight != prior.AmbiguityWeight {
+		t.Errorf("expected unchanged weights with no training examples, got %+v", fitted)
+	}
+}
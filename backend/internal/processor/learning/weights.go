@@ -0,0 +1,43 @@
+// Package learning closes the loop between human review decisions and the
+// matcher's own confidence scoring: it persists reviewer feedback on
+// needs_review matches to match_feedback, and periodically refits
+// ScoringWeights from that feedback via RecalibrateWeights so future runs
+// score with weights tuned on this tenant's actual review outcomes instead
+// of the matcher's historical hard-coded constants.
+package learning
+
+import "time"
+
+// ScoringWeights is a fitted set of scoring parameters: the per-feature
+// weights logistic regression assigned to nameScore, dateAdjustment, and
+// ambiguityPenalty, plus the two thresholds that turn a weighted score into
+// auto_matched/needs_review/unmatched. Version identifies this fit - it
+// flows into ScoringConfig.Version (see processor.ScoringConfig.WithLearnedWeights)
+// and from there into every MatchResult's MatchDetails["version"], so a
+// match scored under an older fit stays explicable after a newer one loads.
+type ScoringWeights struct {
+	Version         int     `db:"version"`
+	NameWeight      float64 `db:"name_weight"`
+	DateWeight      float64 `db:"date_weight"`
+	AmbiguityWeight float64 `db:"ambiguity_weight"`
+	AutoThreshold   float64 `db:"auto_threshold"`
+	ReviewThreshold float64 `db:"review_threshold"`
+
+	FittedAt         time.Time `db:"fitted_at"`
+	TrainingExamples int       `db:"training_examples"`
+}
+
+// DefaultWeights reproduces the matcher's historical hard-coded behavior
+// (equal weight on name/date/ambiguity, 95/60 thresholds) as version 0, the
+// fallback LoadLatestWeights' caller should use before RecalibrateWeights
+// has ever run.
+func DefaultWeights() ScoringWeights {
+	return ScoringWeights{
+		Version:         0,
+		NameWeight:      1.0,
+		DateWeight:      1.0,
+		AmbiguityWeight: 1.0,
+		AutoThreshold:   95.0,
+		ReviewThreshold: 60.0,
+	}
+}
@@ -1,9 +1,12 @@
 package processor
 
 import (
+	"fmt"
 	"math"
+	"slices"
 	"sort"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -12,30 +15,195 @@ type MatchResult struct {
 	Confidence     float64
 	Status         string // auto_matched, needs_review, unmatched
 	MatchDetails   map[string]interface{}
+	AmountMatchReason string // "net", "gross", or "" when unmatched
+
+	// SplitPayment is set instead of InvoiceID when a single transaction was
+	// matched against two or more invoices by FindSplitPaymentMatch (a
+	// consolidated payment), rather than one. nil for an ordinary
+	// single-invoice result.
+	SplitPayment *SplitPaymentMatch
 }
 
 type scoredCandidate struct {
-	candidate        *InvoiceCandidate
-	nameScore        float64
-	dateDelta        int
-	dateAdjustment   float64
-	ambiguityPenalty float64
-	finalScore       float64
-	finalScoreBP     int // finalScore * 100 as integer for deterministic comparison
+	candidate         *InvoiceCandidate
+	nameScore         float64
+	dateDelta         int
+	dateAdjustment    float64
+	ambiguityPenalty  float64
+	amountMatchReason string  // "net" or "gross" - which total the amount was evaluated against
+	amountDelta       float64 // 0 for an exact match, otherwise the within-tolerance drift
+	amountPenalty     float64
+	finalScore        float64
+	finalScoreBP      int // finalScore * 100 as integer for deterministic comparison
+
+	// Set only when this candidate's currency differed from the
+	// transaction's and FX successfully converted between them.
+	fxApplied         bool
+	fxFromCurrency    string
+	fxToCurrency      string
+	fxRate            float64
+	fxConvertedAmount string
+}
+
+// TieBreaker compares two candidates that scored identically, returning <0
+// if a should sort before b, >0 if b should, or 0 if this rule doesn't
+// distinguish them - in which case the chain moves on to the next rule.
+type TieBreaker func(a, b *InvoiceCandidate) int
+
+// tieBreakerRule pairs a TieBreaker with a human-readable label and a
+// describe function, so the chain can both decide ordering and explain
+// itself in MatchDetails["tieBreaker"].
+type tieBreakerRule struct {
+	name     string
+	compare  TieBreaker
+	describe func(winner, loser *InvoiceCandidate) string
+}
+
+// buildTieBreakerChain returns the documented, ordered tie-break rules used
+// to pick between candidates with an identical final score: (1) smallest
+// |deltaDays|, (2) invoice status priority (sent > overdue > draft), (3)
+// oldest due date, (4) lexicographic invoice number, (5) lexicographic ID.
+// The last rule never ties (invoice IDs are unique), so the chain always
+// produces a strict total order regardless of the candidates slice's input
+// order.
+func buildTieBreakerChain(transactionDate time.Time) []tieBreakerRule {
+	return []tieBreakerRule{
+		{
+			name: "dateDelta",
+			compare: func(a, b *InvoiceCandidate) int {
+				return absDeltaDays(transactionDate, a.DueDate) - absDeltaDays(transactionDate, b.DueDate)
+			},
+			describe: func(winner, loser *InvoiceCandidate) string {
+				return fmt.Sprintf("%dd<%dd", absDeltaDays(transactionDate, winner.DueDate), absDeltaDays(transactionDate, loser.DueDate))
+			},
+		},
+		{
+			name: "status",
+			compare: func(a, b *InvoiceCandidate) int {
+				return statusPriorityRank(a.Status) - statusPriorityRank(b.Status)
+			},
+			describe: func(winner, loser *InvoiceCandidate) string {
+				return fmt.Sprintf("%s>%s", winner.Status, loser.Status)
+			},
+		},
+		{
+			name: "dueDate",
+			compare: func(a, b *InvoiceCandidate) int {
+				switch {
+				case a.DueDate.Equal(b.DueDate):
+					return 0
+				case a.DueDate.Before(b.DueDate):
+					return -1
+				default:
+					return 1
+				}
+			},
+			describe: func(winner, loser *InvoiceCandidate) string {
+				return fmt.Sprintf("%s<%s", winner.DueDate.Format("2006-01-02"), loser.DueDate.Format("2006-01-02"))
+			},
+		},
+		{
+			name:    "invoiceNumber",
+			compare: func(a, b *InvoiceCandidate) int { return strings.Compare(a.InvoiceNumber, b.InvoiceNumber) },
+			describe: func(winner, loser *InvoiceCandidate) string {
+				return fmt.Sprintf("%s<%s", winner.InvoiceNumber, loser.InvoiceNumber)
+			},
+		},
+		{
+			name:    "id",
+			compare: func(a, b *InvoiceCandidate) int { return strings.Compare(a.ID, b.ID) },
+			describe: func(winner, loser *InvoiceCandidate) string {
+				return fmt.Sprintf("%s<%s", winner.ID, loser.ID)
+			},
+		},
+	}
 }
 
-// Global transaction counter for debug logging
-var debugTxnCounter int
+// statusPriorityRank orders invoice statuses for tie-breaking: a
+// currently-payable "sent" invoice is a more plausible match than an
+// "overdue" one, which in turn beats an unsent "draft". Unrecognized
+// statuses sort last.
+func statusPriorityRank(status string) int {
+	switch status {
+	case "sent":
+		return 0
+	case "overdue":
+		return 1
+	case "draft":
+		return 2
+	default:
+		return 3
+	}
+}
+
+func absDeltaDays(transactionDate, dueDate time.Time) int {
+	delta := int(transactionDate.Sub(dueDate).Hours() / 24)
+	if delta < 0 {
+		return -delta
+	}
+	return delta
+}
 
-// MatchTransaction matches a bank transaction against invoice candidates
+// compareCandidates runs chain against a and b in rule order, returning the
+// first non-zero result.
+func compareCandidates(a, b *InvoiceCandidate, chain []tieBreakerRule) int {
+	for _, rule := range chain {
+		if result := rule.compare(a, b); result != 0 {
+			return result
+		}
+	}
+	return 0
+}
+
+// explainTieBreak replays chain against a and b and returns one label per
+// rule consulted: "name:tie" for every rule that didn't distinguish them,
+// then "name:winnerValue>loserValue" for the rule that finally did (the
+// chain stops there, since later rules were never reached).
+func explainTieBreak(a, b *InvoiceCandidate, chain []tieBreakerRule) []string {
+	trail := make([]string, 0, len(chain))
+	for _, rule := range chain {
+		result := rule.compare(a, b)
+		if result == 0 {
+			trail = append(trail, rule.name+":tie")
+			continue
+		}
+		winner, loser := a, b
+		if result > 0 {
+			winner, loser = b, a
+		}
+		trail = append(trail, fmt.Sprintf("%s:%s", rule.name, rule.describe(winner, loser)))
+		break
+	}
+	return trail
+}
+
+// Global transaction counter for debug logging. Atomic because
+// MatchTransactionsStream (see stream.go) calls MatchTransaction
+// concurrently from several worker goroutines; a plain int here would race.
+var debugTxnCounter atomic.Int64
+
+// MatchTransaction matches a bank transaction against invoice candidates.
+// opts is variadic so existing callers are unaffected; pass a MatchOptions
+// to select/weight a different name-scorer ensemble than DefaultMatchOptions.
 func MatchTransaction(
 	description string,
 	amount string,
 	transactionDate time.Time,
 	candidates []*InvoiceCandidate,
+	opts ...MatchOptions,
 ) MatchResult {
-	debugTxnCounter++
-	txnNum := debugTxnCounter
+	matchOpts := DefaultMatchOptions
+	if len(opts) > 0 {
+		matchOpts = opts[0]
+	}
+
+	scoringCfg := DefaultScoringConfig()
+	if matchOpts.Scoring != nil {
+		scoringCfg = *matchOpts.Scoring
+	}
+	scoringCfg = scoringCfg.ForTenant(matchOpts.TenantID)
+
+	txnNum := debugTxnCounter.Add(1)
 	
 	if len(candidates) == 0 {
 		debugLog("TXN#%d: desc=%q amount=%s -> NO_CANDIDATES -> unmatched", 
@@ -44,7 +212,7 @@ func MatchTransaction(
 			Confidence: 0,
 			Status:     "unmatched",
 			MatchDetails: map[string]interface{}{
-				"version": "v1",
+				"version": scoringCfg.Version,
 				"reason":  "no_invoice_with_matching_amount",
 			},
 		}
@@ -82,8 +250,54 @@ func MatchTransaction(
 	}
 	
 	for _, cand := range candidates {
+		// When the transaction and candidate are denominated in different
+		// currencies, convert the transaction amount into the candidate's
+		// currency before comparing. Without an FXProvider to do that
+		// conversion, a currency mismatch can't be safely compared at all,
+		// so the candidate is skipped rather than risk matching unconverted
+		// amounts across currencies.
+		compareAmount := amount
+		amountTolerance := scoringCfg.AmountTolerance
+		var fxApplied bool
+		var fxRate float64
+		if matchOpts.TransactionCurrency != "" && cand.Currency != "" &&
+			!strings.EqualFold(matchOpts.TransactionCurrency, cand.Currency) {
+			var converted string
+			var rate float64
+			var ok bool
+			if matchOpts.Currencies != nil {
+				converted, rate, ok = matchOpts.Currencies.Convert(amount, matchOpts.TransactionCurrency, cand.Currency, transactionDate)
+			} else if matchOpts.FX != nil {
+				if r, err := matchOpts.FX.Rate(matchOpts.TransactionCurrency, cand.Currency, transactionDate); err == nil {
+					if c, convErr := convertAmount(amount, r, cand.CurrencyPrecision); convErr == nil {
+						converted, rate, ok = c, r, true
+					}
+				}
+			}
+			if !ok {
+				continue
+			}
+			compareAmount = converted
+			fxApplied = true
+			fxRate = rate
+			// FX conversion carries its own slippage beyond normal
+			// AmountTolerance (rate staleness, bid/ask spread).
+			if scoringCfg.FX.SlippagePct > 0 {
+				amountTolerance.RelativeToleranceBps += int(scoringCfg.FX.SlippagePct * 100)
+			}
+		}
+
+		// Amount match, within amountTolerance of either the candidate's
+		// net or gross total. Candidates outside tolerance (including the
+		// historical "must match exactly" default) aren't real contenders
+		// and are skipped entirely.
+		amountOK, amountReason, amountDelta, amountPenalty := evaluateCandidateAmount(compareAmount, cand, amountTolerance)
+		if !amountOK {
+			continue
+		}
+
 		// Name similarity (primary factor, 0-100)
-		nameScore := jaroWinkler(extractedName, cand.NormalizedName)
+		nameScore, _ := scoreBreakdown(extractedName, cand.NormalizedName, matchOpts)
 		
 		// Boost score for initial matches (e.g., "S ADAMS" vs "SARAH ADAMS")
 		if len(extractedInitials) >= 2 {
@@ -124,61 +338,112 @@ func MatchTransaction(
 			nameScore = math.Min(nameScore, 50.0) // Cap at 50 if name extraction failed
 		}
 		
-		// Date proximity adjustment (-10 to +5 points)
+		// Date proximity adjustment, shaped by scoringCfg.DateCurve (piecewise
+		// by default, or an exponential decay curve if configured).
 		dateDelta := int(transactionDate.Sub(cand.DueDate).Hours() / 24)
-		dateAdjustment := calculateDateAdjustment(dateDelta)
-		
-		// Ambiguity penalty (if multiple candidates)
-		// Reduced penalty to allow more auto-matches when name similarity is high
-		// Only apply significant penalty for 4+ candidates
-		ambiguityPenalty := 0.0
-		if len(candidates) > 3 {
-			ambiguityPenalty = float64(len(candidates)-3) * 1.0 // -1 point per extra candidate beyond 3
-		}
-		
-		// Final score: nameScore + dateAdjustment - ambiguityPenalty
-		finalScore := nameScore + dateAdjustment - ambiguityPenalty
+		dateAdjustment := scoringCfg.DateCurve.Adjustment(dateDelta)
+
+		// Ambiguity penalty (if multiple candidates), shaped by
+		// scoringCfg.Ambiguity - reduced penalty to allow more auto-matches
+		// when name similarity is high.
+		ambiguityPenalty := scoringCfg.Ambiguity.Penalty(len(candidates))
+
+		// amountScore turns the raw amountPenalty (points already subtracted
+		// unweighted below, for backward compatibility) into a 0-100 score
+		// so it can also be folded in through WeightAmount - the "future
+		// fuzzy-amount mode" ScoringConfig.WeightAmount's doc comment
+		// promised. Default WeightAmount is 0, so this term is a no-op
+		// until a config opts in.
+		amountScore := math.Max(0, 100-amountPenalty)
+
+		// Final score: weighted nameScore + weighted dateAdjustment - weighted ambiguityPenalty - amountPenalty + (amountScore-100) * WeightAmount
+		finalScore := nameScore*scoringCfg.WeightName + dateAdjustment*scoringCfg.WeightDate - ambiguityPenalty*scoringCfg.WeightAmbiguity - amountPenalty + (amountScore-100)*scoringCfg.WeightAmount
 		finalScore = math.Max(0, math.Min(100, finalScore)) // Clamp 0-100
 		finalScore = math.Round(finalScore*100) / 100 // Round to 2 decimals
-		
+
 		// Convert to basis points (integer) for deterministic comparison
 		finalScoreBP := int(math.Round(finalScore * 100))
-		
-		scored = append(scored, scoredCandidate{
-			candidate:        cand,
-			nameScore:        nameScore,
-			dateDelta:        dateDelta,
-			dateAdjustment:   dateAdjustment,
-			ambiguityPenalty: ambiguityPenalty,
-			finalScore:       finalScore,
-			finalScoreBP:     finalScoreBP,
-		})
+
+		sc := scoredCandidate{
+			candidate:         cand,
+			nameScore:         nameScore,
+			dateDelta:         dateDelta,
+			dateAdjustment:    dateAdjustment,
+			ambiguityPenalty:  ambiguityPenalty,
+			amountMatchReason: amountReason,
+			amountDelta:       amountDelta,
+			amountPenalty:     amountPenalty,
+			finalScore:        finalScore,
+			finalScoreBP:      finalScoreBP,
+		}
+		if fxApplied {
+			sc.fxApplied = true
+			sc.fxFromCurrency = matchOpts.TransactionCurrency
+			sc.fxToCurrency = cand.Currency
+			sc.fxRate = fxRate
+			sc.fxConvertedAmount = compareAmount
+		}
+		scored = append(scored, sc)
 	}
-	
-	// Sort by score descending with STRICT TOTAL ORDERING
-	// Using integer basis points (finalScoreBP) eliminates float comparison issues
-	// Every comparison path must return a definitive answer - no "equal" cases left unresolved
-	sort.SliceStable(scored, func(i, j int) bool {
-		// Primary: higher score wins (using integer basis points for exact comparison)
-		if scored[i].finalScoreBP != scored[j].finalScoreBP {
-			return scored[i].finalScoreBP > scored[j].finalScoreBP
+
+	if len(scored) == 0 {
+		// No single candidate's amount lines up - before giving up, check
+		// whether this is a consolidated payment covering several invoices
+		// at once. The search is restricted to candidates that plausibly
+		// belong to the same payer as the description (same customer/
+		// description cluster), so it can't assemble a match out of
+		// unrelated invoices that merely happen to sum correctly.
+		cluster := make([]*InvoiceCandidate, 0, len(candidates))
+		for _, cand := range candidates {
+			clusterScore, _ := scoreBreakdown(extractedName, cand.NormalizedName, matchOpts)
+			if !nameTooWeak && clusterScore >= scoringCfg.NeedsReviewThreshold {
+				cluster = append(cluster, cand)
+			}
 		}
-		
-		// Tie-breaker 1: smaller absolute date delta
-		absDeltaI := int(math.Abs(float64(scored[i].dateDelta)))
-		absDeltaJ := int(math.Abs(float64(scored[j].dateDelta)))
-		if absDeltaI != absDeltaJ {
-			return absDeltaI < absDeltaJ
+		if split, ok := FindSplitPaymentMatch(amount, cluster, scoringCfg.AmountTolerance); ok {
+			debugLog("TXN#%d: desc=%q amount=%s -> SPLIT_PAYMENT across %d invoices",
+				txnNum, description, amount, len(split.Allocations))
+			invoiceIDs := make([]string, len(split.Allocations))
+			for i, a := range split.Allocations {
+				invoiceIDs[i] = a.InvoiceID
+			}
+			return MatchResult{
+				Status:       "needs_review",
+				Confidence:   scoringCfg.NeedsReviewThreshold,
+				SplitPayment: split,
+				MatchDetails: map[string]interface{}{
+					"version":    scoringCfg.Version,
+					"reason":     "split_payment",
+					"invoiceIds": invoiceIDs,
+					"delta":      split.Delta,
+				},
+			}
 		}
-		
-		// Tie-breaker 2: earlier due date
-		if !scored[i].candidate.DueDate.Equal(scored[j].candidate.DueDate) {
-			return scored[i].candidate.DueDate.Before(scored[j].candidate.DueDate)
+
+		debugLog("TXN#%d: desc=%q amount=%s -> NO_CANDIDATES_IN_TOLERANCE -> unmatched",
+			txnNum, description, amount)
+		return MatchResult{
+			Confidence: 0,
+			Status:     "unmatched",
+			MatchDetails: map[string]interface{}{
+				"version": scoringCfg.Version,
+				"reason":  "no_invoice_with_matching_amount",
+			},
 		}
-		
-		// Tie-breaker 3: invoice ID for FINAL deterministic ordering
-		// This ensures we NEVER have two elements that compare "equal"
-		return scored[i].candidate.ID < scored[j].candidate.ID
+	}
+
+	// Sort by score descending with STRICT TOTAL ORDERING. Using integer
+	// basis points (finalScoreBP) eliminates float comparison issues; ties
+	// within the same score fall through tieChain, whose last link
+	// (invoice ID) never ties, guaranteeing a definitive order regardless
+	// of the candidates slice's input order (see TestMatchTransaction_TieBreaking
+	// and its shuffle-fuzz companion).
+	tieChain := buildTieBreakerChain(transactionDate)
+	slices.SortStableFunc(scored, func(x, y scoredCandidate) int {
+		if x.finalScoreBP != y.finalScoreBP {
+			return y.finalScoreBP - x.finalScoreBP // higher score first
+		}
+		return compareCandidates(x.candidate, y.candidate, tieChain)
 	})
 	
 	// Log scored candidates after sorting
@@ -190,18 +455,49 @@ func MatchTransaction(
 	
 	best := scored[0]
 	
-	// Determine status based on final confidence score
-	// Thresholds as per BRD:
-	// - Auto-matched: ≥95% (high confidence)
-	// - Needs review: 60-94% (medium confidence, needs human confirmation)
-	// - Unmatched: <60% (low confidence)
+	// Determine status based on final confidence score, against
+	// scoringCfg's (possibly tenant-overridden) thresholds:
+	// - Auto-matched: >= AutoMatchThreshold (high confidence)
+	// - Needs review: NeedsReviewThreshold..AutoMatchThreshold (medium confidence)
+	// - Unmatched: < NeedsReviewThreshold (low confidence)
 	status := "unmatched"
-	if best.finalScore >= 95.0 {
+	if best.finalScore >= scoringCfg.AutoMatchThreshold {
 		status = "auto_matched"
-	} else if best.finalScore >= 60.0 {
+	} else if best.finalScore >= scoringCfg.NeedsReviewThreshold {
 		status = "needs_review"
 	}
 	
+	// Determine which of the invoice's net/gross totals this transaction's
+	// amount matched, so callers can explain VAT-aware matches. Only
+	// meaningful when the invoice actually carries VAT (net != gross).
+	amountMatchReason := ""
+	if status != "unmatched" && best.candidate.VATRateBps > 0 {
+		amountMatchReason = best.amountMatchReason
+	}
+
+	// Re-run the ensemble for just the winning candidate to capture each
+	// scorer's individual contribution for MatchDetails["name"]["breakdown"].
+	_, nameBreakdown := scoreBreakdown(extractedName, best.candidate.NormalizedName, matchOpts)
+
+	// Only worth auditing the tie-break chain when the top two candidates
+	// actually tied on score - otherwise the score alone decided and the
+	// chain was never consulted.
+	var tieBreakerTrail []string
+	if len(scored) > 1 && scored[0].finalScoreBP == scored[1].finalScoreBP {
+		tieBreakerTrail = explainTieBreak(scored[0].candidate, scored[1].candidate, tieChain)
+	}
+
+	rationale := buildRationale(extractedName, best, len(candidates), scoringCfg)
+
+	var whyNot []WhyNotCandidate
+	if len(scored) > 1 {
+		runnersUp := scored[1:]
+		if len(runnersUp) > 2 {
+			runnersUp = runnersUp[:2]
+		}
+		whyNot = explainWhyNot(best, runnersUp)
+	}
+
 	// Build match details with stable schema
 	matchDetails := buildMatchDetails(
 		description,
@@ -213,23 +509,37 @@ func MatchTransaction(
 		best.dateDelta,
 		best.dateAdjustment,
 		best.ambiguityPenalty,
+		best.amountDelta,
+		best.amountPenalty,
 		best.finalScore,
 		status,
+		nameBreakdown,
+		amountMatchReason,
+		tieBreakerTrail,
+		best.fxApplied,
+		best.fxFromCurrency,
+		best.fxToCurrency,
+		best.fxRate,
+		best.fxConvertedAmount,
+		scoringCfg.Version,
+		rationale,
+		whyNot,
 	)
-	
+
 	var invoiceID *string
 	if status != "unmatched" {
 		invoiceID = &best.candidate.ID
 	}
-	
+
 	debugLog("  RESULT: status=%s bestID=%s score=%.2f", status, best.candidate.ID, best.finalScore)
 	debugLog("")
-	
+
 	return MatchResult{
-		InvoiceID:    invoiceID,
-		Confidence:   best.finalScore,
-		Status:       status,
-		MatchDetails: matchDetails,
+		InvoiceID:         invoiceID,
+		Confidence:        best.finalScore,
+		Status:            status,
+		MatchDetails:      matchDetails,
+		AmountMatchReason: amountMatchReason,
 	}
 }
 
@@ -243,21 +553,67 @@ func buildMatchDetails(
 	dateDelta int,
 	dateAdjustment float64,
 	ambiguityPenalty float64,
+	amountDelta float64,
+	amountPenalty float64,
 	finalScore float64,
 	bucket string,
+	nameBreakdown map[string]float64,
+	amountMatchReason string,
+	tieBreakerTrail []string,
+	fxApplied bool,
+	fxFromCurrency string,
+	fxToCurrency string,
+	fxRate float64,
+	fxConvertedAmount string,
+	version string,
+	rationale []RationaleStep,
+	whyNot []WhyNotCandidate,
 ) map[string]interface{} {
 	extractedName := extractNameFromDescription(description)
-	
+
+	// amountDeltaBP expresses amountDelta as basis points of whichever
+	// invoice total (net or gross) it was measured against, so a caller
+	// doesn't have to redo that division itself to judge "how close" a
+	// within-tolerance match actually was.
+	var amountDeltaBP float64
+	if bestCandidate != nil {
+		reference := bestCandidate.NetAmount
+		if amountMatchReason == "gross" {
+			reference = bestCandidate.GrossAmount
+		}
+		if reference == "" {
+			reference = bestCandidate.Amount
+		}
+		if refAmt, err := ParseMoney(reference, bestCandidate.CurrencyPrecision); err == nil {
+			if refF, _ := refAmt.Float64(); refF > 0 {
+				amountDeltaBP = amountDelta / refF * 10000.0
+			}
+		}
+	}
+
 	details := map[string]interface{}{
-		"version": "v1",
+		"version": version,
 		"amount": map[string]interface{}{
-			"transaction": amount,
-			"invoice":     nil,
+			"transaction":      amount,
+			"invoice":          nil,
+			"matchedAgainst":   amountMatchReason, // "net", "gross", or "" (no VAT split)
+			"delta":            amountDelta,       // 0 for an exact match, otherwise the within-tolerance drift
+			"amountDeltaBP":    amountDeltaBP,     // delta expressed in basis points of the matched total
+			"toleranceApplied": amountDelta != 0,
+			"penalty":          amountPenalty,
+			// currency/convertedAmount/fxRate are nil unless bestCandidate
+			// is in a different currency from the transaction - see "fx"
+			// below, which carries the same values alongside fromCurrency/
+			// toCurrency for a full audit trail.
+			"currency":        nil,
+			"convertedAmount": nil,
+			"fxRate":          nil,
 		},
 		"name": map[string]interface{}{
 			"extracted":   extractedName,
 			"invoiceName": nil,
 			"similarity":  nameScore,
+			"breakdown":   nameBreakdown, // per-scorer contribution, e.g. {"jaro_winkler": 92.1, "token_set": 100}
 		},
 		"date": map[string]interface{}{
 			"transactionDate": transactionDate.Format("2006-01-02"),
@@ -271,7 +627,22 @@ func buildMatchDetails(
 		},
 		"finalScore": finalScore,
 		"bucket":     bucket,
+		// nil unless the amount comparison required converting the
+		// transaction amount into the invoice's currency.
+		"fx": nil,
 		"topCandidates": []interface{}{},
+		// Populated only when the top two candidates tied on score; e.g.
+		// ["dateDelta:tie", "status:sent>draft"] explains that deltaDays
+		// didn't distinguish them but invoice status did.
+		"tieBreaker": tieBreakerTrail,
+		// rationale is the ordered list of scoring steps that produced
+		// finalScore, for GET /api/transactions/:id/explain to render as a
+		// natural-language trail instead of just the final numbers above.
+		"rationale": rationale,
+		// whyNot summarizes, for up to the two runner-up candidates, the
+		// single factor that cost them the most points relative to the
+		// winner. Empty when there was no real contention.
+		"whyNot": whyNot,
 	}
 	
 	if bestCandidate != nil {
@@ -279,6 +650,19 @@ func buildMatchDetails(
 		details["name"].(map[string]interface{})["invoiceName"] = bestCandidate.CustomerName
 		details["date"].(map[string]interface{})["invoiceDueDate"] = bestCandidate.DueDate.Format("2006-01-02")
 	}
+
+	if fxApplied {
+		details["fx"] = map[string]interface{}{
+			"fromCurrency":    fxFromCurrency,
+			"toCurrency":      fxToCurrency,
+			"rate":            fxRate,
+			"convertedAmount": fxConvertedAmount,
+		}
+		amountDetails := details["amount"].(map[string]interface{})
+		amountDetails["currency"] = fxToCurrency
+		amountDetails["convertedAmount"] = fxConvertedAmount
+		amountDetails["fxRate"] = fxRate
+	}
 	
 	// Build top candidates (up to 3)
 	topCandidates := make([]interface{}, 0, 3)
@@ -339,27 +723,6 @@ func extractNameFromDescription(desc string) string {
 	return normalizeName(strings.Join(filteredWords, " "))
 }
 
-func calculateDateAdjustment(daysDelta int) float64 {
-	// Transaction before due date: +5 points (early payment is strong signal)
-	if daysDelta < 0 {
-		return 5.0
-	}
-	// Transaction on or near due date (0-7 days): +3 points
-	if daysDelta <= 7 {
-		return 3.0
-	}
-	// Transaction 8-14 days after: +1 point (still reasonable)
-	if daysDelta <= 14 {
-		return 1.0
-	}
-	// Transaction 15-30 days after: 0 points
-	if daysDelta <= 30 {
-		return 0.0
-	}
-	// Transaction >30 days after: -5 points
-	return -5.0
-}
-
 // jaroWinkler calculates enhanced name similarity that handles:
 // 1. Standard Jaro-Winkler character similarity
 // 2. Token reordering (SMITH JOHN vs JOHN SMITH)
@@ -381,6 +744,7 @@ func jaroWinkler(s1, s2 string) float64 {
 		jaroWinklerRaw(s1, s2),                    // Standard character-based
 		tokenSortedJaroWinkler(s1, s2),            // Compare with sorted tokens
 		tokenOverlapScore(s1, s2),                 // Token intersection score
+		trigramSimilarity(s1, s2),                 // Padded 3-gram Jaccard similarity
 	}
 	
 	// Return the maximum score
@@ -1,9 +1,12 @@
 package processor
 
 import (
+	"math/rand"
 	"strings"
 	"testing"
 	"time"
+
+	"payment-reconciliation-engine/backend/internal/processor/learning"
 )
 
 func TestMatchTransaction_ExactAmountRequired(t *testing.T) {
@@ -151,6 +154,47 @@ func TestMatchTransaction_Thresholds(t *testing.T) {
 	}
 }
 
+func TestMatchTransaction_LearnedWeightsStayInScoreRange(t *testing.T) {
+	// A fit from learning.RecalibrateWeights trains against scaled-down
+	// features (see learning.NameScoreScale etc.), so a realistic fitted
+	// NameWeight is on the order of 50-100, not ~1.0. WithLearnedWeights
+	// must undo that scaling before finalScore multiplies it against the
+	// unscaled nameScore, or a strong match's score blows past 100 instead
+	// of landing in auto_matched range.
+	w := learning.ScoringWeights{
+		Version:         1,
+		NameWeight:      90.0,
+		DateWeight:      8.0,
+		AmbiguityWeight: 15.0,
+		AutoThreshold:   90.0,
+		ReviewThreshold: 60.0,
+	}
+	scoring := DefaultScoringConfig().WithLearnedWeights(w)
+
+	candidates := []*InvoiceCandidate{
+		{
+			ID:             "inv-1",
+			InvoiceNumber:  "INV-001",
+			Amount:         "450.00",
+			DueDate:        time.Date(2024, 12, 10, 0, 0, 0, 0, time.UTC),
+			CustomerName:   "John Smith",
+			NormalizedName: "JOHN SMITH",
+			Status:         "sent",
+		},
+	}
+
+	result := MatchTransaction("JOHN SMITH", "450.00",
+		time.Date(2024, 12, 10, 0, 0, 0, 0, time.UTC), candidates,
+		MatchOptions{Scorers: DefaultMatchOptions.Scorers, Scoring: &scoring})
+
+	if result.Confidence < 0 || result.Confidence > 100 {
+		t.Fatalf("expected finalScore clamped to [0, 100], got %.2f", result.Confidence)
+	}
+	if result.Status != "auto_matched" {
+		t.Errorf("expected an exact name/amount/date match to auto_match under learned weights, got %s (score %.2f)", result.Status, result.Confidence)
+	}
+}
+
 func TestMatchTransaction_TieBreaking(t *testing.T) {
 	candidates := []*InvoiceCandidate{
 		{
@@ -230,3 +274,73 @@ func TestNormalizeName(t *testing.T) {
 	}
 }
 
+func TestMatchTransaction_TieBreaking_ExplainsTheDecidingRule(t *testing.T) {
+	candidates := []*InvoiceCandidate{
+		{
+			ID:            "inv-1",
+			InvoiceNumber: "INV-001",
+			Amount:        "450.00",
+			DueDate:       time.Date(2024, 12, 6, 0, 0, 0, 0, time.UTC),
+			CustomerName:  "John Smith",
+			NormalizedName: "JOHN SMITH",
+			Status:        "sent",
+		},
+		{
+			ID:            "inv-2",
+			InvoiceNumber: "INV-002",
+			Amount:        "450.00",
+			DueDate:       time.Date(2024, 12, 6, 0, 0, 0, 0, time.UTC),
+			CustomerName:  "John Smith",
+			NormalizedName: "JOHN SMITH",
+			Status:        "draft",
+		},
+	}
+
+	// Same amount, same name, identical due date: dateDelta (and its score
+	// adjustment) ties exactly, so status should decide.
+	result := MatchTransaction("JOHN SMITH", "450.00",
+		time.Date(2024, 12, 7, 0, 0, 0, 0, time.UTC), candidates)
+
+	if result.InvoiceID == nil || *result.InvoiceID != "inv-1" {
+		t.Fatalf("expected the 'sent' invoice to win the status tie-break, got %v", result.InvoiceID)
+	}
+
+	trail, ok := result.MatchDetails["tieBreaker"].([]string)
+	if !ok || len(trail) == 0 {
+		t.Fatalf("expected a non-empty tieBreaker trail, got %v", result.MatchDetails["tieBreaker"])
+	}
+	if trail[0] != "dateDelta:tie" {
+		t.Errorf("expected dateDelta to tie first, got %v", trail)
+	}
+	if trail[len(trail)-1] != "status:sent>draft" {
+		t.Errorf("expected status to be the deciding rule, got %v", trail)
+	}
+}
+
+func TestMatchTransaction_TieBreaking_StableAcrossShuffledInput(t *testing.T) {
+	base := []*InvoiceCandidate{
+		{ID: "inv-1", InvoiceNumber: "INV-001", Amount: "450.00", DueDate: time.Date(2024, 12, 8, 0, 0, 0, 0, time.UTC), CustomerName: "John Smith", NormalizedName: "JOHN SMITH", Status: "sent"},
+		{ID: "inv-2", InvoiceNumber: "INV-002", Amount: "450.00", DueDate: time.Date(2024, 12, 6, 0, 0, 0, 0, time.UTC), CustomerName: "John Smith", NormalizedName: "JOHN SMITH", Status: "overdue"},
+		{ID: "inv-3", InvoiceNumber: "INV-003", Amount: "450.00", DueDate: time.Date(2024, 12, 8, 0, 0, 0, 0, time.UTC), CustomerName: "John Smith", NormalizedName: "JOHN SMITH", Status: "overdue"},
+	}
+	txnDate := time.Date(2024, 12, 7, 0, 0, 0, 0, time.UTC)
+
+	first := MatchTransaction("JOHN SMITH", "450.00", txnDate, base)
+	if first.InvoiceID == nil {
+		t.Fatal("expected a match")
+	}
+	want := *first.InvoiceID
+
+	rng := rand.New(rand.NewSource(42))
+	for i := 0; i < 100; i++ {
+		shuffled := make([]*InvoiceCandidate, len(base))
+		copy(shuffled, base)
+		rng.Shuffle(len(shuffled), func(a, b int) { shuffled[a], shuffled[b] = shuffled[b], shuffled[a] })
+
+		result := MatchTransaction("JOHN SMITH", "450.00", txnDate, shuffled)
+		if result.InvoiceID == nil || *result.InvoiceID != want {
+			t.Fatalf("shuffle %d: winner changed to %v, want %s", i, result.InvoiceID, want)
+		}
+	}
+}
+
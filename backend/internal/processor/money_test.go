@@ -0,0 +1,118 @@
+package processor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseMoney_EquivalentRepresentations(t *testing.T) {
+	a, err := ParseMoney("450.00", 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, err := ParseMoney("450", 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c, err := ParseMoney("450.000", 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if a.Cmp(b) != 0 || a.Cmp(c) != 0 {
+		t.Errorf("expected 450.00, 450, and 450.000 to parse equal, got %v %v %v", a, b, c)
+	}
+}
+
+func TestParseMoney_RejectsExcessPrecision(t *testing.T) {
+	if _, err := ParseMoney("450.005", 2); err == nil {
+		t.Error("expected an error for a 2-decimal currency given a 3-decimal amount")
+	}
+}
+
+func TestAmountToleranceConfig_ZeroValueRequiresExactMatch(t *testing.T) {
+	var cfg AmountToleranceConfig
+	delta, _ := ParseMoney("0.01", 2)
+	ref, _ := ParseMoney("450.00", 2)
+
+	if ok, _ := cfg.Evaluate(delta, ref); ok {
+		t.Error("expected the zero-value tolerance to reject any non-zero delta")
+	}
+
+	zero, _ := ParseMoney("0", 2)
+	if ok, penalty := cfg.Evaluate(zero, ref); !ok || penalty != 0 {
+		t.Errorf("expected an exact match to pass with no penalty, got ok=%v penalty=%.2f", ok, penalty)
+	}
+}
+
+func TestAmountToleranceConfig_WithinAbsoluteToleranceCostsAPenalty(t *testing.T) {
+	cfg := AmountToleranceConfig{AbsoluteTolerance: 1.00, PenaltyPerBps: 0.1}
+	delta, _ := ParseMoney("0.50", 2)
+	ref, _ := ParseMoney("450.00", 2)
+
+	ok, penalty := cfg.Evaluate(delta, ref)
+	if !ok {
+		t.Fatal("expected a 0.50 delta to pass a 1.00 absolute tolerance")
+	}
+	if penalty <= 0 {
+		t.Errorf("expected a positive penalty for a non-zero delta, got %.4f", penalty)
+	}
+}
+
+func TestMatchTransaction_AmountWithinToleranceIsPenalizedNotExcluded(t *testing.T) {
+	dueDate := time.Now()
+	candidates := []*InvoiceCandidate{
+		{
+			ID:             "inv-1",
+			InvoiceNumber:  "INV-001",
+			Amount:         "450.00",
+			NetAmount:      "450.00",
+			GrossAmount:    "450.00",
+			CurrencyPrecision: 2,
+			DueDate:        dueDate,
+			CustomerName:   "John Smith",
+			NormalizedName: "JOHN SMITH",
+			Status:         "sent",
+		},
+	}
+
+	cfg := DefaultScoringConfig()
+	cfg.AmountTolerance = AmountToleranceConfig{AbsoluteTolerance: 1.00, PenaltyPerBps: 0.1}
+
+	result := MatchTransaction("JOHN SMITH PAYMENT", "449.50", dueDate, candidates, MatchOptions{Scoring: &cfg})
+	if result.Status == "unmatched" {
+		t.Error("expected a near-exact amount within tolerance to still match")
+	}
+	amountDetails, ok := result.MatchDetails["amount"].(map[string]interface{})
+	if !ok {
+		t.Fatal("expected amount details in MatchDetails")
+	}
+	if amountDetails["toleranceApplied"] != true {
+		t.Errorf("expected toleranceApplied=true for a non-exact amount, got %v", amountDetails["toleranceApplied"])
+	}
+}
+
+func TestMatchTransaction_AmountOutsideToleranceIsExcluded(t *testing.T) {
+	dueDate := time.Now()
+	candidates := []*InvoiceCandidate{
+		{
+			ID:             "inv-1",
+			InvoiceNumber:  "INV-001",
+			Amount:         "450.00",
+			NetAmount:      "450.00",
+			GrossAmount:    "450.00",
+			CurrencyPrecision: 2,
+			DueDate:        dueDate,
+			CustomerName:   "John Smith",
+			NormalizedName: "JOHN SMITH",
+			Status:         "sent",
+		},
+	}
+
+	cfg := DefaultScoringConfig()
+	cfg.AmountTolerance = AmountToleranceConfig{AbsoluteTolerance: 1.00, PenaltyPerBps: 0.1}
+
+	result := MatchTransaction("JOHN SMITH PAYMENT", "440.00", dueDate, candidates, MatchOptions{Scoring: &cfg})
+	if result.Status != "unmatched" {
+		t.Errorf("expected an amount 10.00 outside a 1.00 tolerance to be excluded, got %s", result.Status)
+	}
+}
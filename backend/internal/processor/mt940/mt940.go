@@ -0,0 +1,171 @@
+// Package mt940 parses SWIFT MT940 ("Customer Statement Message") bank
+// statement files into the normalized transaction shape the reconciliation
+// matcher expects, the same role the ofx package plays for OFX/QFX files.
+package mt940
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// BankTransaction is one parsed :61: statement line, with its paired :86:
+// narrative (if present) folded in.
+type BankTransaction struct {
+	ValueDate         time.Time
+	EntryDate         time.Time // zero if the :61: line omitted the optional MMDD entry date
+	DebitCredit       string    // "C" or "D" ("RC"/"RD" reversal marks are reduced to the underlying C/D)
+	Amount            *big.Rat  // exact decimal amount, always non-negative - sign is DebitCredit, not Amount
+	TransactionType   string    // the funds code, e.g. "NTRF", "NMSC"
+	CustomerReference string
+	BankReference     string // the part of the :61: line after "//", if any
+	Narrative         string // the paired :86: free text, if any
+}
+
+// Description joins CustomerReference and Narrative the way
+// extractNameFromDescription expects a bank statement line to read.
+func (t BankTransaction) Description() string {
+	parts := make([]string, 0, 2)
+	for _, p := range []string{t.CustomerReference, t.Narrative} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// AmountString renders the exact decimal amount the way the rest of the
+// matcher expects: MatchTransaction and InvoiceCandidate.Amount both deal
+// in plain fixed-point decimal strings, not float64.
+func (t BankTransaction) AmountString() string {
+	return t.Amount.FloatString(2)
+}
+
+// tagRe matches an MT940 field tag line, e.g. ":61:2401150115C1000,00NTRFREF123//BANKREF".
+var tagRe = regexp.MustCompile(`^:(\w+):(.*)$`)
+
+// line61Re parses a :61: statement line: value date (YYMMDD), optional entry
+// date (MMDD), an optional reversal mark before the debit/credit indicator,
+// the amount (comma decimal separator), a 4-character funds code, and
+// whatever reference text follows.
+var line61Re = regexp.MustCompile(`^(\d{6})(\d{4})?([A-Z]?[CD])(\d+,\d{0,2})([A-Z][A-Z0-9]{3})(.*)$`)
+
+// ParseStatement parses every :61:/:86: transaction pair out of an MT940
+// message. A :61: line that fails to parse is collected as a field error
+// alongside whatever transactions did parse (mirroring ofx.ParseStatement),
+// rather than aborting the whole statement.
+func ParseStatement(r io.Reader) ([]BankTransaction, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var transactions []BankTransaction
+	var cur *BankTransaction
+	var fieldErrs []string
+
+	flush := func() {
+		if cur != nil {
+			transactions = append(transactions, *cur)
+			cur = nil
+		}
+	}
+
+	applyTag := func(tag, value string) {
+		switch tag {
+		case "61":
+			flush()
+			tx, err := parseLine61(value)
+			if err != nil {
+				fieldErrs = append(fieldErrs, fmt.Sprintf("invalid :61: line %q: %v", value, err))
+				return
+			}
+			cur = &tx
+		case "86":
+			if cur != nil {
+				cur.Narrative = strings.TrimSpace(value)
+			}
+		}
+	}
+
+	var pendingTag string
+	var pendingVal strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := tagRe.FindStringSubmatch(line); m != nil {
+			if pendingTag != "" {
+				applyTag(pendingTag, pendingVal.String())
+			}
+			pendingTag = m[1]
+			pendingVal.Reset()
+			pendingVal.WriteString(m[2])
+			continue
+		}
+		// A continuation line of a multi-line tag value (MT940 wraps long
+		// :86: narratives onto following lines with no tag prefix).
+		if pendingTag != "" {
+			pendingVal.WriteString("\n")
+			pendingVal.WriteString(line)
+		}
+	}
+	if pendingTag != "" {
+		applyTag(pendingTag, pendingVal.String())
+	}
+	flush()
+
+	if err := scanner.Err(); err != nil {
+		return transactions, fmt.Errorf("failed to read MT940 data: %w", err)
+	}
+
+	if len(fieldErrs) > 0 {
+		return transactions, fmt.Errorf("mt940: %d field(s) failed to parse: %s", len(fieldErrs), strings.Join(fieldErrs, "; "))
+	}
+	return transactions, nil
+}
+
+func parseLine61(value string) (BankTransaction, error) {
+	m := line61Re.FindStringSubmatch(strings.TrimSpace(value))
+	if m == nil {
+		return BankTransaction{}, fmt.Errorf("unrecognized format")
+	}
+
+	valueDate, err := time.Parse("060102", m[1])
+	if err != nil {
+		return BankTransaction{}, fmt.Errorf("invalid value date: %w", err)
+	}
+
+	var entryDate time.Time
+	if m[2] != "" {
+		d, err := time.Parse("0102", m[2])
+		if err != nil {
+			return BankTransaction{}, fmt.Errorf("invalid entry date: %w", err)
+		}
+		entryDate = time.Date(valueDate.Year(), d.Month(), d.Day(), 0, 0, 0, 0, time.UTC)
+	}
+
+	mark := m[3]
+	debitCredit := mark[len(mark)-1:]
+
+	amount, ok := new(big.Rat).SetString(strings.ReplaceAll(m[4], ",", "."))
+	if !ok {
+		return BankTransaction{}, fmt.Errorf("invalid amount %q", m[4])
+	}
+
+	customerRef, bankRef := strings.TrimSpace(m[6]), ""
+	if idx := strings.Index(customerRef, "//"); idx >= 0 {
+		bankRef = customerRef[idx+2:]
+		customerRef = customerRef[:idx]
+	}
+
+	return BankTransaction{
+		ValueDate:         valueDate,
+		EntryDate:         entryDate,
+		DebitCredit:       debitCredit,
+		Amount:            amount,
+		TransactionType:   m[5],
+		CustomerReference: customerRef,
+		BankReference:     bankRef,
+	}, nil
+}
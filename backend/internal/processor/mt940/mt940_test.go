@@ -0,0 +1,77 @@
+package mt940
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const sample = `:20:STMT0001
+:25:12345678/USD
+:28C:1/1
+:60F:C240601USD1000,00
+:61:2406150615C1000,00NTRFREF123//BANKREF456
+:86:ACME CORP Invoice payment
+:61:240616D50,5NCHGMISC
+:86:Monthly fee
+:62F:C240616USD1949,50
+`
+
+func TestParseStatement(t *testing.T) {
+	txns, err := ParseStatement(strings.NewReader(sample))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(txns) != 2 {
+		t.Fatalf("expected 2 transactions, got %d", len(txns))
+	}
+
+	first := txns[0]
+	if first.DebitCredit != "C" {
+		t.Errorf("expected debit/credit C, got %s", first.DebitCredit)
+	}
+	if got := first.AmountString(); got != "1000.00" {
+		t.Errorf("expected amount 1000.00, got %s", got)
+	}
+	if first.CustomerReference != "REF123" || first.BankReference != "BANKREF456" {
+		t.Errorf("unexpected references: customer=%q bank=%q", first.CustomerReference, first.BankReference)
+	}
+	if !first.ValueDate.Equal(time.Date(2024, 6, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected value date: %v", first.ValueDate)
+	}
+	if !first.EntryDate.Equal(time.Date(2024, 6, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected entry date: %v", first.EntryDate)
+	}
+	if got := first.Description(); got != "REF123 ACME CORP Invoice payment" {
+		t.Errorf("unexpected description: %q", got)
+	}
+
+	second := txns[1]
+	if second.DebitCredit != "D" {
+		t.Errorf("expected debit/credit D, got %s", second.DebitCredit)
+	}
+	if got := second.AmountString(); got != "50.50" {
+		t.Errorf("expected amount 50.50, got %s", got)
+	}
+	if second.TransactionType != "NCHG" {
+		t.Errorf("expected transaction type NCHG, got %s", second.TransactionType)
+	}
+	// No MMDD entry date on this line: EntryDate stays zero.
+	if !second.EntryDate.IsZero() {
+		t.Errorf("expected zero entry date, got %v", second.EntryDate)
+	}
+}
+
+func TestParseStatement_BadLine61ReportedButDoesNotDropOthers(t *testing.T) {
+	bad := strings.Replace(sample, ":61:2406150615C1000,00NTRFREF123//BANKREF456", ":61:not-a-valid-line", 1)
+	txns, err := ParseStatement(strings.NewReader(bad))
+	if err == nil {
+		t.Fatal("expected an error for an unparseable :61: line")
+	}
+	if len(txns) != 1 {
+		t.Fatalf("expected the remaining valid transaction despite the bad one, got %d", len(txns))
+	}
+	if txns[0].TransactionType != "NCHG" {
+		t.Errorf("expected the surviving transaction to be the NCHG one, got %q", txns[0].TransactionType)
+	}
+}
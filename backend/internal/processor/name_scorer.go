@@ -0,0 +1,269 @@
+package processor
+
+import (
+	"math"
+	"strings"
+)
+
+// NameScorer computes a 0-100 similarity score between two names.
+// Implementations are registered by name so MatchOptions can select and
+// weight an ensemble of them without MatchTransaction needing to know
+// about any particular algorithm.
+type NameScorer interface {
+	Score(a, b string) float64
+}
+
+var nameScorerRegistry = map[string]NameScorer{}
+
+// RegisterNameScorer adds (or replaces) a named scorer in the registry.
+func RegisterNameScorer(name string, scorer NameScorer) {
+	nameScorerRegistry[name] = scorer
+}
+
+func init() {
+	RegisterNameScorer("jaro_winkler", jaroWinklerScorer{})
+	RegisterNameScorer("token_set", tokenSetScorer{})
+	RegisterNameScorer("damerau_levenshtein", damerauLevenshteinScorer{})
+	RegisterNameScorer("phonetic", phoneticScorer{base: jaroWinklerScorer{}})
+}
+
+// MatchOptions controls how MatchTransaction scores name similarity and
+// weighs the result. Scorers maps a registered scorer name to its ensemble
+// weight; weights don't need to sum to 1, they're normalized internally. A
+// zero-value MatchOptions (or a nil/empty Scorers map) falls back to
+// DefaultMatchOptions. Scoring is a pointer so "not set" (nil, use
+// DefaultScoringConfig) is distinguishable from an explicit zero-value
+// config; TenantID selects a threshold override within it, if any.
+type MatchOptions struct {
+	Scorers  map[string]float64
+	Scoring  *ScoringConfig
+	TenantID string
+
+	// TransactionCurrency is the ISO 4217 code the transaction amount is
+	// denominated in. When it differs from a candidate's Currency, FX
+	// converts the transaction amount into the candidate's currency before
+	// comparing; with FX nil, a currency mismatch excludes the candidate
+	// outright rather than risk comparing unconverted amounts. Empty means
+	// "don't know" - every candidate is assumed same-currency, reproducing
+	// historical single-currency behavior.
+	TransactionCurrency string
+	FX                  FXProvider
+
+	// Currencies, when set, takes over cross-currency conversion from FX:
+	// it additionally enforces a staleness window on the rate used and
+	// lets per-currency decimal precision be overridden. Leave nil to keep
+	// using FX directly with no staleness check, reproducing historical
+	// behavior.
+	Currencies *CurrencyRegistry
+}
+
+// DefaultMatchOptions reproduces the pre-ensemble behavior (pure
+// jaro_winkler), kept as the default so existing callers see no change.
+var DefaultMatchOptions = MatchOptions{
+	Scorers: map[string]float64{"jaro_winkler": 1.0},
+}
+
+// scoreBreakdown runs every scorer named in opts.Scorers against a and b
+// and returns both the weighted ensemble score and each scorer's individual
+// contribution, so callers can explain a match via
+// MatchDetails["name"]["breakdown"].
+func scoreBreakdown(a, b string, opts MatchOptions) (ensemble float64, breakdown map[string]float64) {
+	weights := opts.Scorers
+	if len(weights) == 0 {
+		weights = DefaultMatchOptions.Scorers
+	}
+
+	breakdown = make(map[string]float64, len(weights))
+	var weightedSum, totalWeight float64
+	for name, weight := range weights {
+		scorer, ok := nameScorerRegistry[name]
+		if !ok || weight <= 0 {
+			continue
+		}
+		score := scorer.Score(a, b)
+		breakdown[name] = score
+		weightedSum += score * weight
+		totalWeight += weight
+	}
+
+	if totalWeight == 0 {
+		return 0, breakdown
+	}
+	return weightedSum / totalWeight, breakdown
+}
+
+// jaroWinklerScorer wraps the existing ensemble-of-heuristics Jaro-Winkler
+// implementation (best of character Jaro-Winkler, token-sorted,
+// token-overlap and trigram similarity),
+// kept as the default scorer for backward compatibility.
+type jaroWinklerScorer struct{}
+
+func (jaroWinklerScorer) Score(a, b string) float64 {
+	return jaroWinkler(a, b)
+}
+
+// tokenSetScorer is a token-set ratio: intersection over union of each
+// name's whitespace-separated tokens, so "ADAMS SARAH" vs "SARAH ADAMS"
+// scores 100 without relying on jaroWinkler's internal sort-then-compare
+// trick.
+type tokenSetScorer struct{}
+
+func (tokenSetScorer) Score(a, b string) float64 {
+	tokensA := strings.Fields(strings.ToUpper(a))
+	tokensB := strings.Fields(strings.ToUpper(b))
+	if len(tokensA) == 0 || len(tokensB) == 0 {
+		return 0
+	}
+
+	setA := make(map[string]bool, len(tokensA))
+	for _, t := range tokensA {
+		setA[t] = true
+	}
+	setB := make(map[string]bool, len(tokensB))
+	for _, t := range tokensB {
+		setB[t] = true
+	}
+
+	intersection := 0
+	for t := range setA {
+		if setB[t] {
+			intersection++
+		}
+	}
+	union := len(setA) + len(setB) - intersection
+	if union == 0 {
+		return 0
+	}
+	return float64(intersection) / float64(union) * 100.0
+}
+
+// damerauLevenshteinScorer normalizes Damerau-Levenshtein edit distance
+// (insertions, deletions, substitutions, and adjacent transpositions) to a
+// 0-100 similarity score.
+type damerauLevenshteinScorer struct{}
+
+func (damerauLevenshteinScorer) Score(a, b string) float64 {
+	a, b = strings.ToUpper(a), strings.ToUpper(b)
+	if a == b {
+		return 100.0
+	}
+	maxLen := max(len([]rune(a)), len([]rune(b)))
+	if maxLen == 0 {
+		return 100.0
+	}
+	similarity := 1.0 - float64(damerauLevenshteinDistance(a, b))/float64(maxLen)
+	if similarity < 0 {
+		similarity = 0
+	}
+	return similarity * 100.0
+}
+
+func damerauLevenshteinDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	la, lb := len(ra), len(rb)
+
+	d := make([][]int, la+1)
+	for i := range d {
+		d[i] = make([]int, lb+1)
+		d[i][0] = i
+	}
+	for j := 0; j <= lb; j++ {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= la; i++ {
+		for j := 1; j <= lb; j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, min(d[i][j-1]+1, d[i-1][j-1]+cost))
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+cost) // adjacent transposition
+			}
+		}
+	}
+	return d[la][lb]
+}
+
+// phoneticScorer boosts a base scorer's result to at least 92 when the
+// Soundex codes of the names' last tokens match, catching spelling variants
+// a character-similarity scorer misses entirely (e.g. "SMYTHE" vs "SMITH").
+type phoneticScorer struct {
+	base NameScorer
+}
+
+func (p phoneticScorer) Score(a, b string) float64 {
+	base := p.base.Score(a, b)
+
+	lastA, lastB := lastToken(a), lastToken(b)
+	if lastA == "" || lastB == "" {
+		return base
+	}
+	if soundex(lastA) == soundex(lastB) {
+		return math.Max(base, 92.0)
+	}
+	return base
+}
+
+func lastToken(s string) string {
+	tokens := strings.Fields(s)
+	if len(tokens) == 0 {
+		return ""
+	}
+	return tokens[len(tokens)-1]
+}
+
+// soundex implements the standard American Soundex algorithm: a letter
+// followed by up to 3 digits encoding its phonetic class. Used here to
+// catch consonant-substitution misspellings (SMYTHE/SMITH) that edit
+// distance and token overlap both treat as dissimilar.
+func soundex(s string) string {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if s == "" {
+		return ""
+	}
+
+	code := func(r rune) byte {
+		switch r {
+		case 'B', 'F', 'P', 'V':
+			return '1'
+		case 'C', 'G', 'J', 'K', 'Q', 'S', 'X', 'Z':
+			return '2'
+		case 'D', 'T':
+			return '3'
+		case 'L':
+			return '4'
+		case 'M', 'N':
+			return '5'
+		case 'R':
+			return '6'
+		default:
+			return 0
+		}
+	}
+
+	runes := []rune(s)
+	var result strings.Builder
+	result.WriteRune(runes[0])
+
+	lastCode := code(runes[0])
+	for _, r := range runes[1:] {
+		c := code(r)
+		if c != 0 && c != lastCode {
+			result.WriteByte(c)
+		}
+		if r != 'H' && r != 'W' {
+			lastCode = c
+		}
+		if result.Len() >= 4 {
+			break
+		}
+	}
+
+	out := result.String()
+	for len(out) < 4 {
+		out += "0"
+	}
+	return out[:4]
+}
@@ -0,0 +1,58 @@
+package processor
+
+import "testing"
+
+func TestTokenSetScorer_OrderIndependent(t *testing.T) {
+	scorer := tokenSetScorer{}
+	score := scorer.Score("ADAMS SARAH", "SARAH ADAMS")
+	if score != 100.0 {
+		t.Errorf("expected 100 for reordered tokens, got %.2f", score)
+	}
+}
+
+func TestDamerauLevenshteinScorer_Transposition(t *testing.T) {
+	scorer := damerauLevenshteinScorer{}
+	// A single adjacent transposition should cost 1 edit, not 2 (which a
+	// plain Levenshtein distance would charge).
+	score := scorer.Score("SMITH", "SMTIH")
+	if score < 75.0 {
+		t.Errorf("expected a high score for a single transposition, got %.2f", score)
+	}
+}
+
+func TestPhoneticScorer_CatchesSpellingVariant(t *testing.T) {
+	scorer := phoneticScorer{base: jaroWinklerScorer{}}
+	score := scorer.Score("JOHN SMYTHE", "JOHN SMITH")
+	if score < 92.0 {
+		t.Errorf("expected phonetic boost to at least 92, got %.2f", score)
+	}
+}
+
+func TestScoreBreakdown_WeightedEnsemble(t *testing.T) {
+	opts := MatchOptions{Scorers: map[string]float64{
+		"jaro_winkler": 1.0,
+		"token_set":    1.0,
+	}}
+
+	ensemble, breakdown := scoreBreakdown("SARAH ADAMS", "ADAMS SARAH", opts)
+
+	if _, ok := breakdown["jaro_winkler"]; !ok {
+		t.Error("expected jaro_winkler contribution in breakdown")
+	}
+	if _, ok := breakdown["token_set"]; !ok {
+		t.Error("expected token_set contribution in breakdown")
+	}
+	if ensemble < 90.0 {
+		t.Errorf("expected a high ensemble score for a reordered exact match, got %.2f", ensemble)
+	}
+}
+
+func TestScoreBreakdown_EmptyOptionsFallsBackToDefault(t *testing.T) {
+	ensemble, breakdown := scoreBreakdown("JOHN SMITH", "JOHN SMITH", MatchOptions{})
+	if ensemble != 100.0 {
+		t.Errorf("expected 100 for an identical name, got %.2f", ensemble)
+	}
+	if len(breakdown) != 1 {
+		t.Errorf("expected exactly the default jaro_winkler scorer to run, got %v", breakdown)
+	}
+}
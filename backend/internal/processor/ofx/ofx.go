@@ -0,0 +1,158 @@
+// Package ofx parses OFX 1.x (SGML) and OFX 2.x (XML) bank statement files
+// into the normalized transaction shape the reconciliation matcher expects,
+// so a CSV upload isn't the only way to get bank transactions into the
+// system.
+package ofx
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BankTransaction is one parsed STMTTRN record.
+type BankTransaction struct {
+	FITID    string   // bank's unique transaction id, for idempotent re-import dedup
+	TrnType  string   // OFX TRNTYPE, e.g. CREDIT, DEBIT, CHECK
+	PostedAt time.Time
+	Amount   *big.Rat // exact decimal amount - never float64, to avoid rounding drift on re-import
+	Name     string
+	Memo     string
+	CheckNum string
+}
+
+// Description concatenates NAME+MEMO+CHECKNUM the way
+// extractNameFromDescription expects a bank statement line to read.
+func (t BankTransaction) Description() string {
+	parts := make([]string, 0, 3)
+	for _, p := range []string{t.Name, t.Memo, t.CheckNum} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// AmountString renders the exact decimal amount the way the rest of the
+// matcher expects: MatchTransaction and InvoiceCandidate.Amount both deal
+// in plain fixed-point decimal strings, not float64.
+func (t BankTransaction) AmountString() string {
+	return t.Amount.FloatString(2)
+}
+
+// tagRe matches an OFX/SGML tag and everything up to the next tag. This
+// works for both OFX 1.x SGML (leaf elements have no closing tag - the
+// value just runs to the next "<") and OFX 2.x XML (the "next tag" is the
+// element's own closing tag, e.g. "</NAME>", which simply doesn't match
+// tagRe's name class and gets skipped).
+var tagRe = regexp.MustCompile(`<([A-Za-z0-9.]+)>([^<]*)`)
+
+// ParseStatement parses every STMTTRN record out of an OFX/QFX document.
+// A record-level field that fails to parse (a bad DTPOSTED or TRNAMT) is
+// skipped from the field but does not drop the transaction; all such
+// failures are collected and returned as a single error alongside whatever
+// transactions did parse, so a caller can decide whether to proceed.
+func ParseStatement(r io.Reader) ([]BankTransaction, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OFX data: %w", err)
+	}
+
+	var transactions []BankTransaction
+	var cur *BankTransaction
+	var fieldErrs []string
+
+	flush := func() {
+		if cur != nil {
+			transactions = append(transactions, *cur)
+			cur = nil
+		}
+	}
+
+	for _, m := range tagRe.FindAllStringSubmatch(string(data), -1) {
+		tag := strings.ToUpper(m[1])
+		value := strings.TrimSpace(m[2])
+
+		if tag == "STMTTRN" {
+			flush()
+			cur = &BankTransaction{}
+			continue
+		}
+		if cur == nil {
+			continue // outside a STMTTRN block (account info, balances, etc.)
+		}
+
+		switch tag {
+		case "TRNTYPE":
+			cur.TrnType = value
+		case "DTPOSTED":
+			posted, err := parseOFXDate(value)
+			if err != nil {
+				fieldErrs = append(fieldErrs, fmt.Sprintf("invalid DTPOSTED %q: %v", value, err))
+				continue
+			}
+			cur.PostedAt = posted
+		case "TRNAMT":
+			amt, ok := new(big.Rat).SetString(value)
+			if !ok {
+				fieldErrs = append(fieldErrs, fmt.Sprintf("invalid TRNAMT %q", value))
+				continue
+			}
+			cur.Amount = amt
+		case "FITID":
+			cur.FITID = value
+		case "NAME":
+			cur.Name = value
+		case "MEMO":
+			cur.Memo = value
+		case "CHECKNUM":
+			cur.CheckNum = value
+		}
+	}
+	flush()
+
+	if len(fieldErrs) > 0 {
+		return transactions, fmt.Errorf("ofx: %d field(s) failed to parse: %s", len(fieldErrs), strings.Join(fieldErrs, "; "))
+	}
+	return transactions, nil
+}
+
+// ofxDateRe splits an OFX DTPOSTED value into its date, time, and optional
+// "[offset:TZ]" timezone suffix, e.g. "20230105120000.500[-5:EST]".
+var ofxDateRe = regexp.MustCompile(`^(\d{8})(\d{2})?(\d{2})?(\d{2})?(?:\.\d+)?(?:\[([+-]?[\d.]+)(?::(\w+))?\])?$`)
+
+func parseOFXDate(raw string) (time.Time, error) {
+	m := ofxDateRe.FindStringSubmatch(raw)
+	if m == nil {
+		return time.Time{}, fmt.Errorf("unrecognized OFX date format")
+	}
+
+	hour, min, sec := "00", "00", "00"
+	if m[2] != "" {
+		hour = m[2]
+	}
+	if m[3] != "" {
+		min = m[3]
+	}
+	if m[4] != "" {
+		sec = m[4]
+	}
+
+	loc := time.UTC
+	if m[5] != "" {
+		offsetHours, err := strconv.ParseFloat(m[5], 64)
+		if err == nil {
+			name := m[6]
+			if name == "" {
+				name = "OFX"
+			}
+			loc = time.FixedZone(name, int(offsetHours*3600))
+		}
+	}
+
+	return time.ParseInLocation("20060102150405", m[1]+hour+min+sec, loc)
+}
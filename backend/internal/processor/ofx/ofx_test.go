@@ -0,0 +1,124 @@
+package ofx
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const sgmlSample = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+
+<OFX>
+<BANKMSGSRSV1>
+<STMTTRNRS>
+<STMTRS>
+<BANKTRANLIST>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20230105120000[-5:EST]
+<TRNAMT>-123.45
+<FITID>1001
+<NAME>ACME CORP
+<MEMO>Invoice payment
+<CHECKNUM>554
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>CREDIT
+<DTPOSTED>20230106
+<TRNAMT>67.89
+<FITID>1002
+<NAME>JANE DOE
+</STMTTRN>
+</BANKTRANLIST>
+</STMTRS>
+</STMTTRNRS>
+</BANKMSGSRSV1>
+</OFX>
+`
+
+const xmlSample = `<?xml version="1.0" encoding="UTF-8"?>
+<?OFX OFXHEADER="200" VERSION="211" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+<OFX>
+<BANKMSGSRSV1>
+<STMTTRNRS>
+<STMTRS>
+<BANKTRANLIST>
+<STMTTRN>
+<TRNTYPE>DEBIT</TRNTYPE>
+<DTPOSTED>20230105120000[-5:EST]</DTPOSTED>
+<TRNAMT>-123.45</TRNAMT>
+<FITID>1001</FITID>
+<NAME>ACME CORP</NAME>
+<MEMO>Invoice payment</MEMO>
+</STMTTRN>
+</BANKTRANLIST>
+</STMTRS>
+</STMTTRNRS>
+</BANKMSGSRSV1>
+</OFX>
+`
+
+func TestParseStatement_SGML(t *testing.T) {
+	txns, err := ParseStatement(strings.NewReader(sgmlSample))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(txns) != 2 {
+		t.Fatalf("expected 2 transactions, got %d", len(txns))
+	}
+
+	first := txns[0]
+	if first.FITID != "1001" {
+		t.Errorf("expected FITID 1001, got %s", first.FITID)
+	}
+	if got := first.AmountString(); got != "-123.45" {
+		t.Errorf("expected amount -123.45, got %s", got)
+	}
+	if got := first.Description(); got != "ACME CORP Invoice payment 554" {
+		t.Errorf("unexpected description: %q", got)
+	}
+	if !first.PostedAt.Equal(time.Date(2023, 1, 5, 12, 0, 0, 0, time.FixedZone("EST", -5*3600))) {
+		t.Errorf("unexpected posted time: %v", first.PostedAt)
+	}
+
+	second := txns[1]
+	if second.FITID != "1002" {
+		t.Errorf("expected FITID 1002, got %s", second.FITID)
+	}
+	if got := second.AmountString(); got != "67.89" {
+		t.Errorf("expected amount 67.89, got %s", got)
+	}
+	// No time-of-day or timezone in DTPOSTED: defaults to midnight UTC.
+	if !second.PostedAt.Equal(time.Date(2023, 1, 6, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected posted time: %v", second.PostedAt)
+	}
+}
+
+func TestParseStatement_XML(t *testing.T) {
+	txns, err := ParseStatement(strings.NewReader(xmlSample))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(txns) != 1 {
+		t.Fatalf("expected 1 transaction, got %d", len(txns))
+	}
+	if txns[0].AmountString() != "-123.45" {
+		t.Errorf("expected amount -123.45, got %s", txns[0].AmountString())
+	}
+}
+
+func TestParseStatement_BadTrnAmtReportedButDoesNotDropTransaction(t *testing.T) {
+	bad := strings.ReplaceAll(sgmlSample, "-123.45", "not-a-number")
+	txns, err := ParseStatement(strings.NewReader(bad))
+	if err == nil {
+		t.Fatal("expected an error for an unparseable TRNAMT")
+	}
+	if len(txns) != 2 {
+		t.Fatalf("expected both transactions despite the bad field, got %d", len(txns))
+	}
+	if txns[0].Amount != nil {
+		t.Errorf("expected nil Amount for the unparseable transaction, got %v", txns[0].Amount)
+	}
+}
@@ -1,16 +1,20 @@
 package processor
 
 import (
-	"encoding/csv"
-	"encoding/json"
 	"fmt"
 	"io"
 	"log"
+	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"payment-reconciliation-engine/backend/internal/events"
+	"payment-reconciliation-engine/backend/internal/processor/learning"
+	"payment-reconciliation-engine/backend/internal/webhooks"
 	"payment-reconciliation-engine/backend/internal/worker"
 )
 
@@ -18,10 +22,61 @@ type Processor struct {
 	DB            *sqlx.DB
 	Worker        *worker.Worker
 	BatchID       string
+	// JobID identifies this run's reconciliation_jobs row, so flushBatch can
+	// call Worker.Heartbeat(JobID) to prove the job is still alive even
+	// during a long stretch of CPU-bound matching with no DB writes.
+	JobID         string
 	InvoiceCache  *InvoiceCache
 	BatchSize     int
 	ProgressEvery int
-	MatchedInvoices map[string]bool // Track matched invoices to prevent duplicates
+
+	// MatchedInvoices tracks each invoice's remaining balance (starting from
+	// its full owed amount) rather than a simple matched/unmatched bool, so
+	// an invoice can be paid down across several transactions (installments)
+	// and stops being offered as a candidate once its balance reaches zero.
+	// Invoices not yet present here are assumed to have their full balance
+	// outstanding - see remainingBalance.
+	MatchedInvoices map[string]float64
+
+	// Lifecycle timestamps for this processing run. CreatedAt/StartedAt are
+	// set once at the top of ProcessJob; LastCheckpointAt/FinishedAt are
+	// updated as processing and checkpointing progress. These mirror (and
+	// are persisted through to) reconciliation_batches' own timestamp
+	// columns, so a resumed job can report how long it's actually been
+	// running rather than just since the current process restarted.
+	CreatedAt        time.Time
+	StartedAt        time.Time
+	LastCheckpointAt time.Time
+	FinishedAt       time.Time
+
+	// ResumeOffset is the statement row offset (1-indexed count of rows
+	// already read) to skip forward to before matching/inserting anything,
+	// loaded from a prior batch_checkpoints row when ProcessJob resumes a
+	// job a crashed worker left mid-file. Zero means start from row 1.
+	ResumeOffset int
+
+	// BaseCurrency is the currency a row's amount is assumed to be in when
+	// its CSV has no "currency" column, and the only currency FXProvider
+	// doesn't need to be consulted for.
+	BaseCurrency string
+	// FXProvider converts between currencies when a row's currency differs
+	// from an invoice's; nil means cross-currency candidates are excluded
+	// rather than compared unconverted.
+	FXProvider FXProvider
+
+	// BulkWriter inserts each flushed batch into bank_transactions.
+	// ProcessJob selects it eagerly via selectBulkWriter, based on DB's
+	// driver and BatchSize, and raises BatchSize once COPY is in use; if it
+	// is still nil at flushBatch's first call, a multiRowInsertWriter is
+	// used - see bulk_writer.go.
+	BulkWriter bulkWriter
+
+	// Scoring overrides DefaultScoringConfig for every match this run
+	// makes, set once at ProcessJob startup from whatever
+	// learning.RecalibrateWeights last fitted. Nil means no recalibration
+	// has ever run yet, so matchRow leaves MatchOptions.Scoring unset and
+	// MatchTransaction falls back to DefaultScoringConfig itself.
+	Scoring *ScoringConfig
 }
 
 type TransactionRow struct {
@@ -29,17 +84,29 @@ type TransactionRow struct {
 	Description     string
 	Amount          string
 	ReferenceNumber *string
+	// Currency is the ISO 4217 code this row's amount is denominated in,
+	// taken from an optional "currency" CSV column and defaulting to
+	// Processor.BaseCurrency when the column is absent or blank.
+	Currency string
+	// Source carries a non-CSV StatementReader's raw native fields (bank
+	// transaction id, type code, counterparty reference, ...), merged into
+	// MatchResult.MatchDetails["source"] by matchRow for audit. Nil for CSV
+	// rows, which have no fields beyond what's already mapped into the rest
+	// of TransactionRow.
+	Source map[string]interface{}
 }
 
 func ProcessJob(job *worker.Job, db *sqlx.DB, w *worker.Worker) error {
 	startTime := time.Now()
-	log.Printf("Starting CSV processing: batch_id=%s", job.BatchID)
+	log.Printf("Starting statement processing: batch_id=%s", job.BatchID)
 
-	// Check if file content is available in database (preferred for Render multi-instance)
-	if len(job.FileContent) == 0 {
-		return fmt.Errorf("file content not found in database for batch %s", job.BatchID)
+	fileContent, err := os.ReadFile(job.FilePath)
+	if err != nil {
+		return worker.NewPermanentJobError(fmt.Errorf("failed to read statement file %s: %w", job.FilePath, err))
 	}
 
+	w.PublishEvent(events.Event{BatchID: job.BatchID, Stage: events.StageLoadingInvoices})
+
 	// Load invoice cache
 	cacheStart := time.Now()
 	cache, err := LoadInvoiceCache(db)
@@ -48,121 +115,159 @@ func ProcessJob(job *worker.Job, db *sqlx.DB, w *worker.Worker) error {
 	}
 	log.Printf("Loaded %d invoices into cache (took %v)", len(cache.ByID), time.Since(cacheStart))
 
+	// A prior run of this batch may have crashed mid-file; resume from its
+	// last committed checkpoint instead of reprocessing rows already in
+	// bank_transactions.
+	checkpoint, err := w.LoadBatchCheckpoint(job.BatchID)
+	if err != nil {
+		return fmt.Errorf("failed to load batch checkpoint: %w", err)
+	}
+
 	// Create processor
 	processor := &Processor{
-		DB:            db,
-		Worker:        w,
-		BatchID:       job.BatchID,
-		InvoiceCache:  cache,
-		BatchSize:     500,
-		ProgressEvery: 200,
-		MatchedInvoices: make(map[string]bool),
+		DB:              db,
+		Worker:          w,
+		BatchID:         job.BatchID,
+		JobID:           job.ID,
+		InvoiceCache:    cache,
+		BatchSize:       defaultBatchSize,
+		ProgressEvery:   200,
+		MatchedInvoices: make(map[string]float64),
+		CreatedAt:       job.CreatedAt,
+		StartedAt:       startTime,
+		BaseCurrency:    "USD",
+	}
+	processor.BulkWriter = selectBulkWriter(db, processor.BatchSize)
+	if _, usingCopy := processor.BulkWriter.(*copyWriter); usingCopy {
+		processor.BatchSize = copyBatchSize
+	}
+	if weights, err := learning.LoadLatestWeights(db); err == nil {
+		scoring := DefaultScoringConfig().WithLearnedWeights(*weights)
+		processor.Scoring = &scoring
+		log.Printf("Loaded recalibrated scoring weights version %d (trained on %d examples)",
+			weights.Version, weights.TrainingExamples)
+	}
+	if checkpoint != nil {
+		processor.ResumeOffset = checkpoint.RowOffset
+		processor.LastCheckpointAt = checkpoint.UpdatedAt
+		log.Printf("Resuming batch %s from checkpoint: row_offset=%d, last_checkpoint_at=%v",
+			job.BatchID, checkpoint.RowOffset, checkpoint.UpdatedAt)
 	}
 
-	// Process CSV from database content
-	err = processor.processCSVFromContent(job.FileContent)
+	// Process the statement read from disk above
+	err = processor.processStatementFromContent(fileContent, job.Format)
 	if err != nil {
-		return fmt.Errorf("CSV processing failed: %w", err)
+		return fmt.Errorf("statement processing failed: %w", err)
 	}
+	processor.FinishedAt = time.Now()
 
 	duration := time.Since(startTime)
-	log.Printf("CSV processing completed: batch_id=%s, duration=%v", job.BatchID, duration)
+	log.Printf("Statement processing completed: batch_id=%s, duration=%v", job.BatchID, duration)
 	return nil
 }
 
-func (p *Processor) processCSVFromContent(fileContent []byte) error {
-	reader := csv.NewReader(strings.NewReader(string(fileContent)))
-	
-	// Read header
-	header, err := reader.Read()
+func (p *Processor) processStatementFromContent(fileContent []byte, format string) error {
+	reader, err := NewStatementReader(format, fileContent, p.BaseCurrency)
 	if err != nil {
-		return fmt.Errorf("failed to read header: %w", err)
-	}
-
-	// Map column names to indices
-	colMap := make(map[string]int)
-	for i, col := range header {
-		colMap[strings.ToLower(strings.TrimSpace(col))] = i
-	}
-
-	// Validate required columns
-	required := []string{"transaction_date", "description", "amount"}
-	for _, req := range required {
-		if _, exists := colMap[req]; !exists {
-			return fmt.Errorf("missing required column: %s", req)
-		}
+		// Bad format name or malformed header/column schema - re-running the
+		// same file will fail the same way, so don't waste retry attempts.
+		return worker.NewPermanentJobError(fmt.Errorf("failed to create statement reader: %w", err))
 	}
+	log.Printf("Reading batch %s as %s", p.BatchID, reader.Header().Format)
+	p.Worker.PublishEvent(events.Event{BatchID: p.BatchID, Stage: events.StageMatching})
 
 	// Counters
 	var processedCount, autoMatchedCount, needsReviewCount, unmatchedCount int
+	var autoMatchedTotal, needsReviewTotal, unmatchedTotal float64
 	var invalidRows int
 
 	// Batch accumulator
 	batch := make([]TransactionRow, 0, p.BatchSize)
 	batchMatches := make([]MatchResult, 0, p.BatchSize)
+	batchPayments := make([][]invoicePayment, 0, p.BatchSize)
 
 	// Process rows
 	rowNum := 0
-	for {
-		record, err := reader.Read()
-		if err == io.EOF {
-			break
+
+	// Resuming: skip forward past rows a prior, crashed run already
+	// committed, rather than re-matching/re-inserting them. The skip count
+	// mirrors exactly how rowNum advances below (one per raw row read,
+	// including ones that later fail to parse), so it lands on the same
+	// file position the checkpoint was saved at.
+	if p.ResumeOffset > 0 {
+		skipped := 0
+		for skipped < p.ResumeOffset {
+			if _, err := reader.Next(); err != nil {
+				if err == io.EOF {
+					break
+				}
+				return fmt.Errorf("failed to skip to checkpoint offset %d: %w", p.ResumeOffset, err)
+			}
+			skipped++
+			rowNum++
 		}
+
+		committed, err := p.loadCommittedCounters()
 		if err != nil {
-			log.Printf("Error reading row %d: %v", rowNum+1, err)
-			invalidRows++
-			continue
+			return fmt.Errorf("failed to load committed counters for resume: %w", err)
 		}
+		processedCount = committed.Processed
+		autoMatchedCount = committed.AutoMatched
+		needsReviewCount = committed.NeedsReview
+		unmatchedCount = committed.Unmatched
+		autoMatchedTotal = committed.AutoMatchedTotal
+		needsReviewTotal = committed.NeedsReviewTotal
+		unmatchedTotal = committed.UnmatchedTotal
+
+		log.Printf("Resumed batch %s: skipped %d already-read row(s), %d already committed",
+			p.BatchID, skipped, processedCount)
+	}
 
+	for {
+		row, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
 		rowNum++
-
-		// Parse row
-		row, parseErr := p.parseRow(record, colMap)
-		if parseErr != nil {
-			log.Printf("Invalid row %d: %v", rowNum, parseErr)
+		if err != nil {
+			log.Printf("Invalid row %d: %v", rowNum, err)
 			invalidRows++
 			continue
 		}
 
-		// Match transaction
-		candidates := p.InvoiceCache.ByAmount[row.Amount]
-		
-		// Filter out already-matched invoices
-		filteredCandidates := make([]*InvoiceCandidate, 0, len(candidates))
-		for _, cand := range candidates {
-			if !p.MatchedInvoices[cand.ID] {
-				filteredCandidates = append(filteredCandidates, cand)
-			}
-		}
-		
-		match := MatchTransaction(row.Description, row.Amount, row.TransactionDate, filteredCandidates)
-		
-		// Mark invoice as matched if auto_matched or needs_review
-		if match.InvoiceID != nil && (match.Status == "auto_matched" || match.Status == "needs_review") {
-			p.MatchedInvoices[*match.InvoiceID] = true
-		}
+		match, payments := p.matchRow(row)
 
 		// Accumulate for batch insert
 		batch = append(batch, row)
 		batchMatches = append(batchMatches, match)
+		batchPayments = append(batchPayments, payments)
 
 		// Flush batch when full
 		if len(batch) >= p.BatchSize {
-			err := p.flushBatch(batch, batchMatches, &processedCount, &autoMatchedCount, &needsReviewCount, &unmatchedCount)
+			err := p.flushBatch(batch, batchMatches, batchPayments, &processedCount, &autoMatchedCount, &needsReviewCount, &unmatchedCount, &autoMatchedTotal, &needsReviewTotal, &unmatchedTotal)
 			if err != nil {
 				return err
 			}
 			batch = batch[:0]
 			batchMatches = batchMatches[:0]
+			batchPayments = batchPayments[:0]
+
+			if err := p.checkpoint(rowNum); err != nil {
+				log.Printf("Warning: Failed to save batch checkpoint: %v", err)
+			}
 		}
 	}
 
 	// Flush remaining rows
 	if len(batch) > 0 {
-		err := p.flushBatch(batch, batchMatches, &processedCount, &autoMatchedCount, &needsReviewCount, &unmatchedCount)
+		err := p.flushBatch(batch, batchMatches, batchPayments, &processedCount, &autoMatchedCount, &needsReviewCount, &unmatchedCount, &autoMatchedTotal, &needsReviewTotal, &unmatchedTotal)
 		if err != nil {
 			return err
 		}
+
+		if err := p.checkpoint(rowNum); err != nil {
+			log.Printf("Warning: Failed to save batch checkpoint: %v", err)
+		}
 	}
 
 	// Finalize
@@ -176,7 +281,15 @@ func (p *Processor) processCSVFromContent(fileContent []byte) error {
 	}
 
 	// Final count update to ensure accuracy
-	err = p.Worker.UpdateBatchProgress(p.BatchID, processedCount, autoMatchedCount, needsReviewCount, unmatchedCount)
+	err = p.Worker.UpdateBatchProgress(p.BatchID, worker.BatchProgress{
+		Processed:        processedCount,
+		AutoMatched:      autoMatchedCount,
+		NeedsReview:      needsReviewCount,
+		Unmatched:        unmatchedCount,
+		AutoMatchedTotal: autoMatchedTotal,
+		NeedsReviewTotal: needsReviewTotal,
+		UnmatchedTotal:   unmatchedTotal,
+	})
 	if err != nil {
 		log.Printf("Warning: Failed to update final batch counts: %v", err)
 	}
@@ -184,53 +297,301 @@ func (p *Processor) processCSVFromContent(fileContent []byte) error {
 	return nil
 }
 
-func (p *Processor) parseRow(record []string, colMap map[string]int) (TransactionRow, error) {
-	var row TransactionRow
+// checkpoint saves the current statement row offset to batch_checkpoints and
+// bumps p.LastCheckpointAt, so a worker that crashes right after this point
+// resumes just past rowNum instead of from the start of the file.
+func (p *Processor) checkpoint(rowNum int) error {
+	if err := p.Worker.SaveBatchCheckpoint(p.BatchID, rowNum); err != nil {
+		return err
+	}
+	p.LastCheckpointAt = time.Now()
+	return nil
+}
+
+// committedCounters is the subset of BatchProgress recomputable from rows
+// already durably committed to bank_transactions, used to re-seed a
+// resumed run's in-memory counters instead of persisting them a second time
+// in batch_checkpoints.
+type committedCounters struct {
+	Processed        int
+	AutoMatched      int
+	NeedsReview      int
+	Unmatched        int
+	AutoMatchedTotal float64
+	NeedsReviewTotal float64
+	UnmatchedTotal   float64
+}
+
+// loadCommittedCounters recomputes batch counters from bank_transactions,
+// mirroring the status-bucketed SUM/COUNT the batch status handler already
+// runs to compute dollar totals, so resuming a batch never has to trust a
+// separately-persisted running total that could drift from the rows it
+// describes.
+func (p *Processor) loadCommittedCounters() (committedCounters, error) {
+	var row struct {
+		Processed        int     `db:"processed"`
+		AutoMatched      int     `db:"auto_matched"`
+		NeedsReview      int     `db:"needs_review"`
+		Unmatched        int     `db:"unmatched"`
+		AutoMatchedTotal float64 `db:"auto_matched_total"`
+		NeedsReviewTotal float64 `db:"needs_review_total"`
+		UnmatchedTotal   float64 `db:"unmatched_total"`
+	}
+
+	err := p.DB.Get(&row, `
+		SELECT
+			COUNT(*) AS processed,
+			COUNT(CASE WHEN status = 'auto_matched' THEN 1 END) AS auto_matched,
+			COUNT(CASE WHEN status = 'needs_review' THEN 1 END) AS needs_review,
+			COUNT(CASE WHEN status = 'unmatched' THEN 1 END) AS unmatched,
+			COALESCE(SUM(CASE WHEN status = 'auto_matched' THEN amount ELSE 0 END), 0) AS auto_matched_total,
+			COALESCE(SUM(CASE WHEN status = 'needs_review' THEN amount ELSE 0 END), 0) AS needs_review_total,
+			COALESCE(SUM(CASE WHEN status = 'unmatched' THEN amount ELSE 0 END), 0) AS unmatched_total
+		FROM bank_transactions
+		WHERE upload_batch_id = $1
+	`, p.BatchID)
+	if err != nil {
+		return committedCounters{}, err
+	}
+
+	return committedCounters{
+		Processed:        row.Processed,
+		AutoMatched:      row.AutoMatched,
+		NeedsReview:      row.NeedsReview,
+		Unmatched:        row.Unmatched,
+		AutoMatchedTotal: row.AutoMatchedTotal,
+		NeedsReviewTotal: row.NeedsReviewTotal,
+		UnmatchedTotal:   row.UnmatchedTotal,
+	}, nil
+}
+
+// invoicePayment is one row flushBatch will write to invoice_payments: the
+// amount a single bank transaction applied to a single invoice, and that
+// invoice's remaining balance immediately afterward. A transaction matched
+// to one invoice produces exactly one of these; a split payment (see
+// MatchResult.SplitPayment) produces one per invoice in the split.
+type invoicePayment struct {
+	InvoiceID        string
+	Applied          float64
+	RemainingBalance float64
+}
 
-	// Parse date
-	dateIdx, exists := colMap["transaction_date"]
-	if !exists || dateIdx >= len(record) {
-		return row, fmt.Errorf("missing transaction_date")
+// owedAmount is what's actually owed on an invoice before any payments are
+// applied: the VAT-inclusive gross total, falling back to Amount for
+// invoices without separate net/gross tracking.
+func owedAmount(cand *InvoiceCandidate) float64 {
+	amt := cand.GrossAmount
+	if amt == "" {
+		amt = cand.Amount
 	}
-	date, err := time.Parse("2006-01-02", record[dateIdx])
+	parsed, err := strconv.ParseFloat(amt, 64)
 	if err != nil {
-		return row, fmt.Errorf("invalid date format: %w", err)
+		return 0
+	}
+	return parsed
+}
+
+// remainingBalance is what's still owed on invoiceID: its tracked balance in
+// MatchedInvoices if a payment has already been applied this run, otherwise
+// its full owed amount.
+func (p *Processor) remainingBalance(invoiceID string) float64 {
+	if balance, ok := p.MatchedInvoices[invoiceID]; ok {
+		return balance
+	}
+	cand, ok := p.InvoiceCache.ByID[invoiceID]
+	if !ok {
+		return 0
+	}
+	return owedAmount(cand)
+}
+
+// applyPayment records that applied has just been paid toward invoiceID,
+// reducing its remaining balance (floored at 0 - overpayment isn't expected
+// given candidates are pre-filtered by amount, but a future fuzzy-amount
+// match shouldn't be able to drive a balance negative).
+func (p *Processor) applyPayment(invoiceID string, applied float64) {
+	remaining := p.remainingBalance(invoiceID) - applied
+	if remaining < 0 {
+		remaining = 0
+	}
+	p.MatchedInvoices[invoiceID] = remaining
+}
+
+// filterOutstanding drops any candidate whose remaining balance has already
+// reached zero, so a fully-paid invoice stops being offered as a match
+// target even though it's still present in InvoiceCache for the life of the
+// run.
+func (p *Processor) filterOutstanding(candidates []*InvoiceCandidate) []*InvoiceCandidate {
+	filtered := make([]*InvoiceCandidate, 0, len(candidates))
+	for _, cand := range candidates {
+		if p.remainingBalance(cand.ID) > 0 {
+			filtered = append(filtered, cand)
+		}
+	}
+	return filtered
+}
+
+// allOutstandingCandidates returns every invoice in the cache, sorted
+// deterministically (by due date, then ID, mirroring LoadInvoiceCache's own
+// ordering) so that FindSplitPaymentMatch's truncation to
+// maxSplitPaymentCandidates considers the same invoices on every run rather
+// than whichever ones Go's randomized map iteration happens to yield first.
+func (p *Processor) allOutstandingCandidates() []*InvoiceCandidate {
+	all := make([]*InvoiceCandidate, 0, len(p.InvoiceCache.ByID))
+	for _, cand := range p.InvoiceCache.ByID {
+		all = append(all, cand)
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		if !all[i].DueDate.Equal(all[j].DueDate) {
+			return all[i].DueDate.Before(all[j].DueDate)
+		}
+		return all[i].ID < all[j].ID
+	})
+	return all
+}
+
+// matchRow matches a single parsed row against the invoice cache and applies
+// the result to p's in-memory balances, returning the MatchResult and
+// whichever invoice_payments rows it produced. Factored out of
+// processStatementFromContent so the conformance test harness (testvectors/)
+// can drive the exact same matching/balance-tracking path a live batch run
+// uses, without needing flushBatch's Postgres writes.
+func (p *Processor) matchRow(row TransactionRow) (MatchResult, []invoicePayment) {
+	// The exact-amount bucket covers the common single-invoice case
+	// directly; when it comes up empty (e.g. this transaction is a
+	// consolidated payment covering several invoices, none of which alone
+	// equals this amount, or a cross-currency payment whose raw amount
+	// never matches a same-currency invoice total), fall back to every
+	// outstanding invoice so MatchTransaction's split-payment search
+	// (FindSplitPaymentMatch) and FX conversion have a pool to search.
+	// Installments - an invoice paid down by amounts smaller than its own
+	// total - aren't found through either lookup today, since both
+	// key/filter by an invoice's full owed amount rather than its
+	// remaining balance; the remaining-balance bookkeeping below exists so
+	// a future matching pass could close that gap without a data model
+	// change.
+	candidates := p.filterOutstanding(p.InvoiceCache.ByAmount[row.Amount])
+	if len(candidates) == 0 {
+		candidates = p.filterOutstanding(p.allOutstandingCandidates())
+	}
+
+	match := MatchTransaction(row.Description, row.Amount, row.TransactionDate, candidates, MatchOptions{
+		TransactionCurrency: row.Currency,
+		FX:                  p.FXProvider,
+		Scoring:             p.Scoring,
+	})
+
+	// A non-CSV StatementReader's raw native fields ride along for audit,
+	// under the same match_details blob flushBatch already persists.
+	if len(row.Source) > 0 {
+		if match.MatchDetails == nil {
+			match.MatchDetails = make(map[string]interface{})
+		}
+		match.MatchDetails["source"] = row.Source
+	}
+
+	// Apply this transaction against whichever invoice(s) it matched,
+	// paying down their remaining balances, and record the ledger entries
+	// flushBatch will write to invoice_payments.
+	var payments []invoicePayment
+	switch {
+	case match.SplitPayment != nil:
+		for _, alloc := range match.SplitPayment.Allocations {
+			p.applyPayment(alloc.InvoiceID, alloc.Applied)
+			payments = append(payments, invoicePayment{
+				InvoiceID:        alloc.InvoiceID,
+				Applied:          alloc.Applied,
+				RemainingBalance: p.remainingBalance(alloc.InvoiceID),
+			})
+		}
+	case match.InvoiceID != nil && (match.Status == "auto_matched" || match.Status == "needs_review"):
+		if appliedAmount, parseErr := strconv.ParseFloat(row.Amount, 64); parseErr == nil {
+			p.applyPayment(*match.InvoiceID, appliedAmount)
+			payments = append(payments, invoicePayment{
+				InvoiceID:        *match.InvoiceID,
+				Applied:          appliedAmount,
+				RemainingBalance: p.remainingBalance(*match.InvoiceID),
+			})
+		}
+	}
+
+	return match, payments
+}
+
+// recordMatchFeedback extracts the scored features behind match's
+// finalScore out of its MatchDetails (built by buildMatchDetails, so the
+// keys below always exist) and logs them to match_feedback via
+// learning.RecordScored, so a later RecalibrateWeights run can train on
+// whatever decision a reviewer eventually makes about it.
+func (p *Processor) recordMatchFeedback(transactionID string, match MatchResult) error {
+	// Split-payment results (match.SplitPayment != nil) use a different,
+	// smaller MatchDetails shape with no per-feature breakdown to train
+	// on - nothing to log.
+	if _, ok := match.MatchDetails["finalScore"]; !ok {
+		return nil
 	}
-	row.TransactionDate = date
 
-	// Parse description
-	descIdx, exists := colMap["description"]
-	if !exists || descIdx >= len(record) {
-		return row, fmt.Errorf("missing description")
+	nameDetails, _ := match.MatchDetails["name"].(map[string]interface{})
+	dateDetails, _ := match.MatchDetails["date"].(map[string]interface{})
+	ambiguityDetails, _ := match.MatchDetails["ambiguity"].(map[string]interface{})
+
+	nameScore, _ := nameDetails["similarity"].(float64)
+	dateAdjustment, _ := dateDetails["adjustment"].(float64)
+	ambiguityPenalty, _ := ambiguityDetails["penalty"].(float64)
+	finalScore, _ := match.MatchDetails["finalScore"].(float64)
+	version, _ := match.MatchDetails["version"].(string)
+
+	return learning.RecordScored(p.DB, learning.ScoredInput{
+		TransactionID:    transactionID,
+		InvoiceID:        match.InvoiceID,
+		NameScore:        nameScore,
+		DateAdjustment:   dateAdjustment,
+		AmbiguityPenalty: ambiguityPenalty,
+		FinalScore:       finalScore,
+		ScoringVersion:   version,
+	})
+}
+
+// allocateLineItems splits a single-invoice match's transaction amount
+// across matched.InvoiceID's line items (proportional to each item's share
+// of the invoice total - see AllocateProportionally) and persists the
+// result to match_allocation, so GET /reports/reconciliation-summary can
+// aggregate matched amounts per cost centre and VAT rate. A no-op for
+// unmatched results, split payments (already spread across several invoices
+// by a different mechanism - invoice_payments), and invoices with no line
+// items recorded.
+func (p *Processor) allocateLineItems(transactionID, amount string, match MatchResult) error {
+	if match.InvoiceID == nil || match.SplitPayment != nil {
+		return nil
 	}
-	row.Description = record[descIdx]
 
-	// Parse amount
-	amountIdx, exists := colMap["amount"]
-	if !exists || amountIdx >= len(record) {
-		return row, fmt.Errorf("missing amount")
+	cand, ok := p.InvoiceCache.ByID[*match.InvoiceID]
+	if !ok {
+		return nil
 	}
-	row.Amount = record[amountIdx]
-	
-	// Validate amount is numeric
-	_, err = strconv.ParseFloat(row.Amount, 64)
+
+	items, err := LoadLineItems(p.DB, *match.InvoiceID)
 	if err != nil {
-		return row, fmt.Errorf("invalid amount: %w", err)
+		return fmt.Errorf("failed to load line items for invoice %s: %w", *match.InvoiceID, err)
+	}
+	if len(items) == 0 {
+		return nil
 	}
 
-	// Parse reference_number (optional)
-	if refIdx, exists := colMap["reference_number"]; exists && refIdx < len(record) && record[refIdx] != "" {
-		ref := record[refIdx]
-		row.ReferenceNumber = &ref
+	allocations, err := AllocateProportionally(amount, items, cand.CurrencyPrecision)
+	if err != nil {
+		return fmt.Errorf("failed to allocate invoice %s: %w", *match.InvoiceID, err)
 	}
 
-	return row, nil
+	return PersistAllocations(p.DB, *match.InvoiceID, transactionID, allocations)
 }
 
 func (p *Processor) flushBatch(
 	rows []TransactionRow,
 	matches []MatchResult,
+	payments [][]invoicePayment,
 	processedCount, autoMatchedCount, needsReviewCount, unmatchedCount *int,
+	autoMatchedTotal, needsReviewTotal, unmatchedTotal *float64,
 ) error {
 	if len(rows) == 0 {
 		return nil
@@ -238,98 +599,131 @@ func (p *Processor) flushBatch(
 
 	startTime := time.Now()
 
-	// Build insert query (multi-row insert)
-	query := `
-		INSERT INTO bank_transactions (
-			upload_batch_id, transaction_date, description, amount, reference_number,
-			status, matched_invoice_id, confidence_score, match_details
-		) VALUES `
-	
-	args := make([]interface{}, 0, len(rows)*9)
-	placeholders := make([]string, 0, len(rows))
-	
-	for i, row := range rows {
-		match := matches[i]
-		
-		var invoiceID interface{}
-		if match.InvoiceID != nil {
-			invoiceID = *match.InvoiceID
-		}
-		
-		var confidence interface{}
-		if match.Status != "unmatched" {
-			confidence = match.Confidence
+	// Each transaction's id is generated here rather than left to a DB-side
+	// default, so it's known up front and can be used below to link
+	// invoice_payments.transaction_id without depending on RETURNING
+	// preserving row order.
+	transactionIDs := make([]string, len(rows))
+	for i := range rows {
+		transactionIDs[i] = uuid.New().String()
+	}
+
+	if err := p.bulkWriter().WriteBatch(p.BatchID, rows, matches, transactionIDs); err != nil {
+		return fmt.Errorf("failed to insert batch: %w", err)
+	}
+
+	// Link every applied payment (one invoice matched normally, or several
+	// for a split payment) to the transaction that paid it. This is
+	// deliberately a separate statement/transaction from the
+	// bank_transactions write above: copyWriter streams rows via COPY on
+	// its own connection, outside any *sql.Tx, so the two tables can no
+	// longer share one atomic commit. A crash between them leaves
+	// bank_transactions rows with no matching invoice_payments yet, which
+	// is recoverable the same way a stale/crashed job already is -
+	// reprocessed from the last batch checkpoint.
+	paymentPlaceholders := make([]string, 0, len(rows))
+	paymentArgs := make([]interface{}, 0, len(rows)*4)
+	for i, rowPayments := range payments {
+		for _, pmt := range rowPayments {
+			n := len(paymentArgs)
+			paymentPlaceholders = append(paymentPlaceholders, fmt.Sprintf("($%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4))
+			paymentArgs = append(paymentArgs, pmt.InvoiceID, transactionIDs[i], pmt.Applied, pmt.RemainingBalance)
 		}
-		
-		// Convert match_details to JSONB-compatible format
-		var matchDetailsJSON interface{}
-		if match.MatchDetails != nil {
-			// Marshal map to JSON bytes for PostgreSQL JSONB
-			jsonBytes, err := json.Marshal(match.MatchDetails)
-			if err != nil {
-				log.Printf("Failed to marshal match_details: %v", err)
-				matchDetailsJSON = "{}"
-			} else {
-				matchDetailsJSON = string(jsonBytes)
-			}
-		} else {
-			matchDetailsJSON = "{}"
+	}
+	if len(paymentPlaceholders) > 0 {
+		paymentsQuery := `
+			INSERT INTO invoice_payments (
+				invoice_id, transaction_id, applied_amount, remaining_balance
+			) VALUES ` + strings.Join(paymentPlaceholders, ", ")
+
+		if _, err := p.DB.Exec(paymentsQuery, paymentArgs...); err != nil {
+			return fmt.Errorf("failed to insert invoice payments: %w", err)
 		}
-		
-		// Cast match_details to JSONB in SQL
-		placeholders = append(placeholders, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d::jsonb)",
-			i*9+1, i*9+2, i*9+3, i*9+4, i*9+5, i*9+6, i*9+7, i*9+8, i*9+9))
-		
-		args = append(args,
-			p.BatchID,
-			row.TransactionDate,
-			row.Description,
-			row.Amount,
-			row.ReferenceNumber,
-			match.Status,
-			invoiceID,
-			confidence,
-			matchDetailsJSON,
-		)
-	}
-
-	fullQuery := query + strings.Join(placeholders, ", ")
-
-	// Execute in transaction
-	tx, err := p.DB.Beginx()
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	defer tx.Rollback()
 
-	_, err = tx.Exec(fullQuery, args...)
-	if err != nil {
-		return fmt.Errorf("failed to insert batch: %w", err)
+	// Log the scored features behind every needs_review match for later
+	// recalibration (see learning.RecalibrateWeights) - auto_matched and
+	// unmatched results never reach a human reviewer, so they can never
+	// become a labeled training example and aren't logged here.
+	for i, match := range matches {
+		if match.Status != "needs_review" {
+			continue
+		}
+		if err := p.recordMatchFeedback(transactionIDs[i], match); err != nil {
+			log.Printf("Warning: Failed to record match feedback: %v", err)
+		}
 	}
 
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+	// Proportionally allocate every single-invoice match across its line
+	// items for VAT/cost-centre reporting - see allocateLineItems.
+	for i, match := range matches {
+		if err := p.allocateLineItems(transactionIDs[i], rows[i].Amount, match); err != nil {
+			log.Printf("Warning: Failed to allocate line items: %v", err)
+		}
 	}
 
 	// Update counters after successful commit
 	*processedCount += len(rows)
-	for _, match := range matches {
+	for i, match := range matches {
+		amount, _ := strconv.ParseFloat(rows[i].Amount, 64) // already validated numeric by the StatementReader
 		switch match.Status {
 		case "auto_matched":
 			*autoMatchedCount++
+			*autoMatchedTotal += amount
 		case "needs_review":
 			*needsReviewCount++
+			*needsReviewTotal += amount
 		case "unmatched":
 			*unmatchedCount++
+			*unmatchedTotal += amount
+		}
+
+		switch match.Status {
+		case "auto_matched":
+			p.Worker.Webhooks.Emit(webhooks.EventTransactionAutoMatched, map[string]interface{}{
+				"transactionId": transactionIDs[i],
+				"batchId":       p.BatchID,
+				"invoiceId":     match.InvoiceID,
+			})
+		case "needs_review":
+			p.Worker.Webhooks.Emit(webhooks.EventTransactionNeedsReview, map[string]interface{}{
+				"transactionId": transactionIDs[i],
+				"batchId":       p.BatchID,
+				"invoiceId":     match.InvoiceID,
+			})
 		}
 	}
 
 	// Update progress
-	err = p.Worker.UpdateBatchProgress(p.BatchID, *processedCount, *autoMatchedCount, *needsReviewCount, *unmatchedCount)
+	err := p.Worker.UpdateBatchProgress(p.BatchID, worker.BatchProgress{
+		Processed:        *processedCount,
+		AutoMatched:      *autoMatchedCount,
+		NeedsReview:      *needsReviewCount,
+		Unmatched:        *unmatchedCount,
+		AutoMatchedTotal: *autoMatchedTotal,
+		NeedsReviewTotal: *needsReviewTotal,
+		UnmatchedTotal:   *unmatchedTotal,
+	})
 	if err != nil {
 		log.Printf("Warning: Failed to update progress: %v", err)
 	}
 
+	// Prove liveness alongside the progress update, so a job stuck between
+	// flushes (stalled on a slow query, wedged in a deadlock) gets reclaimed
+	// by recoverStaleJobs even though updated_at on its own wouldn't move.
+	if err := p.Worker.Heartbeat(p.JobID); err != nil {
+		log.Printf("Warning: Failed to record job heartbeat: %v", err)
+	}
+
+	p.Worker.PublishEvent(events.Event{
+		BatchID:     p.BatchID,
+		Stage:       events.StagePersisting,
+		Processed:   *processedCount,
+		AutoMatched: *autoMatchedCount,
+		NeedsReview: *needsReviewCount,
+		Unmatched:   *unmatchedCount,
+	})
+
 	duration := time.Since(startTime)
 	log.Printf("Flushed batch: %d rows in %v (%.0f rows/sec)", len(rows), duration, float64(len(rows))/duration.Seconds())
 
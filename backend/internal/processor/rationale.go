@@ -0,0 +1,120 @@
+package processor
+
+import "fmt"
+
+// RationaleStep records one factor MatchTransaction folded into a
+// candidate's final score - in the order the scoring formula applies them -
+// so a caller can render a natural-language trail ("matched name at 96,
+// +3 for an on-time date, no ambiguity penalty") instead of only seeing the
+// final number buildMatchDetails captured before chunk3-5.
+type RationaleStep struct {
+	Name     string  `json:"name"`
+	Before   float64 `json:"before"`
+	After    float64 `json:"after"`
+	Delta    float64 `json:"delta"`
+	Evidence string  `json:"evidence"`
+}
+
+// RationaleBuilder accumulates RationaleStep values as each factor is
+// applied to a running score, so Record's caller only has to supply the
+// delta and evidence for its own step - Before/After follow automatically
+// from the steps recorded so far.
+type RationaleBuilder struct {
+	running float64
+	steps   []RationaleStep
+}
+
+// Record appends a step: delta is added to the running total, and evidence
+// is a short human-readable explanation of what produced that delta (e.g.
+// "extracted name \"S ADAMS\" matched \"SARAH ADAMS\" at 96.0 similarity").
+func (b *RationaleBuilder) Record(name string, delta float64, evidence string) {
+	before := b.running
+	b.running += delta
+	b.steps = append(b.steps, RationaleStep{
+		Name:     name,
+		Before:   before,
+		After:    b.running,
+		Delta:    delta,
+		Evidence: evidence,
+	})
+}
+
+// Steps returns every step recorded so far, in recording order.
+func (b *RationaleBuilder) Steps() []RationaleStep {
+	return b.steps
+}
+
+// buildRationale replays the winning candidate's scoring factors, in the
+// same order MatchTransaction's finalScore formula applies them, into a
+// RationaleBuilder - so MatchDetails["rationale"] explains a match the same
+// way it was actually scored, rather than re-deriving an approximation.
+func buildRationale(extractedName string, best scoredCandidate, candidateCount int, scoringCfg ScoringConfig) []RationaleStep {
+	var rb RationaleBuilder
+
+	rb.Record("name", best.nameScore*scoringCfg.WeightName,
+		fmt.Sprintf("extracted name %q matched %q at %.1f similarity", extractedName, best.candidate.NormalizedName, best.nameScore))
+
+	rb.Record("date", best.dateAdjustment*scoringCfg.WeightDate,
+		fmt.Sprintf("transaction posted %d day(s) relative to the due date", best.dateDelta))
+
+	if candidateCount > scoringCfg.Ambiguity.FreeCandidates {
+		rb.Record("ambiguity", -best.ambiguityPenalty*scoringCfg.WeightAmbiguity,
+			fmt.Sprintf("%d candidates in contention (%d free)", candidateCount, scoringCfg.Ambiguity.FreeCandidates))
+	} else {
+		rb.Record("ambiguity", 0, fmt.Sprintf("%d candidate(s), within the %d free before a penalty applies", candidateCount, scoringCfg.Ambiguity.FreeCandidates))
+	}
+
+	if best.amountDelta == 0 {
+		rb.Record("amount", 0, fmt.Sprintf("amount matched the invoice's %s total exactly", amountReasonOrDefault(best.amountMatchReason)))
+	} else {
+		rb.Record("amount", -best.amountPenalty,
+			fmt.Sprintf("amount drifted %.4f from the invoice's %s total, within tolerance", best.amountDelta, amountReasonOrDefault(best.amountMatchReason)))
+	}
+
+	return rb.Steps()
+}
+
+func amountReasonOrDefault(reason string) string {
+	if reason == "" {
+		return "net"
+	}
+	return reason
+}
+
+// explainWhyNot compares the winning candidate against each runner-up
+// (scored[1:], already sorted by buildMatchDetails's caller) and summarizes,
+// in one sentence per candidate, the single factor that cost it the most
+// points relative to the winner - e.g. "candidate INV-2001 lost 12.0
+// point(s) on name similarity".
+type WhyNotCandidate struct {
+	InvoiceID     string  `json:"invoiceId"`
+	InvoiceNumber string  `json:"invoiceNumber"`
+	Score         float64 `json:"score"`
+	Reason        string  `json:"reason"`
+}
+
+func explainWhyNot(best scoredCandidate, runnersUp []scoredCandidate) []WhyNotCandidate {
+	out := make([]WhyNotCandidate, 0, len(runnersUp))
+	for _, r := range runnersUp {
+		nameDelta := best.nameScore - r.nameScore
+		dateDelta := best.dateAdjustment - r.dateAdjustment
+		amountDelta := r.amountPenalty - best.amountPenalty
+
+		reason := "date proximity"
+		largest := dateDelta
+		if nameDelta > largest {
+			reason, largest = "name similarity", nameDelta
+		}
+		if amountDelta > largest {
+			reason, largest = "amount tolerance", amountDelta
+		}
+
+		out = append(out, WhyNotCandidate{
+			InvoiceID:     r.candidate.ID,
+			InvoiceNumber: r.candidate.InvoiceNumber,
+			Score:         r.finalScore,
+			Reason:        fmt.Sprintf("lost %.1f point(s) to the winner, mostly on %s", best.finalScore-r.finalScore, reason),
+		})
+	}
+	return out
+}
@@ -0,0 +1,76 @@
+package processor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMatchTransaction_RationaleExplainsWinningScore(t *testing.T) {
+	candidates := []*InvoiceCandidate{
+		{
+			ID:             "inv-1",
+			InvoiceNumber:  "INV-001",
+			Amount:         "450.00",
+			DueDate:        time.Date(2024, 12, 10, 0, 0, 0, 0, time.UTC),
+			CustomerName:   "John Smith",
+			NormalizedName: "JOHN SMITH",
+			Status:         "sent",
+		},
+	}
+
+	result := MatchTransaction("JOHN SMITH", "450.00", time.Date(2024, 12, 10, 0, 0, 0, 0, time.UTC), candidates)
+
+	steps, ok := result.MatchDetails["rationale"].([]RationaleStep)
+	if !ok {
+		t.Fatalf("expected rationale to be []RationaleStep, got %T", result.MatchDetails["rationale"])
+	}
+	if len(steps) == 0 {
+		t.Fatal("expected at least one rationale step")
+	}
+	if steps[0].Name != "name" {
+		t.Errorf("expected first step to be name scoring, got %s", steps[0].Name)
+	}
+	if steps[len(steps)-1].After != steps[len(steps)-1].Before+steps[len(steps)-1].Delta {
+		t.Error("expected the last step's After to equal Before+Delta")
+	}
+}
+
+func TestMatchTransaction_WhyNotExplainsRunnersUp(t *testing.T) {
+	dueDate := time.Date(2024, 12, 10, 0, 0, 0, 0, time.UTC)
+	candidates := []*InvoiceCandidate{
+		{
+			ID:             "inv-1",
+			InvoiceNumber:  "INV-001",
+			Amount:         "450.00",
+			DueDate:        dueDate,
+			CustomerName:   "John Smith",
+			NormalizedName: "JOHN SMITH",
+			Status:         "sent",
+		},
+		{
+			ID:             "inv-2",
+			InvoiceNumber:  "INV-002",
+			Amount:         "450.00",
+			DueDate:        dueDate,
+			CustomerName:   "Jane Doe",
+			NormalizedName: "JANE DOE",
+			Status:         "sent",
+		},
+	}
+
+	result := MatchTransaction("JOHN SMITH", "450.00", dueDate, candidates)
+
+	whyNot, ok := result.MatchDetails["whyNot"].([]WhyNotCandidate)
+	if !ok {
+		t.Fatalf("expected whyNot to be []WhyNotCandidate, got %T", result.MatchDetails["whyNot"])
+	}
+	if len(whyNot) != 1 {
+		t.Fatalf("expected exactly one runner-up, got %d", len(whyNot))
+	}
+	if whyNot[0].InvoiceID != "inv-2" {
+		t.Errorf("expected runner-up inv-2, got %s", whyNot[0].InvoiceID)
+	}
+	if whyNot[0].Reason == "" {
+		t.Error("expected a non-empty reason")
+	}
+}
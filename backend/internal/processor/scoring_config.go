@@ -0,0 +1,260 @@
+package processor
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"payment-reconciliation-engine/backend/internal/processor/learning"
+)
+
+// ScoringConfig holds every tunable threshold, weight, and curve
+// MatchTransaction uses to turn a name/date/ambiguity score into an
+// auto_matched/needs_review/unmatched decision. DefaultScoringConfig
+// reproduces the historical hard-coded behavior exactly; LoadScoringConfig
+// lets an operator override it from a JSON or YAML file so BRD tweaks (e.g.
+// "raise the auto-match bar to 97") don't require a code change and
+// redeploy.
+type ScoringConfig struct {
+	AutoMatchThreshold   float64 `json:"autoMatchThreshold" yaml:"autoMatchThreshold"`
+	NeedsReviewThreshold float64 `json:"needsReviewThreshold" yaml:"needsReviewThreshold"`
+
+	// Weights applied when combining each factor into the final score.
+	// Amount is still mostly a hard pre-filter (AmountTolerance excludes
+	// candidates outright, and amountPenalty is always subtracted
+	// unweighted for backward compatibility), but WeightAmount additionally
+	// scales a 0-100 amountScore derived from that same penalty into
+	// finalScore. The default 0 makes this a no-op, reproducing historical
+	// behavior until a config opts in.
+	WeightName      float64 `json:"weightName" yaml:"weightName"`
+	WeightAmount    float64 `json:"weightAmount" yaml:"weightAmount"`
+	WeightDate      float64 `json:"weightDate" yaml:"weightDate"`
+	WeightAmbiguity float64 `json:"weightAmbiguity" yaml:"weightAmbiguity"`
+
+	DateCurve DateCurveConfig `json:"dateCurve" yaml:"dateCurve"`
+	Ambiguity AmbiguityConfig `json:"ambiguity" yaml:"ambiguity"`
+
+	// Version tags every MatchResult this config produces via
+	// MatchDetails["version"], so a match scored under an older weight fit
+	// stays reproducible/explicable after a newer one is loaded. Defaults to
+	// "v1" (the historical hard-coded weights); RecalibrateWeights-derived
+	// configs use "learned-vN" - see ScoringConfig.WithLearnedWeights.
+	Version string `json:"version" yaml:"version"`
+
+	// AmountTolerance controls how far a transaction amount may drift from
+	// an invoice's net/gross total (partial payments, wire fees, FX
+	// rounding) and still be scored instead of excluded outright. The zero
+	// value requires an exact match, reproducing historical behavior.
+	AmountTolerance AmountToleranceConfig `json:"amountTolerance" yaml:"amountTolerance"`
+
+	// FX controls how much extra drift a cross-currency comparison is
+	// allowed, on top of AmountTolerance, to absorb FX-rate staleness or
+	// spread. The zero value adds no extra slack, so a converted amount is
+	// held to exactly the same tolerance as a same-currency one.
+	FX FXConfig `json:"fx" yaml:"fx"`
+
+	// TenantOverrides lets a specific tenant relax or tighten the two
+	// thresholds without shipping it a whole separate config file. ForTenant
+	// merges the non-nil fields of a matching override over the base config.
+	TenantOverrides map[string]ThresholdOverride `json:"tenantOverrides" yaml:"tenantOverrides"`
+}
+
+// ThresholdOverride replaces ScoringConfig's thresholds for one tenant.
+// Pointers distinguish "not set" from "explicitly set to zero".
+type ThresholdOverride struct {
+	AutoMatchThreshold   *float64 `json:"autoMatchThreshold" yaml:"autoMatchThreshold"`
+	NeedsReviewThreshold *float64 `json:"needsReviewThreshold" yaml:"needsReviewThreshold"`
+}
+
+// FXConfig controls cross-currency amount matching. SlippagePct widens the
+// relative tolerance (expressed as a percentage of the converted amount)
+// applied to a transaction whose currency differs from the invoice it's
+// being compared against, to absorb drift the FX conversion itself
+// introduces (rate staleness, bid/ask spread) beyond what AmountTolerance
+// already allows for same-currency amounts.
+type FXConfig struct {
+	SlippagePct float64 `json:"slippagePct" yaml:"slippagePct"`
+}
+
+// AmbiguityConfig controls the penalty applied when many candidates remain
+// in contention. FreeCandidates is how many incur no penalty at all (the
+// historical behavior: the first 3 are free).
+type AmbiguityConfig struct {
+	FreeCandidates int     `json:"freeCandidates" yaml:"freeCandidates"`
+	PerExtra       float64 `json:"perExtra" yaml:"perExtra"`
+}
+
+// Penalty returns the ambiguity penalty for a candidate set of the given
+// size, replacing the hard-coded "len(candidates) > 3" check.
+func (a AmbiguityConfig) Penalty(candidateCount int) float64 {
+	if candidateCount <= a.FreeCandidates {
+		return 0.0
+	}
+	return float64(candidateCount-a.FreeCandidates) * a.PerExtra
+}
+
+// DateCurveConfig describes how a transaction's distance from its
+// candidate's due date adjusts the final score. Type selects the shape:
+//
+//   - "piecewise" (the default) reproduces the original step function:
+//     an early-payment boost, an on-time window, a grace window, a stale
+//     window with no adjustment, and a flat late penalty beyond it.
+//   - "exponential" instead decays smoothly from MaxBoost at deltaDays=0
+//     toward zero as |deltaDays| grows, clamped to [MinPenalty, MaxBoost].
+type DateCurveConfig struct {
+	Type string `json:"type" yaml:"type"`
+
+	// Piecewise fields (Type == "piecewise").
+	EarlyBoost           float64 `json:"earlyBoost" yaml:"earlyBoost"`
+	OnTimeWindowDays     int     `json:"onTimeWindowDays" yaml:"onTimeWindowDays"`
+	OnTimeBoost          float64 `json:"onTimeBoost" yaml:"onTimeBoost"`
+	ReasonableWindowDays int     `json:"reasonableWindowDays" yaml:"reasonableWindowDays"`
+	ReasonableBoost      float64 `json:"reasonableBoost" yaml:"reasonableBoost"`
+	StaleWindowDays      int     `json:"staleWindowDays" yaml:"staleWindowDays"`
+	StaleBoost           float64 `json:"staleBoost" yaml:"staleBoost"`
+	LatePenalty          float64 `json:"latePenalty" yaml:"latePenalty"`
+
+	// Exponential fields (Type == "exponential").
+	MaxBoost     float64 `json:"maxBoost" yaml:"maxBoost"`
+	HalfLifeDays float64 `json:"halfLifeDays" yaml:"halfLifeDays"`
+	MinPenalty   float64 `json:"minPenalty" yaml:"minPenalty"`
+}
+
+// Adjustment returns the score adjustment for a transaction posted
+// daysDelta days after its candidate's due date (negative means early).
+func (d DateCurveConfig) Adjustment(daysDelta int) float64 {
+	if d.Type == "exponential" {
+		raw := d.MaxBoost * math.Exp(-math.Abs(float64(daysDelta))/d.HalfLifeDays)
+		return clampFloat(raw, d.MinPenalty, d.MaxBoost)
+	}
+
+	// Piecewise: the original hard-coded cutoffs, now configurable.
+	switch {
+	case daysDelta < 0:
+		return d.EarlyBoost
+	case daysDelta <= d.OnTimeWindowDays:
+		return d.OnTimeBoost
+	case daysDelta <= d.ReasonableWindowDays:
+		return d.ReasonableBoost
+	case daysDelta <= d.StaleWindowDays:
+		return d.StaleBoost
+	default:
+		return d.LatePenalty
+	}
+}
+
+func clampFloat(v, lo, hi float64) float64 {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+// DefaultScoringConfig reproduces the historical hard-coded behavior
+// (95/60 thresholds, +5/+3/+1/0/-5 piecewise date adjustment, -1 point per
+// candidate beyond the first 3) so existing callers see no change.
+func DefaultScoringConfig() ScoringConfig {
+	return ScoringConfig{
+		AutoMatchThreshold:   95.0,
+		NeedsReviewThreshold: 60.0,
+		WeightName:           1.0,
+		WeightAmount:         0.0,
+		WeightDate:           1.0,
+		WeightAmbiguity:      1.0,
+		Version:              "v1",
+		DateCurve: DateCurveConfig{
+			Type:                 "piecewise",
+			EarlyBoost:           5.0,
+			OnTimeWindowDays:     7,
+			OnTimeBoost:          3.0,
+			ReasonableWindowDays: 14,
+			ReasonableBoost:      1.0,
+			StaleWindowDays:      30,
+			StaleBoost:           0.0,
+			LatePenalty:          -5.0,
+			MaxBoost:             5.0,
+			HalfLifeDays:         10.0,
+			MinPenalty:           -5.0,
+		},
+		Ambiguity: AmbiguityConfig{
+			FreeCandidates: 3,
+			PerExtra:       1.0,
+		},
+	}
+}
+
+// ForTenant returns the effective config for tenantID: the base config with
+// any matching TenantOverrides fields applied on top. An empty tenantID or
+// one with no override returns c unchanged.
+func (c ScoringConfig) ForTenant(tenantID string) ScoringConfig {
+	override, ok := c.TenantOverrides[tenantID]
+	if !ok {
+		return c
+	}
+	effective := c
+	if override.AutoMatchThreshold != nil {
+		effective.AutoMatchThreshold = *override.AutoMatchThreshold
+	}
+	if override.NeedsReviewThreshold != nil {
+		effective.NeedsReviewThreshold = *override.NeedsReviewThreshold
+	}
+	return effective
+}
+
+// LoadScoringConfig reads a ScoringConfig from a JSON (.json) or YAML
+// (.yaml/.yml) file. Fields the file doesn't set keep DefaultScoringConfig's
+// values, so a tenant can ship a config that only overrides, say,
+// autoMatchThreshold.
+func LoadScoringConfig(path string) (*ScoringConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scoring config %s: %w", path, err)
+	}
+
+	cfg := DefaultScoringConfig()
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse YAML scoring config %s: %w", path, err)
+		}
+	case ".json":
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON scoring config %s: %w", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported scoring config extension %q (expected .json, .yaml, or .yml)", ext)
+	}
+
+	return &cfg, nil
+}
+
+// WithLearnedWeights returns a copy of c with the per-feature weights and
+// thresholds replaced by w - a fit produced by learning.RecalibrateWeights
+// from human review decisions - and Version set to "learned-vN" so every
+// MatchResult it subsequently produces is traceable back to that fit.
+// fitWeights trains w.NameWeight/DateWeight/AmbiguityWeight against
+// nameScore/dateAdjustment/ambiguityPenalty scaled down by
+// learning.NameScoreScale/DateAdjustmentScale/AmbiguityPenaltyScale (and
+// with ambiguityPenalty's sign flipped), while finalScore in matcher.go
+// multiplies weights against those same features unscaled; dividing back
+// out by the same factors here keeps the two in agreement.
+// Fields WithLearnedWeights doesn't touch (DateCurve, Ambiguity.FreeCandidates,
+// AmountTolerance, FX, TenantOverrides) keep c's values.
+func (c ScoringConfig) WithLearnedWeights(w learning.ScoringWeights) ScoringConfig {
+	effective := c
+	effective.WeightName = w.NameWeight / learning.NameScoreScale
+	effective.WeightDate = w.DateWeight / learning.DateAdjustmentScale
+	effective.WeightAmbiguity = w.AmbiguityWeight / learning.AmbiguityPenaltyScale
+	effective.AutoMatchThreshold = w.AutoThreshold
+	effective.NeedsReviewThreshold = w.ReviewThreshold
+	effective.Version = fmt.Sprintf("learned-v%d", w.Version)
+	return effective
+}
@@ -0,0 +1,118 @@
+package processor
+
+import (
+	"testing"
+
+	"payment-reconciliation-engine/backend/internal/processor/learning"
+)
+
+func TestDateCurveConfig_PiecewiseMatchesHistoricalCutoffs(t *testing.T) {
+	curve := DefaultScoringConfig().DateCurve
+
+	cases := []struct {
+		deltaDays int
+		want      float64
+	}{
+		{-3, 5.0},
+		{0, 3.0},
+		{7, 3.0},
+		{10, 1.0},
+		{20, 0.0},
+		{31, -5.0},
+	}
+	for _, c := range cases {
+		if got := curve.Adjustment(c.deltaDays); got != c.want {
+			t.Errorf("Adjustment(%d) = %.2f, want %.2f", c.deltaDays, got, c.want)
+		}
+	}
+}
+
+func TestDateCurveConfig_ExponentialDecaysAndClamps(t *testing.T) {
+	curve := DefaultScoringConfig().DateCurve
+	curve.Type = "exponential"
+
+	if got := curve.Adjustment(0); got != curve.MaxBoost {
+		t.Errorf("expected peak boost at deltaDays=0, got %.2f", got)
+	}
+
+	far := curve.Adjustment(1000)
+	if far < curve.MinPenalty || far > curve.MaxBoost {
+		t.Errorf("expected far-out adjustment clamped to [%.2f, %.2f], got %.2f", curve.MinPenalty, curve.MaxBoost, far)
+	}
+
+	near := curve.Adjustment(1)
+	if near <= far {
+		t.Errorf("expected adjustment to decay as deltaDays grows, got near=%.2f far=%.2f", near, far)
+	}
+}
+
+func TestAmbiguityConfig_Penalty(t *testing.T) {
+	a := DefaultScoringConfig().Ambiguity
+
+	if got := a.Penalty(3); got != 0 {
+		t.Errorf("expected no penalty at the free-candidate limit, got %.2f", got)
+	}
+	if got := a.Penalty(5); got != 2.0 {
+		t.Errorf("expected 2.0 penalty for 2 candidates over the limit, got %.2f", got)
+	}
+}
+
+func TestScoringConfig_ForTenantOverridesThresholds(t *testing.T) {
+	cfg := DefaultScoringConfig()
+	auto := 97.0
+	cfg.TenantOverrides = map[string]ThresholdOverride{
+		"tenant-a": {AutoMatchThreshold: &auto},
+	}
+
+	effective := cfg.ForTenant("tenant-a")
+	if effective.AutoMatchThreshold != 97.0 {
+		t.Errorf("expected overridden threshold 97.0, got %.2f", effective.AutoMatchThreshold)
+	}
+	if effective.NeedsReviewThreshold != cfg.NeedsReviewThreshold {
+		t.Error("expected NeedsReviewThreshold to pass through unchanged")
+	}
+
+	unchanged := cfg.ForTenant("no-such-tenant")
+	if unchanged.AutoMatchThreshold != cfg.AutoMatchThreshold {
+		t.Error("expected no override for an unknown tenant")
+	}
+}
+
+func TestScoringConfig_WithLearnedWeights_UndoesFittingScale(t *testing.T) {
+	// fitWeights trains against nameScore/100, dateAdjustment/10, and
+	// -ambiguityPenalty/10 (see learning.RecalibrateWeights), so a fitted
+	// weight is on a ~10-100x different scale than the unscaled
+	// nameScore/dateAdjustment/ambiguityPenalty finalScore actually
+	// multiplies it against. WithLearnedWeights must divide that scaling
+	// back out, or a real fit would blow finalScore wildly out of the
+	// range its thresholds are calibrated against.
+	w := learning.ScoringWeights{
+		Version:         3,
+		NameWeight:      50.0,
+		DateWeight:      5.0,
+		AmbiguityWeight: 20.0,
+		AutoThreshold:   90.0,
+		ReviewThreshold: 55.0,
+	}
+
+	effective := DefaultScoringConfig().WithLearnedWeights(w)
+
+	if got, want := effective.WeightName, w.NameWeight/learning.NameScoreScale; got != want {
+		t.Errorf("WeightName = %.4f, want %.4f", got, want)
+	}
+	if got, want := effective.WeightDate, w.DateWeight/learning.DateAdjustmentScale; got != want {
+		t.Errorf("WeightDate = %.4f, want %.4f", got, want)
+	}
+	if got, want := effective.WeightAmbiguity, w.AmbiguityWeight/learning.AmbiguityPenaltyScale; got != want {
+		t.Errorf("WeightAmbiguity = %.4f, want %.4f", got, want)
+	}
+	if effective.AutoMatchThreshold != w.AutoThreshold || effective.NeedsReviewThreshold != w.ReviewThreshold {
+		t.Error("expected thresholds to pass through unchanged")
+	}
+}
+
+func TestLoadScoringConfig_RejectsUnknownExtension(t *testing.T) {
+	if _, err := LoadScoringConfig("/tmp/does-not-exist.txt"); err == nil {
+		t.Error("expected an error for an unrecognized config extension")
+	}
+}
@@ -0,0 +1,136 @@
+package processor
+
+import (
+	"math/big"
+	"math/bits"
+	"sort"
+)
+
+// SplitPaymentAllocation is one invoice's share of a consolidated payment
+// found by FindSplitPaymentMatch: the amount applied to it, and what's left
+// owing on it afterward (always 0 today, since a subset-sum hit means the
+// invoice's full owed amount was part of the sum - see FindSplitPaymentMatch).
+type SplitPaymentAllocation struct {
+	InvoiceID        string
+	Applied          float64
+	RemainingBalance float64
+}
+
+// SplitPaymentMatch is the result of FindSplitPaymentMatch: a combination of
+// two or more invoice candidates whose owed amounts sum, within tolerance,
+// to a transaction amount no single candidate matched alone.
+type SplitPaymentMatch struct {
+	Allocations []SplitPaymentAllocation
+	// Delta is |sum(allocations) - transaction amount|, 0 for an exact sum.
+	Delta float64
+}
+
+// maxSplitPaymentCandidates bounds the subset-sum search so it stays
+// tractable: at k=6, meet-in-the-middle enumerates 2*2^3 = 16 partial sums
+// instead of 2^6 = 64 full ones, and the gap only widens from there. A
+// transaction genuinely covering more than 6 invoices at once is rare
+// enough that it's fine to leave for a human to pick apart in needs_review.
+const maxSplitPaymentCandidates = 6
+
+type subsetSum struct {
+	mask int
+	sum  *big.Rat
+}
+
+// FindSplitPaymentMatch searches candidates (expected to already be
+// filtered to a single customer/description cluster by the caller) for a
+// subset of two or more whose owed amounts sum, within cfg's tolerance, to
+// amount - covering the "one wire pays several invoices" case. It's a
+// brute-force subset-sum bounded at maxSplitPaymentCandidates via a
+// meet-in-the-middle split (two halves of up to 2^(k/2) sums each, merged
+// with a sorted binary search, rather than a plain 2^k scan).
+func FindSplitPaymentMatch(amount string, candidates []*InvoiceCandidate, cfg AmountToleranceConfig) (*SplitPaymentMatch, bool) {
+	if len(candidates) < 2 {
+		return nil, false
+	}
+	target, err := ParseMoney(amount, 2)
+	if err != nil {
+		return nil, false
+	}
+
+	pool := candidates
+	if len(pool) > maxSplitPaymentCandidates {
+		pool = pool[:maxSplitPaymentCandidates]
+	}
+
+	amounts := make([]*big.Rat, len(pool))
+	for i, c := range pool {
+		owed := c.GrossAmount
+		if owed == "" {
+			owed = c.Amount
+		}
+		r, err := ParseMoney(owed, c.CurrencyPrecision)
+		if err != nil {
+			return nil, false
+		}
+		amounts[i] = r
+	}
+
+	half := len(pool) / 2
+	leftSubsets := enumerateSubsetSums(amounts[:half])
+	rightSubsets := enumerateSubsetSums(amounts[half:])
+	sort.Slice(rightSubsets, func(i, j int) bool { return rightSubsets[i].sum.Cmp(rightSubsets[j].sum) < 0 })
+
+	var bestMask int
+	var bestDelta *big.Rat
+
+	for _, l := range leftSubsets {
+		need := new(big.Rat).Sub(target, l.sum)
+		idx := sort.Search(len(rightSubsets), func(i int) bool { return rightSubsets[i].sum.Cmp(need) >= 0 })
+		for _, ri := range [2]int{idx - 1, idx} {
+			if ri < 0 || ri >= len(rightSubsets) {
+				continue
+			}
+			r := rightSubsets[ri]
+			if bits.OnesCount(uint(l.mask))+bits.OnesCount(uint(r.mask)) < 2 {
+				continue // a single invoice isn't a split; MatchTransaction's normal path already covers that
+			}
+
+			total := new(big.Rat).Add(l.sum, r.sum)
+			delta := new(big.Rat).Abs(new(big.Rat).Sub(total, target))
+			if withinTolerance, _ := cfg.Evaluate(delta, target); delta.Sign() != 0 && !withinTolerance {
+				continue
+			}
+
+			if bestDelta == nil || delta.Cmp(bestDelta) < 0 {
+				bestDelta = delta
+				bestMask = l.mask | (r.mask << half)
+			}
+		}
+	}
+
+	if bestDelta == nil {
+		return nil, false
+	}
+
+	allocations := make([]SplitPaymentAllocation, 0, bits.OnesCount(uint(bestMask)))
+	for i, c := range pool {
+		if bestMask&(1<<i) == 0 {
+			continue
+		}
+		applied, _ := amounts[i].Float64()
+		allocations = append(allocations, SplitPaymentAllocation{InvoiceID: c.ID, Applied: applied})
+	}
+
+	deltaF, _ := bestDelta.Float64()
+	return &SplitPaymentMatch{Allocations: allocations, Delta: deltaF}, true
+}
+
+func enumerateSubsetSums(amounts []*big.Rat) []subsetSum {
+	subsets := make([]subsetSum, 1<<len(amounts))
+	for mask := range subsets {
+		sum := new(big.Rat)
+		for i, amt := range amounts {
+			if mask&(1<<i) != 0 {
+				sum.Add(sum, amt)
+			}
+		}
+		subsets[mask] = subsetSum{mask: mask, sum: sum}
+	}
+	return subsets
+}
@@ -0,0 +1,360 @@
+package processor
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math/big"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"payment-reconciliation-engine/backend/internal/processor/camt053"
+	"payment-reconciliation-engine/backend/internal/processor/mt940"
+	"payment-reconciliation-engine/backend/internal/processor/ofx"
+)
+
+// Statement format identifiers, used both as Job.Format values and as
+// SourceInfo.Format.
+const (
+	FormatCSV     = "csv"
+	FormatOFX     = "ofx"
+	FormatMT940   = "mt940"
+	FormatCAMT053 = "camt053"
+	FormatXLSX    = "xlsx"
+)
+
+// SourceInfo describes which format and parsing path a StatementReader is
+// reading a batch's transactions through.
+type SourceInfo struct {
+	Format string
+}
+
+// StatementReader iterates a bank statement's transactions one at a time,
+// normalizing whatever source format it was constructed from into
+// TransactionRow. Next returns io.EOF once exhausted; any other error means
+// a single record failed to parse, and processStatementFromContent treats that the
+// same way it always has for a malformed CSV row - count it invalid and
+// continue, rather than aborting the whole file.
+type StatementReader interface {
+	Next() (TransactionRow, error)
+	Header() SourceInfo
+}
+
+// NewStatementReader builds the StatementReader for format, or - when
+// format is empty or "auto" - sniffs it from content's first bytes. An
+// explicit, unrecognized format is an error rather than a silent CSV
+// fallback, so a job mislabeled by the uploader surfaces immediately
+// instead of failing CSV's column validation.
+func NewStatementReader(format string, content []byte, baseCurrency string) (StatementReader, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format == "" || format == "auto" {
+		format = sniffFormat(content)
+	}
+
+	switch format {
+	case FormatCSV:
+		return newCSVReader(content, baseCurrency)
+	case FormatOFX, "qfx":
+		return newOFXReader(content, baseCurrency)
+	case FormatMT940:
+		return newMT940Reader(content, baseCurrency)
+	case FormatCAMT053, "camt.053":
+		return newCAMT053Reader(content, baseCurrency)
+	case FormatXLSX:
+		return newXLSXReader(content, baseCurrency)
+	default:
+		return nil, fmt.Errorf("unsupported statement format %q", format)
+	}
+}
+
+// DetectFormat determines a freshly uploaded statement's format from
+// signals only the upload path has - filename and declared Content-Type -
+// before falling back to sniffFormat's content-only magic-byte check. An
+// explicit filename extension wins outright rather than being sniffed,
+// since it reflects what the user/bank actually named the export; a
+// recognized Content-Type catches the extensionless or misnamed case
+// (e.g. an XLSX served as a generic download); content is the last
+// resort, same as it is once a job has nothing but FileContent to go on.
+func DetectFormat(filename, contentType string, content []byte) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".ofx", ".qfx":
+		return FormatOFX
+	case ".mt940", ".sta":
+		return FormatMT940
+	case ".xlsx":
+		return FormatXLSX
+	case ".csv":
+		return FormatCSV
+		// ".xml" is deliberately not decided here - CAMT.053 is the only
+		// XML format this reads, so it's still worth sniffing the root
+		// element rather than trusting a bare ".xml" extension.
+	}
+
+	switch strings.ToLower(strings.TrimSpace(contentType)) {
+	case "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":
+		return FormatXLSX
+	}
+
+	return sniffFormat(content)
+}
+
+// sniffFormat guesses a statement's format from its first bytes: XLSX's
+// zip magic number, OFX/QFX's SGML header or <OFX> root, MT940's
+// mandatory leading :20: tag, and CAMT.053's ISO 20022 BkToCstmrStmt
+// element, falling back to CSV - the only one of these with no magic
+// bytes of its own.
+func sniffFormat(content []byte) string {
+	head := firstBytes(content, 1024)
+
+	if bytes.HasPrefix(head, []byte("PK\x03\x04")) {
+		return FormatXLSX
+	}
+
+	trimmed := strings.ToUpper(strings.TrimSpace(string(head)))
+
+	switch {
+	case strings.HasPrefix(trimmed, "OFXHEADER"):
+		return FormatOFX
+	case strings.Contains(trimmed, "<OFX>"):
+		return FormatOFX
+	case strings.Contains(trimmed, "BKTOCSTMRSTMT"):
+		return FormatCAMT053
+	case strings.HasPrefix(trimmed, ":20:"):
+		return FormatMT940
+	default:
+		return FormatCSV
+	}
+}
+
+func firstBytes(content []byte, n int) []byte {
+	if len(content) < n {
+		return content
+	}
+	return content[:n]
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// csvReader reads TransactionRow directly off a CSV file's header-mapped
+// columns - the CSV read path processStatementFromContent uses, behind the
+// StatementReader interface.
+type csvReader struct {
+	reader       *csv.Reader
+	colMap       map[string]int
+	baseCurrency string
+}
+
+func newCSVReader(content []byte, baseCurrency string) (*csvReader, error) {
+	r := csv.NewReader(bytes.NewReader(content))
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	colMap := make(map[string]int)
+	for i, col := range header {
+		colMap[strings.ToLower(strings.TrimSpace(col))] = i
+	}
+
+	required := []string{"transaction_date", "description", "amount"}
+	for _, req := range required {
+		if _, exists := colMap[req]; !exists {
+			return nil, fmt.Errorf("missing required column: %s", req)
+		}
+	}
+
+	return &csvReader{reader: r, colMap: colMap, baseCurrency: baseCurrency}, nil
+}
+
+func (c *csvReader) Header() SourceInfo { return SourceInfo{Format: FormatCSV} }
+
+func (c *csvReader) Next() (TransactionRow, error) {
+	record, err := c.reader.Read()
+	if err != nil {
+		return TransactionRow{}, err
+	}
+	return c.parseRecord(record)
+}
+
+func (c *csvReader) parseRecord(record []string) (TransactionRow, error) {
+	var row TransactionRow
+
+	dateIdx, exists := c.colMap["transaction_date"]
+	if !exists || dateIdx >= len(record) {
+		return row, fmt.Errorf("missing transaction_date")
+	}
+	date, err := time.Parse("2006-01-02", record[dateIdx])
+	if err != nil {
+		return row, fmt.Errorf("invalid date format: %w", err)
+	}
+	row.TransactionDate = date
+
+	descIdx, exists := c.colMap["description"]
+	if !exists || descIdx >= len(record) {
+		return row, fmt.Errorf("missing description")
+	}
+	row.Description = record[descIdx]
+
+	amountIdx, exists := c.colMap["amount"]
+	if !exists || amountIdx >= len(record) {
+		return row, fmt.Errorf("missing amount")
+	}
+	row.Amount = record[amountIdx]
+	if _, err := strconv.ParseFloat(row.Amount, 64); err != nil {
+		return row, fmt.Errorf("invalid amount: %w", err)
+	}
+
+	if refIdx, exists := c.colMap["reference_number"]; exists && refIdx < len(record) && record[refIdx] != "" {
+		ref := record[refIdx]
+		row.ReferenceNumber = &ref
+	}
+
+	row.Currency = c.baseCurrency
+	if curIdx, exists := c.colMap["currency"]; exists && curIdx < len(record) && record[curIdx] != "" {
+		row.Currency = strings.ToUpper(strings.TrimSpace(record[curIdx]))
+	}
+
+	return row, nil
+}
+
+// ofxReader wraps ofx.ParseStatement, which parses the whole document
+// eagerly; Next just walks the resulting slice.
+type ofxReader struct {
+	baseCurrency string
+	txns         []ofx.BankTransaction
+	idx          int
+}
+
+func newOFXReader(content []byte, baseCurrency string) (*ofxReader, error) {
+	txns, err := ofx.ParseStatement(bytes.NewReader(content))
+	if err != nil && len(txns) == 0 {
+		return nil, fmt.Errorf("failed to parse OFX statement: %w", err)
+	}
+	return &ofxReader{baseCurrency: baseCurrency, txns: txns}, nil
+}
+
+func (r *ofxReader) Header() SourceInfo { return SourceInfo{Format: FormatOFX} }
+
+func (r *ofxReader) Next() (TransactionRow, error) {
+	if r.idx >= len(r.txns) {
+		return TransactionRow{}, io.EOF
+	}
+	t := r.txns[r.idx]
+	r.idx++
+
+	if t.Amount == nil {
+		return TransactionRow{}, fmt.Errorf("ofx transaction %s missing amount", t.FITID)
+	}
+	amount := t.Amount
+	if amount.Sign() < 0 {
+		amount = new(big.Rat).Neg(amount)
+	}
+
+	return TransactionRow{
+		TransactionDate: t.PostedAt,
+		Description:     t.Description(),
+		Amount:          amount.FloatString(2),
+		ReferenceNumber: optionalString(t.CheckNum),
+		Currency:        r.baseCurrency,
+		Source: map[string]interface{}{
+			"fitid":   t.FITID,
+			"trnType": t.TrnType,
+			"name":    t.Name,
+			"memo":    t.Memo,
+		},
+	}, nil
+}
+
+// mt940Reader wraps mt940.ParseStatement, which parses the whole message
+// eagerly; Next just walks the resulting slice.
+type mt940Reader struct {
+	baseCurrency string
+	txns         []mt940.BankTransaction
+	idx          int
+}
+
+func newMT940Reader(content []byte, baseCurrency string) (*mt940Reader, error) {
+	txns, err := mt940.ParseStatement(bytes.NewReader(content))
+	if err != nil && len(txns) == 0 {
+		return nil, fmt.Errorf("failed to parse MT940 statement: %w", err)
+	}
+	return &mt940Reader{baseCurrency: baseCurrency, txns: txns}, nil
+}
+
+func (r *mt940Reader) Header() SourceInfo { return SourceInfo{Format: FormatMT940} }
+
+func (r *mt940Reader) Next() (TransactionRow, error) {
+	if r.idx >= len(r.txns) {
+		return TransactionRow{}, io.EOF
+	}
+	t := r.txns[r.idx]
+	r.idx++
+
+	date := t.ValueDate
+
+	return TransactionRow{
+		TransactionDate: date,
+		Description:     t.Description(),
+		Amount:          t.AmountString(),
+		ReferenceNumber: optionalString(t.BankReference),
+		Currency:        r.baseCurrency,
+		Source: map[string]interface{}{
+			"debitCredit":     t.DebitCredit,
+			"transactionType": t.TransactionType,
+			"customerRef":     t.CustomerReference,
+			"bankRef":         t.BankReference,
+		},
+	}, nil
+}
+
+// camt053Reader wraps camt053.ParseStatement, which parses the whole
+// document eagerly; Next just walks the resulting slice.
+type camt053Reader struct {
+	baseCurrency string
+	txns         []camt053.BankTransaction
+	idx          int
+}
+
+func newCAMT053Reader(content []byte, baseCurrency string) (*camt053Reader, error) {
+	txns, err := camt053.ParseStatement(bytes.NewReader(content))
+	if err != nil && len(txns) == 0 {
+		return nil, fmt.Errorf("failed to parse CAMT.053 statement: %w", err)
+	}
+	return &camt053Reader{baseCurrency: baseCurrency, txns: txns}, nil
+}
+
+func (r *camt053Reader) Header() SourceInfo { return SourceInfo{Format: FormatCAMT053} }
+
+func (r *camt053Reader) Next() (TransactionRow, error) {
+	if r.idx >= len(r.txns) {
+		return TransactionRow{}, io.EOF
+	}
+	t := r.txns[r.idx]
+	r.idx++
+
+	currency := t.Currency
+	if currency == "" {
+		currency = r.baseCurrency
+	}
+
+	return TransactionRow{
+		TransactionDate: t.BookingDate,
+		Description:     t.Description(),
+		Amount:          t.AmountString(),
+		ReferenceNumber: optionalString(t.EntryReference),
+		Currency:        currency,
+		Source: map[string]interface{}{
+			"creditDebit":    t.CreditDebit,
+			"entryReference": t.EntryReference,
+		},
+	}, nil
+}
@@ -0,0 +1,105 @@
+package processor
+
+import (
+	"io"
+	"testing"
+)
+
+func TestSniffFormat(t *testing.T) {
+	cases := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"ofx sgml", "OFXHEADER:100\nDATA:OFXSGML\n<OFX>\n", FormatOFX},
+		{"ofx xml", "<?xml version=\"1.0\"?>\n<OFX>\n<BANKMSGSRSV1>", FormatOFX},
+		{"mt940", ":20:STMT0001\n:25:12345678/USD\n:61:2406150615C1000,00NTRF\n", FormatMT940},
+		{"camt053", "<?xml version=\"1.0\"?>\n<Document><BkToCstmrStmt><Stmt></Stmt></BkToCstmrStmt></Document>", FormatCAMT053},
+		{"csv", "transaction_date,description,amount\n2024-01-01,ACME,100.00\n", FormatCSV},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := sniffFormat([]byte(c.content)); got != c.want {
+				t.Errorf("sniffFormat(%q) = %s, want %s", c.name, got, c.want)
+			}
+		})
+	}
+}
+
+func TestNewStatementReader_UnsupportedFormat(t *testing.T) {
+	_, err := NewStatementReader("swift-mt101", []byte("irrelevant"), "USD")
+	if err == nil {
+		t.Fatal("expected an error for an unsupported format")
+	}
+}
+
+func TestNewStatementReader_CSVMissingColumn(t *testing.T) {
+	_, err := NewStatementReader(FormatCSV, []byte("description,amount\nACME,100.00\n"), "USD")
+	if err == nil {
+		t.Fatal("expected an error for a missing required column")
+	}
+}
+
+func TestCSVReader_DefaultsCurrencyToBaseCurrency(t *testing.T) {
+	content := "transaction_date,description,amount\n2024-01-01,ACME CORP,100.00\n"
+	reader, err := NewStatementReader(FormatCSV, []byte(content), "EUR")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	row, err := reader.Next()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if row.Currency != "EUR" {
+		t.Errorf("expected currency EUR, got %s", row.Currency)
+	}
+	if row.Source != nil {
+		t.Errorf("expected nil Source for a CSV row, got %v", row.Source)
+	}
+
+	if _, err := reader.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF after the only row, got %v", err)
+	}
+}
+
+func TestMT940Reader_PopulatesSource(t *testing.T) {
+	content := ":20:STMT0001\n:61:2406150615C1000,00NTRFREF123//BANKREF456\n:86:ACME CORP Invoice payment\n"
+	reader, err := NewStatementReader(FormatMT940, []byte(content), "USD")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	row, err := reader.Next()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if row.Amount != "1000.00" {
+		t.Errorf("expected amount 1000.00, got %s", row.Amount)
+	}
+	if row.Currency != "USD" {
+		t.Errorf("expected currency USD, got %s", row.Currency)
+	}
+	if row.Source["debitCredit"] != "C" {
+		t.Errorf("expected debitCredit C in source, got %v", row.Source["debitCredit"])
+	}
+}
+
+func TestOFXReader_UsesAbsoluteAmount(t *testing.T) {
+	content := "OFXHEADER:100\n<OFX>\n<STMTTRN>\n<TRNTYPE>DEBIT\n<DTPOSTED>20240101\n<TRNAMT>-42.50\n<FITID>1\n<NAME>ACME\n</STMTTRN>\n"
+	reader, err := NewStatementReader(FormatOFX, []byte(content), "USD")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	row, err := reader.Next()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if row.Amount != "42.50" {
+		t.Errorf("expected absolute amount 42.50, got %s", row.Amount)
+	}
+	if row.Source["fitid"] != "1" {
+		t.Errorf("expected fitid 1 in source, got %v", row.Source["fitid"])
+	}
+}
@@ -0,0 +1,213 @@
+package processor
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// TxnInput is one unit of work for MatchTransactionsStream: everything
+// MatchTransaction needs for a single transaction, plus Seq - the input's
+// position in the caller's original sequence - so the sink can restore that
+// ordering after scoring runs out of order across worker goroutines. Seq is
+// assumed to be a contiguous, zero-based count of values sent on the input
+// channel (unlike processStatementFromContent's rowNum, which starts at 1).
+type TxnInput struct {
+	Seq             int
+	Description     string
+	Amount          string
+	TransactionDate time.Time
+	Candidates      []*InvoiceCandidate
+}
+
+// StreamOptions configures MatchTransactionsStream. MatchOptions is applied
+// to every transaction exactly as a direct MatchTransaction call would;
+// Workers and BufferSize control the pool's parallelism and backpressure.
+type StreamOptions struct {
+	MatchOptions
+
+	// Workers is how many goroutines score transactions concurrently.
+	// <= 0 defaults to streamDefaultWorkers.
+	Workers int
+
+	// BufferSize bounds the output channel, providing backpressure: a slow
+	// consumer stalls the worker pool rather than letting
+	// MatchTransactionsStream buffer unboundedly in memory. <= 0 defaults
+	// to streamDefaultBufferSize.
+	BufferSize int
+}
+
+const (
+	streamDefaultWorkers    = 4
+	streamDefaultBufferSize = 64
+)
+
+// StreamStats summarizes a finished MatchTransactionsStream run: how many
+// inputs landed in each status bucket, and how long the whole stream ran
+// from the first input accepted to the last result emitted.
+type StreamStats struct {
+	Processed   int
+	AutoMatched int
+	NeedsReview int
+	Unmatched   int
+	Duration    time.Duration
+}
+
+// seqResult pairs a TxnInput's Seq with the MatchResult the worker pool
+// produced for it, so the sink goroutine can re-sequence results that
+// finish out of order.
+type seqResult struct {
+	seq    int
+	result MatchResult
+}
+
+// MatchTransactionsStream is the streaming counterpart to MatchTransaction:
+// instead of a caller loading every transaction into memory up front (as
+// BatchReconcile does), it fans scoring out across a bounded worker pool and
+// streams MatchResult values back as they're ready, reordered to match
+// input's original sequence. This lets handlers that drive very large
+// statement uploads (invoice search, SSE/websocket progress) process
+// statements without holding the whole result set in RAM, and cancel
+// mid-stream via ctx.
+//
+// The returned result channel is closed once input is exhausted, every
+// in-flight result has been emitted, and (if ctx wasn't canceled first) the
+// StreamStats channel has received its single summary value. The error
+// channel receives ctx.Err() and closes without a StreamStats value if ctx
+// is canceled before input drains.
+func MatchTransactionsStream(ctx context.Context, input <-chan TxnInput, opts ...StreamOptions) (<-chan MatchResult, <-chan error, <-chan StreamStats) {
+	streamOpts := StreamOptions{}
+	if len(opts) > 0 {
+		streamOpts = opts[0]
+	}
+	workers := streamOpts.Workers
+	if workers <= 0 {
+		workers = streamDefaultWorkers
+	}
+	bufferSize := streamOpts.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = streamDefaultBufferSize
+	}
+
+	results := make(chan MatchResult, bufferSize)
+	errs := make(chan error, 1)
+	stats := make(chan StreamStats, 1)
+	scored := make(chan seqResult, bufferSize)
+
+	start := time.Now()
+
+	// workerCounters replaces the global debugTxnCounter for this stream:
+	// each worker numbers only the transactions it personally scores, so
+	// the debug trace stays race-free without synchronizing across
+	// goroutines just to print a sequential number.
+	workerCounters := make([]int, workers)
+
+	var pool sync.WaitGroup
+	pool.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func(workerID int) {
+			defer pool.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case in, ok := <-input:
+					if !ok {
+						return
+					}
+					workerCounters[workerID]++
+					debugLog("STREAM worker#%d txn#%d: seq=%d desc=%q amount=%s",
+						workerID, workerCounters[workerID], in.Seq, in.Description, in.Amount)
+
+					match := MatchTransaction(in.Description, in.Amount, in.TransactionDate, in.Candidates, streamOpts.MatchOptions)
+
+					select {
+					case scored <- seqResult{seq: in.Seq, result: match}:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}(w)
+	}
+
+	go func() {
+		pool.Wait()
+		close(scored)
+	}()
+
+	go func() {
+		defer close(results)
+		defer close(errs)
+		defer close(stats)
+
+		summary := StreamStats{}
+		next := 0
+		pending := make(map[int]MatchResult)
+
+		emit := func(r MatchResult) {
+			summary.Processed++
+			switch r.Status {
+			case "auto_matched":
+				summary.AutoMatched++
+			case "needs_review":
+				summary.NeedsReview++
+			case "unmatched":
+				summary.Unmatched++
+			}
+			select {
+			case results <- r:
+			case <-ctx.Done():
+			}
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				errs <- ctx.Err()
+				return
+			case sr, ok := <-scored:
+				if !ok {
+					// Workers also return on cancellation, so a closed
+					// scored channel alone doesn't mean input drained.
+					if err := ctx.Err(); err != nil {
+						errs <- err
+						return
+					}
+					// input is exhausted and every worker has returned; any
+					// results still buffered out of order are contiguous
+					// from next (nothing can ever fill the gaps now).
+					for {
+						r, ok := pending[next]
+						if !ok {
+							break
+						}
+						delete(pending, next)
+						emit(r)
+						next++
+					}
+					summary.Duration = time.Since(start)
+					stats <- summary
+					return
+				}
+				if sr.seq == next {
+					emit(sr.result)
+					next++
+					for {
+						r, ok := pending[next]
+						if !ok {
+							break
+						}
+						delete(pending, next)
+						emit(r)
+						next++
+					}
+				} else {
+					pending[sr.seq] = sr.result
+				}
+			}
+		}
+	}()
+
+	return results, errs, stats
+}
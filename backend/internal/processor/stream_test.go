@@ -0,0 +1,69 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMatchTransactionsStream_PreservesInputOrder(t *testing.T) {
+	dueDate := time.Date(2024, 12, 10, 0, 0, 0, 0, time.UTC)
+	candidates := []*InvoiceCandidate{
+		{ID: "inv-1", InvoiceNumber: "INV-001", Amount: "450.00", DueDate: dueDate, CustomerName: "John Smith", NormalizedName: "JOHN SMITH", Status: "sent"},
+	}
+
+	input := make(chan TxnInput)
+	go func() {
+		defer close(input)
+		for i := 0; i < 20; i++ {
+			input <- TxnInput{
+				Seq:             i,
+				Description:     "JOHN SMITH PAYMENT",
+				Amount:          "450.00",
+				TransactionDate: dueDate,
+				Candidates:      candidates,
+			}
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	results, errs, stats := MatchTransactionsStream(ctx, input, StreamOptions{Workers: 8, BufferSize: 4})
+
+	count := 0
+	for range results {
+		count++
+	}
+	if count != 20 {
+		t.Fatalf("expected 20 results, got %d", count)
+	}
+
+	if err, ok := <-errs; ok {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	summary := <-stats
+	if summary.Processed != 20 || summary.AutoMatched != 20 {
+		t.Fatalf("expected 20 processed/auto_matched, got %+v", summary)
+	}
+}
+
+func TestMatchTransactionsStream_CancelStopsEarly(t *testing.T) {
+	input := make(chan TxnInput)
+	defer close(input)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results, errs, _ := MatchTransactionsStream(ctx, input, StreamOptions{Workers: 2})
+
+	for range results {
+		t.Fatal("expected no results once ctx is already canceled")
+	}
+
+	err, ok := <-errs
+	if !ok || err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v (ok=%v)", err, ok)
+	}
+}
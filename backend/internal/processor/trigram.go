@@ -0,0 +1,64 @@
+package processor
+
+import "strings"
+
+// trigram is a padded character 3-gram, keyed as a fixed-size byte array
+// (rather than a string) so trigramSet doesn't allocate one string per
+// trigram.
+type trigram [3]byte
+
+// trigramSet returns the set of padded character 3-grams in s: s is
+// upper-cased and bracketed with two leading/trailing spaces (e.g. "SAM" ->
+// "  S", " SA", "SAM", "AM ", "M  "), modeled on Postgres's pg_trgm
+// extension behind the SQL-side `similarity()` used by
+// InvoicesHandler.buildSearchQuery. The two are close but not identical:
+// pg_trgm pads each word separately with two leading spaces and one
+// trailing space, while this pads the whole string with two on each side.
+func trigramSet(s string) map[trigram]struct{} {
+	padded := "  " + strings.ToUpper(strings.TrimSpace(s)) + "  "
+	runes := []byte(padded)
+
+	set := make(map[trigram]struct{}, len(runes))
+	for i := 0; i+3 <= len(runes); i++ {
+		set[trigram{runes[i], runes[i+1], runes[i+2]}] = struct{}{}
+	}
+	return set
+}
+
+// trigramSimilarity returns the Jaccard similarity |A∩B| / |A∪B| of s1 and
+// s2's padded character 3-gram sets, as a 0-100 score. Unlike
+// jaroWinklerRaw's character-by-character alignment, trigram overlap is
+// tolerant of words appearing in a different position within the string,
+// which helps with bank descriptions that interleave name tokens with
+// other noise trigramSet's caller hasn't stripped.
+func trigramSimilarity(s1, s2 string) float64 {
+	return trigramSetSimilarity(trigramSet(s1), trigramSet(s2))
+}
+
+// trigramSetSimilarity is trigramSimilarity for callers that already have
+// one side's trigram set cached - see InvoiceCandidate.TrigramSet, computed
+// once per candidate in LoadInvoiceCache instead of once per transaction
+// scored against it.
+func trigramSetSimilarity(a, b map[trigram]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0.0
+	}
+
+	smaller, larger := a, b
+	if len(b) < len(a) {
+		smaller, larger = b, a
+	}
+
+	intersection := 0
+	for t := range smaller {
+		if _, ok := larger[t]; ok {
+			intersection++
+		}
+	}
+
+	union := len(a) + len(b) - intersection
+	if union == 0 {
+		return 0.0
+	}
+	return float64(intersection) / float64(union) * 100.0
+}
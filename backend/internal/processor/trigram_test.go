@@ -0,0 +1,42 @@
+package processor
+
+import "testing"
+
+func TestTrigramSimilarity_IdenticalStrings(t *testing.T) {
+	if score := trigramSimilarity("SARAH ADAMS", "SARAH ADAMS"); score != 100.0 {
+		t.Errorf("expected 100 for identical strings, got %.2f", score)
+	}
+}
+
+func TestTrigramSimilarity_NoOverlap(t *testing.T) {
+	if score := trigramSimilarity("AAA", "ZZZ"); score != 0.0 {
+		t.Errorf("expected 0 for disjoint trigram sets, got %.2f", score)
+	}
+}
+
+func TestTrigramSimilarity_PartialOverlapIsOrderTolerant(t *testing.T) {
+	// A transposed pair of tokens shares most of its trigrams even though
+	// jaroWinklerRaw's character alignment would score it much lower.
+	score := trigramSimilarity("ADAMS SARAH", "SARAH ADAMS")
+	if score < 40.0 {
+		t.Errorf("expected a meaningfully positive score for reordered tokens, got %.2f", score)
+	}
+}
+
+func TestTrigramSetSimilarity_MatchesTrigramSimilarity(t *testing.T) {
+	a, b := "SARAH ADAMS", "SARA ADAMS"
+	if got, want := trigramSetSimilarity(trigramSet(a), trigramSet(b)), trigramSimilarity(a, b); got != want {
+		t.Errorf("expected trigramSetSimilarity to agree with trigramSimilarity, got %.4f want %.4f", got, want)
+	}
+}
+
+func TestJaroWinkler_IncludesTrigramInEnsemble(t *testing.T) {
+	// Two names that share almost no character alignment position-for-
+	// position should still score well. The ensemble takes the best of its
+	// scorers, and here tokenSortedJaroWinkler already sees identical sorted
+	// tokens, so this alone does not show trigramSimilarity's contribution.
+	score := jaroWinkler("ADAMS SARAH JANE", "JANE SARAH ADAMS")
+	if score < 80.0 {
+		t.Errorf("expected ensemble to catch heavily reordered tokens, got %.2f", score)
+	}
+}
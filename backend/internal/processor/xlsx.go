@@ -0,0 +1,126 @@
+package processor
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// xlsxReader reads TransactionRow off an XLSX workbook's first sheet,
+// header-mapped the same way csvReader maps a CSV's header row. excelize
+// has no streaming row reader that tolerates a header lookup ahead of
+// time, so rows are loaded eagerly - the same tradeoff ofxReader,
+// mt940Reader, and camt053Reader already make for their own eager
+// parsers.
+type xlsxReader struct {
+	colMap       map[string]int
+	baseCurrency string
+	rows         [][]string
+	idx          int
+}
+
+func newXLSXReader(content []byte, baseCurrency string) (*xlsxReader, error) {
+	f, err := excelize.OpenReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open XLSX workbook: %w", err)
+	}
+	defer f.Close()
+
+	sheets := f.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, fmt.Errorf("XLSX workbook has no sheets")
+	}
+
+	rows, err := f.GetRows(sheets[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sheet %q: %w", sheets[0], err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("failed to read header: sheet %q is empty", sheets[0])
+	}
+
+	colMap := make(map[string]int)
+	for i, col := range rows[0] {
+		colMap[strings.ToLower(strings.TrimSpace(col))] = i
+	}
+
+	required := []string{"transaction_date", "description", "amount"}
+	for _, req := range required {
+		if _, exists := colMap[req]; !exists {
+			return nil, fmt.Errorf("missing required column: %s", req)
+		}
+	}
+
+	return &xlsxReader{colMap: colMap, baseCurrency: baseCurrency, rows: rows[1:]}, nil
+}
+
+func (x *xlsxReader) Header() SourceInfo { return SourceInfo{Format: FormatXLSX} }
+
+func (x *xlsxReader) Next() (TransactionRow, error) {
+	if x.idx >= len(x.rows) {
+		return TransactionRow{}, io.EOF
+	}
+	record := x.rows[x.idx]
+	x.idx++
+	return x.parseRecord(record)
+}
+
+// cell returns record[idx], or "" when the row is short a trailing blank
+// cell - excelize, unlike encoding/csv, trims a row to its last non-empty
+// cell rather than padding it out to the header's width.
+func cell(record []string, idx int) string {
+	if idx < 0 || idx >= len(record) {
+		return ""
+	}
+	return record[idx]
+}
+
+func (x *xlsxReader) parseRecord(record []string) (TransactionRow, error) {
+	var row TransactionRow
+
+	dateIdx, exists := x.colMap["transaction_date"]
+	if !exists {
+		return row, fmt.Errorf("missing transaction_date")
+	}
+	dateStr := cell(record, dateIdx)
+	date, err := time.Parse("2006-01-02", dateStr)
+	if err != nil {
+		return row, fmt.Errorf("invalid date format: %w", err)
+	}
+	row.TransactionDate = date
+
+	descIdx, exists := x.colMap["description"]
+	if !exists {
+		return row, fmt.Errorf("missing description")
+	}
+	row.Description = cell(record, descIdx)
+
+	amountIdx, exists := x.colMap["amount"]
+	if !exists {
+		return row, fmt.Errorf("missing amount")
+	}
+	row.Amount = cell(record, amountIdx)
+	if _, err := strconv.ParseFloat(row.Amount, 64); err != nil {
+		return row, fmt.Errorf("invalid amount: %w", err)
+	}
+
+	if refIdx, exists := x.colMap["reference_number"]; exists {
+		if ref := cell(record, refIdx); ref != "" {
+			row.ReferenceNumber = &ref
+		}
+	}
+
+	row.Currency = x.baseCurrency
+	if curIdx, exists := x.colMap["currency"]; exists {
+		if cur := cell(record, curIdx); cur != "" {
+			row.Currency = strings.ToUpper(strings.TrimSpace(cur))
+		}
+	}
+
+	return row, nil
+}
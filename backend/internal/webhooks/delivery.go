@@ -0,0 +1,284 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// backoffSchedule is the delay before each retry after a delivery attempt
+// fails: attempt 1 waits backoffSchedule[0], attempt 2 waits
+// backoffSchedule[1], and so on. Once the schedule is exhausted every
+// further retry waits the last entry (24h) until MaxAttempts is reached.
+var backoffSchedule = []time.Duration{
+	1 * time.Second,
+	5 * time.Second,
+	30 * time.Second,
+	5 * time.Minute,
+	30 * time.Minute,
+	24 * time.Hour,
+}
+
+func backoffFor(attempt int) time.Duration {
+	if attempt >= len(backoffSchedule) {
+		return backoffSchedule[len(backoffSchedule)-1]
+	}
+	return backoffSchedule[attempt]
+}
+
+// DeliveryWorker turns Events from an Emitter into persisted
+// webhook_deliveries rows, then delivers (and retries) them. Run one per
+// process alongside the Emitter it drains - see cmd/api/main.go and
+// cmd/worker/main.go.
+type DeliveryWorker struct {
+	DB           *sqlx.DB
+	Emitter      *Emitter
+	HTTPClient   *http.Client
+	PollInterval time.Duration
+	MaxAttempts  int
+	BatchSize    int
+}
+
+// NewDeliveryWorker builds a DeliveryWorker with the repo's usual
+// production defaults; override fields on the returned value for tests or
+// local tuning.
+func NewDeliveryWorker(db *sqlx.DB, emitter *Emitter) *DeliveryWorker {
+	return &DeliveryWorker{
+		DB:           db,
+		Emitter:      emitter,
+		HTTPClient:   &http.Client{Timeout: 10 * time.Second},
+		PollInterval: 2 * time.Second,
+		MaxAttempts:  10,
+		BatchSize:    20,
+	}
+}
+
+// Start runs until ctx is cancelled: one goroutine turns emitted Events
+// into pending webhook_deliveries rows, the other polls for deliveries
+// that are due and attempts them.
+func (w *DeliveryWorker) Start(ctx context.Context) {
+	go w.recordIncoming(ctx)
+	w.processDue(ctx)
+}
+
+// recordIncoming drains the Emitter and, for every webhook subscribed to
+// an event's type, inserts a pending delivery row. This is the durability
+// boundary: once a row is committed here, a crash before or during
+// delivery just means processDue picks it up again later, instead of the
+// event being silently lost.
+func (w *DeliveryWorker) recordIncoming(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case evt, ok := <-w.Emitter.Events():
+			if !ok {
+				return
+			}
+			if err := w.recordDeliveries(evt); err != nil {
+				log.Printf("Warning: failed to record webhook deliveries for event %s (%s): %v", evt.ID, evt.Type, err)
+			}
+		}
+	}
+}
+
+type webhookSubscriber struct {
+	ID     string `db:"id"`
+	URL    string `db:"url"`
+	Secret string `db:"secret"`
+}
+
+func (w *DeliveryWorker) recordDeliveries(evt Event) error {
+	eventTypeJSON, err := json.Marshal([]string{evt.Type})
+	if err != nil {
+		return fmt.Errorf("failed to marshal event type filter: %w", err)
+	}
+
+	var subscribers []webhookSubscriber
+	err = w.DB.Select(&subscribers, `
+		SELECT id, url, secret FROM webhooks WHERE event_types @> $1::jsonb
+	`, eventTypeJSON)
+	if err != nil {
+		return fmt.Errorf("failed to look up subscribed webhooks: %w", err)
+	}
+	if len(subscribers) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"eventId": evt.ID,
+		"type":    evt.Type,
+		"data":    evt.Payload,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal event payload: %w", err)
+	}
+
+	for _, sub := range subscribers {
+		_, err := w.DB.Exec(`
+			INSERT INTO webhook_deliveries (id, webhook_id, event_id, event_type, payload, status, attempts, next_attempt_at, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5::jsonb, 'pending', 0, NOW(), NOW(), NOW())
+		`, uuid.New().String(), sub.ID, evt.ID, evt.Type, payload)
+		if err != nil {
+			log.Printf("Warning: failed to queue delivery of event %s to webhook %s: %v", evt.ID, sub.ID, err)
+		}
+	}
+	return nil
+}
+
+type pendingDelivery struct {
+	ID       string `db:"id"`
+	Attempts int    `db:"attempts"`
+	Payload  []byte `db:"payload"`
+	URL      string `db:"url"`
+	Secret   string `db:"secret"`
+	EventID  string `db:"event_id"`
+}
+
+// processDue polls for deliveries whose next_attempt_at has passed and
+// attempts each, until ctx is cancelled.
+func (w *DeliveryWorker) processDue(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		n, err := w.attemptDue()
+		if err != nil {
+			log.Printf("Warning: failed to process due webhook deliveries: %v", err)
+		}
+		if n == 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(w.PollInterval):
+			}
+		}
+	}
+}
+
+func (w *DeliveryWorker) attemptDue() (int, error) {
+	tx, err := w.DB.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var due []pendingDelivery
+	err = tx.Select(&due, `
+		SELECT d.id, d.attempts, d.payload, d.event_id, w.url, w.secret
+		FROM webhook_deliveries d
+		JOIN webhooks w ON w.id = d.webhook_id
+		WHERE d.status = 'pending' AND d.next_attempt_at <= NOW()
+		ORDER BY d.next_attempt_at ASC
+		LIMIT $1
+		FOR UPDATE OF d SKIP LOCKED
+	`, w.BatchSize)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to select due deliveries: %w", err)
+	}
+	if len(due) == 0 {
+		return 0, nil
+	}
+
+	// Mark them processing under the same row lock so a concurrent
+	// DeliveryWorker (another replica) skips these rather than racing to
+	// deliver them too - mirrors claimJob's FOR UPDATE SKIP LOCKED pattern.
+	ids := make([]string, len(due))
+	for i, d := range due {
+		ids[i] = d.ID
+	}
+	if _, err := tx.Exec(`UPDATE webhook_deliveries SET status = 'delivering', updated_at = NOW() WHERE id = ANY($1)`, pq.Array(ids)); err != nil {
+		return 0, fmt.Errorf("failed to mark deliveries in-flight: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit claim of due deliveries: %w", err)
+	}
+
+	for _, d := range due {
+		w.attemptOne(d)
+	}
+	return len(due), nil
+}
+
+func (w *DeliveryWorker) attemptOne(d pendingDelivery) {
+	sig := sign(d.Secret, d.Payload)
+
+	req, err := http.NewRequest(http.MethodPost, d.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		w.recordFailure(d, fmt.Sprintf("failed to build request: %v", err))
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Signature", "sha256="+sig)
+	req.Header.Set("X-Event-Id", d.EventID)
+
+	resp, err := w.HTTPClient.Do(req)
+	if err != nil {
+		w.recordFailure(d, fmt.Sprintf("request failed: %v", err))
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		w.recordSuccess(d)
+		return
+	}
+	w.recordFailure(d, fmt.Sprintf("receiver returned status %d", resp.StatusCode))
+}
+
+// sign computes the HMAC-SHA256 of body using secret, hex-encoded - the
+// value a receiver recomputes and compares against X-Signature to verify
+// the delivery actually came from this service.
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (w *DeliveryWorker) recordSuccess(d pendingDelivery) {
+	_, err := w.DB.Exec(`
+		UPDATE webhook_deliveries
+		SET status = 'delivered', attempts = attempts + 1, delivered_at = NOW(), last_error = NULL, updated_at = NOW()
+		WHERE id = $1
+	`, d.ID)
+	if err != nil {
+		log.Printf("Warning: failed to record successful webhook delivery %s: %v", d.ID, err)
+	}
+}
+
+func (w *DeliveryWorker) recordFailure(d pendingDelivery, reason string) {
+	attempts := d.Attempts + 1
+	status := "pending"
+	if attempts >= w.MaxAttempts {
+		status = "failed"
+	}
+	nextAttempt := time.Now().Add(backoffFor(attempts - 1))
+
+	_, err := w.DB.Exec(`
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, next_attempt_at = $3, last_error = $4, updated_at = NOW()
+		WHERE id = $5
+	`, status, attempts, nextAttempt, reason, d.ID)
+	if err != nil {
+		log.Printf("Warning: failed to record failed webhook delivery %s: %v", d.ID, err)
+	}
+}
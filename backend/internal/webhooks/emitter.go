@@ -0,0 +1,65 @@
+// Package webhooks lets registered URLs be notified, via a signed HTTP
+// POST, when a reconciliation event happens - a batch finishing one way or
+// another, or a transaction's match being decided. Emitter is the
+// in-process publish side callers use; DeliveryWorker is what actually
+// turns an emitted Event into persisted, retried deliveries. See the
+// webhooks and webhook_deliveries tables.
+package webhooks
+
+import (
+	"log"
+
+	"github.com/google/uuid"
+)
+
+// Event types a webhook can subscribe to.
+const (
+	EventBatchCompleted             = "batch.completed"
+	EventBatchFailed                = "batch.failed"
+	EventTransactionAutoMatched     = "transaction.auto_matched"
+	EventTransactionNeedsReview     = "transaction.needs_review"
+	EventTransactionManuallyMatched = "transaction.manually_matched"
+	EventTransactionConfirmed       = "transaction.confirmed"
+	EventTransactionRejected        = "transaction.rejected"
+	EventTransactionMarkedExternal  = "transaction.marked_external"
+	EventTransactionUndone          = "transaction.undone"
+)
+
+// Event is one occurrence an Emitter hands to whatever's draining it. ID
+// becomes the X-Event-Id header on every delivery derived from it, so a
+// receiver can dedupe retried deliveries of the same event.
+type Event struct {
+	ID      string
+	Type    string
+	Payload map[string]interface{}
+}
+
+// Emitter fans events out to whatever's draining Events() - normally one
+// DeliveryWorker per process, the same one-instance-per-process shape as
+// events.Bus's in-process mode. Emitting itself is cheap and never
+// survives a crash; webhook_deliveries, which DeliveryWorker writes before
+// it ever makes an HTTP call, is what needs to.
+type Emitter struct {
+	ch chan Event
+}
+
+func NewEmitter() *Emitter {
+	return &Emitter{ch: make(chan Event, 256)}
+}
+
+// Emit hands payload off non-blockingly - a slow or absent DeliveryWorker
+// must never make a caller (a match confirmation, a processor flush) wait
+// on webhook plumbing.
+func (e *Emitter) Emit(eventType string, payload map[string]interface{}) {
+	evt := Event{ID: uuid.New().String(), Type: eventType, Payload: payload}
+	select {
+	case e.ch <- evt:
+	default:
+		log.Printf("Warning: webhook emitter queue full, dropping %s event", eventType)
+	}
+}
+
+// Events returns the channel a DeliveryWorker drains.
+func (e *Emitter) Events() <-chan Event {
+	return e.ch
+}
@@ -0,0 +1,155 @@
+package webhooks
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// WriteEvent records eventType against transactionID/batchID inside tx - the
+// same transaction as the mutation it describes - by inserting a row into
+// reconciliation_events. This is the durability boundary Emitter.Emit
+// doesn't have: Emit only ever reaches a live DeliveryWorker's in-memory
+// channel, so a crash between tx.Commit() and that send lands the event
+// nowhere. A reconciliation_events row, once committed alongside the
+// mutation it describes, is picked up by OutboxDispatcher however long it
+// takes for one to run. transactionID and batchID may be nil (a bulk action
+// spanning more than one transaction, or an event with no batch) - pass
+// sql.NullString-compatible values the same way the rest of this package's
+// callers pass nullable columns.
+func WriteEvent(tx *sqlx.Tx, eventType string, transactionID, batchID interface{}, oldStatus, newStatus, actor string, payload map[string]interface{}) error {
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal outbox event payload: %w", err)
+	}
+	_, err = tx.Exec(`
+		INSERT INTO reconciliation_events (
+			id, event_type, transaction_id, batch_id, old_status, new_status, actor, payload, created_at, published_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8::jsonb, NOW(), NULL)
+	`, uuid.New().String(), eventType, transactionID, batchID, oldStatus, newStatus, actor, payloadJSON)
+	if err != nil {
+		return fmt.Errorf("failed to write outbox event: %w", err)
+	}
+	return nil
+}
+
+// OutboxDispatcher replays reconciliation_events rows ActionsHandler wrote
+// into webhook_deliveries, the same rows Delivery.recordDeliveries would
+// have produced had it received the event straight off Emitter's channel.
+// Run one per process alongside a DeliveryWorker - see cmd/api/main.go and
+// cmd/worker/main.go.
+type OutboxDispatcher struct {
+	DB           *sqlx.DB
+	Delivery     *DeliveryWorker
+	PollInterval time.Duration
+	BatchSize    int
+}
+
+// NewOutboxDispatcher builds an OutboxDispatcher with the repo's usual
+// production defaults; override fields on the returned value for tests or
+// local tuning.
+func NewOutboxDispatcher(db *sqlx.DB, delivery *DeliveryWorker) *OutboxDispatcher {
+	return &OutboxDispatcher{
+		DB:           db,
+		Delivery:     delivery,
+		PollInterval: 2 * time.Second,
+		BatchSize:    20,
+	}
+}
+
+// Start polls for unpublished reconciliation_events rows until ctx is
+// cancelled.
+func (d *OutboxDispatcher) Start(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		n, err := d.dispatchDue()
+		if err != nil {
+			log.Printf("Warning: failed to dispatch outbox events: %v", err)
+		}
+		if n == 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(d.PollInterval):
+			}
+		}
+	}
+}
+
+type outboxRow struct {
+	ID        string `db:"id"`
+	EventType string `db:"event_type"`
+	Payload   []byte `db:"payload"`
+}
+
+func (d *OutboxDispatcher) dispatchDue() (int, error) {
+	tx, err := d.DB.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var due []outboxRow
+	err = tx.Select(&due, `
+		SELECT id, event_type, payload
+		FROM reconciliation_events
+		WHERE published_at IS NULL
+		ORDER BY created_at ASC
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`, d.BatchSize)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to select unpublished outbox events: %w", err)
+	}
+	if len(due) == 0 {
+		return 0, nil
+	}
+
+	// Hold the FOR UPDATE SKIP LOCKED claim on these rows for the duration of
+	// recordDeliveries, and only mark published_at for the rows it returned
+	// no error for. A row recordDeliveries fails on stays unpublished and
+	// locked out of the next SKIP LOCKED poll only until this transaction
+	// ends, so it's retried rather than silently dropped. recordDeliveries
+	// fails only on the subscriber lookup or payload encoding; a failed
+	// insert for a single subscriber is logged and the row is still marked
+	// published.
+	published := make([]string, 0, len(due))
+	for _, row := range due {
+		var payload map[string]interface{}
+		if err := json.Unmarshal(row.Payload, &payload); err != nil {
+			log.Printf("Warning: failed to decode outbox event %s payload: %v", row.ID, err)
+			continue
+		}
+		if err := d.Delivery.recordDeliveries(Event{ID: row.ID, Type: row.EventType, Payload: payload}); err != nil {
+			log.Printf("Warning: failed to record deliveries for outbox event %s: %v", row.ID, err)
+			continue
+		}
+		published = append(published, row.ID)
+	}
+
+	if len(published) > 0 {
+		if _, err := tx.Exec(`UPDATE reconciliation_events SET published_at = NOW() WHERE id = ANY($1)`, pq.Array(published)); err != nil {
+			return 0, fmt.Errorf("failed to mark outbox events published: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit published outbox events: %w", err)
+	}
+	return len(published), nil
+}
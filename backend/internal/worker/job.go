@@ -1,37 +1,241 @@
 package worker
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"math"
+	"math/rand"
 	"os"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"payment-reconciliation-engine/backend/internal/cache"
+	"payment-reconciliation-engine/backend/internal/events"
+	"payment-reconciliation-engine/backend/internal/webhooks"
+)
+
+// PermanentJobError marks a ProcessJobFunc failure that retrying cannot fix
+// - a malformed statement schema, an invalid ID, anything wrong with the
+// input itself rather than the environment. failJob sends these straight to
+// status='dead_letter' instead of burning retry attempts on them.
+type PermanentJobError struct {
+	err error
+}
+
+// NewPermanentJobError wraps err so failJob classifies it as permanent.
+func NewPermanentJobError(err error) *PermanentJobError {
+	return &PermanentJobError{err: err}
+}
+
+func (e *PermanentJobError) Error() string { return e.err.Error() }
+func (e *PermanentJobError) Unwrap() error { return e.err }
+
+// isPermanent reports whether err (or anything it wraps) is a
+// PermanentJobError. Anything else - I/O errors, DB deadlocks, upstream
+// 5xx's - is treated as transient and retried with backoff.
+func isPermanent(err error) bool {
+	var permanent *PermanentJobError
+	return errors.As(err, &permanent)
+}
+
+// batchProgressDelta is one UpdateBatchProgress call queued for the
+// aggregator goroutine. "Delta" is a slight misnomer - BatchProgress already
+// carries running totals, not increments - but later deltas for the same
+// batch_id still coalesce to a single row, which is the point: many
+// per-row progress calls collapse into one flushed UPDATE.
+type batchProgressDelta struct {
+	BatchID  string
+	Progress BatchProgress
+}
+
+// flushRequest asks the aggregator to flush one batch's pending delta (if
+// any) immediately, used by SetBatchTotal and the terminal job paths so
+// they never race a buffered progress update that hasn't hit Postgres yet.
+type flushRequest struct {
+	batchID string
+	done    chan struct{}
+}
+
+// progressMetrics tracks the aggregator's behavior for observability.
+// DeltasReceived vs RowsFlushed is the coalesce ratio: e.g. 1000 deltas
+// flushed as 40 rows means each flush collapsed ~25 calls into one write.
+type progressMetrics struct {
+	DeltasReceived uint64
+	FlushCount     uint64
+	RowsFlushed    uint64
+	Dropped        uint64
+}
+
+// ProgressMetricsSnapshot is a point-in-time read of progressMetrics plus
+// the aggregator's current queue depth.
+type ProgressMetricsSnapshot struct {
+	DeltasReceived uint64
+	FlushCount     uint64
+	RowsFlushed    uint64
+	Dropped        uint64
+	QueueDepth     int
+	CoalesceRatio  float64 // DeltasReceived / RowsFlushed; 1.0 means no coalescing happened
+}
+
+// Job priority levels, higher values claimed first. Mirrors the kind of
+// tiering a queue shared between small interactive uploads and large
+// nightly ERP dumps needs so the latter can't starve the former.
+const (
+	PriorityBackfill    = -10
+	PriorityBatch       = 0
+	PriorityInteractive = 10
 )
 
 type Job struct {
 	ID        string    `db:"id"`
 	BatchID   string    `db:"batch_id"`
 	FilePath  string    `db:"file_path"`
+	// Format is the statement format ("csv", "ofx", "mt940", "camt053", "xlsx") the
+	// upload was tagged with, or empty to have the processor sniff it from
+	// FileContent's first bytes.
+	Format    string    `db:"format"`
+	// Type selects which entry of Worker.Handlers processes this job, and
+	// which registered JobPayload Payload decodes into. Empty on any job
+	// queued before this column existed - dispatch treats that the same as
+	// JobTypeCSVIngest, built from FilePath/Format directly, so an old
+	// queued row still runs after a deploy instead of failing to decode.
+	Type      string    `db:"type"`
+	// Payload is Type's JobPayload, encoded with whatever PayloadCodec
+	// enqueued it (JSONCodec unless the caller chose otherwise).
+	Payload        []byte `db:"payload"`
+	PayloadVersion int    `db:"payload_version"`
 	Status    string    `db:"status"`
+	// Priority ranks otherwise-eligible jobs against each other; higher is
+	// claimed first. See the Priority* constants.
+	Priority  int       `db:"priority"`
+	// ScheduledFor gates when a queued job becomes eligible to claim, so a
+	// job can be deferred (retry backoff, a delayed nightly sweep) without
+	// taking it out of the "queued" status.
+	ScheduledFor time.Time `db:"scheduled_for"`
 	Attempts  int       `db:"attempts"`
 	LastError *string   `db:"last_error"`
 	CreatedAt time.Time `db:"created_at"`
 	UpdatedAt time.Time `db:"updated_at"`
+
+	// Fine-grained lifecycle timestamps, distinct from the coarser
+	// created_at/updated_at: PulledAt/StartedAt mark when claimJob picked
+	// this job up and processing actually began, HeartbeatAt is bumped
+	// periodically by Heartbeat while processing runs, EndedAt is set once
+	// it reaches a terminal status, and TimeoutAt is the hard deadline
+	// claimJob assigned this attempt - recoverStaleJobs reclaims a job
+	// whose heartbeat has gone quiet OR whose timeout has simply elapsed,
+	// so a hung worker can't sit on a job for the full StaleThreshold
+	// before anyone notices.
+	PulledAt    sql.NullTime `db:"pulled_at"`
+	StartedAt   sql.NullTime `db:"started_at"`
+	HeartbeatAt sql.NullTime `db:"heartbeat_at"`
+	EndedAt     sql.NullTime `db:"ended_at"`
+	TimeoutAt   sql.NullTime `db:"timeout_at"`
 }
 
 type Worker struct {
 	DB                *sqlx.DB
+	Cache             *cache.Client // optional, nil unless REDIS_URL is set
 	PollInterval      time.Duration
 	StaleThreshold    time.Duration
 	MaxAttempts       int
+	// BaseBackoff, MaxBackoff, and BackoffFactor shape the delay before a
+	// failed job is retried: delay = min(MaxBackoff, BaseBackoff *
+	// BackoffFactor^attempts) + jitter. Ignored for permanent errors, which
+	// go straight to the dead letter queue instead of retrying.
+	BaseBackoff   time.Duration
+	MaxBackoff    time.Duration
+	BackoffFactor float64
+	// MaxJobDuration bounds how long a single claimed job is allowed to run
+	// before it's considered hung regardless of heartbeat activity; claimJob
+	// stamps timeout_at = NOW() + MaxJobDuration on every claim. One value
+	// for every job type for now - named per-worker rather than a package
+	// constant so a future job type could override it.
+	MaxJobDuration time.Duration
 	ProgressEvery     int
-	ProcessJobFunc    func(*Job) error // Will be set in Step 8
+
+	// JobBackend mirrors JOB_BACKEND: "postgres" (default) claims purely by
+	// polling, "redis" additionally subscribes to job_enqueued notifications
+	// (see listenForJobNotifications) so a freshly dispatched job is
+	// claimed immediately instead of waiting out QueueSweepInterval.
+	JobBackend string
+	// QueueSweepInterval is how long the claim loop sleeps when claimJob
+	// finds nothing and no notification wakes it early. On the postgres
+	// backend this is every worker's only way of finding a job, so it's
+	// short (PollInterval). On the redis backend notifications carry that
+	// load instead, so this can be much longer - it's just the fallback
+	// sweep that catches a job whose notification was dropped or published
+	// before this worker subscribed.
+	QueueSweepInterval time.Duration
+	// ProcessJobFunc is the legacy single-handler entry point, still used as
+	// a fallback by dispatch for csv.ingest jobs (pre-Type jobs, whose Type
+	// is "", included) when Handlers has nothing registered for csv.ingest.
+	// A job of any other type with no registered handler fails permanently.
+	// New job types should register through Handlers/RegisterHandler instead.
+	ProcessJobFunc    func(*Job) error
+
+	// Codec encodes/decodes every job's Payload column. Defaults to
+	// JSONCodec in NewWorker; set to GobCodec (or swap per-call via
+	// EnqueueTypedJob's codec argument) for payload-heavy job types.
+	Codec PayloadCodec
+
+	// Handlers dispatches a claimed job to the function that knows how to
+	// run its Type, keyed the same way the payload registry is. Populate
+	// via RegisterHandler rather than writing to the map directly.
+	Handlers map[string]JobHandler
+
+	// Events fans out fine-grained progress (stage + counters) to
+	// WebSocket subscribers as a job runs. Always non-nil - in-process
+	// only unless Cache is configured, in which case it's Redis-backed so
+	// a processor and a client's WebSocket connection can sit on different
+	// replicas. See PublishEvent.
+	Events *events.Bus
+
+	// Webhooks emits batch.completed/batch.failed events (see
+	// completeJob/failJob) for cmd/worker/main.go's DeliveryWorker to
+	// drain. Always non-nil, same as Events - set it to a shared Emitter
+	// if this process should deliver alongside another, or leave NewWorker's
+	// default if it should run its own DeliveryWorker.
+	Webhooks *webhooks.Emitter
+
+	// FlushInterval and FlushThreshold bound how long a progress delta can
+	// sit buffered: the aggregator goroutine flushes whichever comes first -
+	// FlushInterval elapsing, or the pending map reaching FlushThreshold
+	// distinct batches.
+	FlushInterval  time.Duration
+	FlushThreshold int
+
+	shuttingDown atomic.Bool
+	done         chan struct{}
+
+	mu          sync.Mutex
+	inFlightJob *Job
+
+	progressCh     chan batchProgressDelta
+	flushRequestCh chan flushRequest
+	progressDone   chan struct{}
+	metrics        progressMetrics
+
+	// wakeCh carries batch IDs from listenForJobNotifications to Start's
+	// claim loop, so a redis-backend worker sleeping out QueueSweepInterval
+	// wakes early instead of waiting for the next sweep.
+	wakeCh chan string
 }
 
+// notificationClaimTTL bounds how long a job_enqueued notification stays
+// claimed by the worker that reacted to it first, via
+// cache.TryClaimNotification. It only needs to outlast the time a healthy
+// worker takes to turn the notification into a claimJob attempt.
+const notificationClaimTTL = 5 * time.Minute
+
 func NewWorker(db *sqlx.DB) *Worker {
 	pollIntervalMs := 1000 // default
 	if ms := os.Getenv("JOB_POLL_INTERVAL_MS"); ms != "" {
@@ -47,26 +251,147 @@ func NewWorker(db *sqlx.DB) *Worker {
 		}
 	}
 
+	redisCache, enabled := cache.NewFromEnv()
+	if enabled {
+		log.Println("Redis progress cache enabled")
+	}
+
+	jobBackend := os.Getenv("JOB_BACKEND")
+	if jobBackend == "" {
+		jobBackend = "postgres"
+	}
+	pollInterval := time.Duration(pollIntervalMs) * time.Millisecond
+	queueSweepInterval := pollInterval
+	if jobBackend == "redis" && enabled {
+		queueSweepInterval = 2 * time.Minute
+		if ms := os.Getenv("JOB_QUEUE_SWEEP_INTERVAL_MS"); ms != "" {
+			if parsed, err := strconv.Atoi(ms); err == nil {
+				queueSweepInterval = time.Duration(parsed) * time.Millisecond
+			}
+		}
+	} else {
+		jobBackend = "postgres"
+	}
+
 	return &Worker{
 		DB:             db,
-		PollInterval:   time.Duration(pollIntervalMs) * time.Millisecond,
+		Cache:          redisCache,
+		PollInterval:   pollInterval,
+		JobBackend:         jobBackend,
+		QueueSweepInterval: queueSweepInterval,
 		StaleThreshold: 10 * time.Minute,
-		MaxAttempts:    1, // Simple: no retries for interview
+		MaxAttempts:    5,
+		BaseBackoff:    30 * time.Second,
+		MaxBackoff:     30 * time.Minute,
+		BackoffFactor:  2.0,
+		MaxJobDuration: 2 * time.Hour,
 		ProgressEvery:  progressEvery,
+		Codec:          JSONCodec,
+		Handlers:       make(map[string]JobHandler),
+		Events:         events.NewBus(redisCache),
+		Webhooks:       webhooks.NewEmitter(),
+		FlushInterval:  500 * time.Millisecond,
+		FlushThreshold: 50,
+		done:           make(chan struct{}),
+		progressCh:     make(chan batchProgressDelta, 256),
+		flushRequestCh: make(chan flushRequest),
+		progressDone:   make(chan struct{}),
+		wakeCh:         make(chan string, 64),
+	}
+}
+
+// execer is satisfied by both *sqlx.DB and *sqlx.Tx, so EnqueueJob can run
+// standalone (an admin endpoint enqueuing a backfill) or as part of a
+// caller's larger transaction (the upload handler, which inserts the batch
+// and job together).
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// EnqueueJob inserts a queued reconciliation_jobs row for batchID/filePath
+// at the given priority, eligible for claiming once runAt has passed. Both
+// the upload handler (PriorityInteractive, runAt=now) and an admin-driven
+// batch sweep or retry (PriorityBatch/PriorityBackfill, runAt possibly in
+// the future) share this single insert path so claimJob's ordering always
+// reflects every job the same way.
+//
+// This is the csv.ingest convenience form of EnqueueTypedJob: batchID and
+// filePath are still their own columns (not just folded into Payload)
+// because claimJob, checkpoints, and the admin jobs endpoints all key and
+// display on them directly.
+func EnqueueJob(db execer, batchID, filePath string, priority int, runAt time.Time) error {
+	return EnqueueTypedJob(db, batchID, CSVIngestPayload{FilePath: filePath}, priority, runAt, JSONCodec)
+}
+
+// EnqueueTypedJob inserts a queued reconciliation_jobs row for any
+// registered JobPayload type, encoded with codec at its registry-current
+// payload_version. batchID is carried in its own column regardless of
+// Type, since every job - rescans and exports included - is still scoped
+// to one reconciliation_batches row.
+func EnqueueTypedJob(db execer, batchID string, payload JobPayload, priority int, runAt time.Time, codec PayloadCodec) error {
+	jobType := payload.JobType()
+	data, err := EncodeJobPayload(payload, codec)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO reconciliation_jobs (batch_id, file_path, type, payload, payload_version, status, priority, scheduled_for, attempts)
+		VALUES ($1, $2, $3, $4, $5, 'queued', $6, $7, 0)
+	`, batchID, filePathOf(payload), jobType, data, PayloadVersion(jobType), priority, runAt)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue %q job: %w", jobType, err)
+	}
+	return nil
+}
+
+// filePathOf returns payload's FilePath for the one job type that still
+// has one (file_path is NOT NULL on reconciliation_jobs), or "" for every
+// other type.
+func filePathOf(payload JobPayload) string {
+	if csv, ok := payload.(CSVIngestPayload); ok {
+		return csv.FilePath
 	}
+	return ""
+}
+
+// JobHandler processes one claimed job given its already-decoded payload.
+type JobHandler func(job *Job, payload JobPayload) error
+
+// RegisterHandler associates jobType with the function that should process
+// a claimed job of that type. Call once per type during startup (see
+// cmd/worker/main.go) before Start(); dispatch looks Handlers up by
+// job.Type on every claim.
+func (w *Worker) RegisterHandler(jobType string, handler JobHandler) {
+	w.Handlers[jobType] = handler
 }
 
 func (w *Worker) Start() {
 	log.Println("Worker started")
+	log.Printf("Job backend: %s", w.JobBackend)
 	log.Printf("Poll interval: %v", w.PollInterval)
+	log.Printf("Queue sweep interval: %v", w.QueueSweepInterval)
 	log.Printf("Stale threshold: %v", w.StaleThreshold)
 	log.Printf("Max attempts: %d", w.MaxAttempts)
 
 	// Recover stale jobs on startup
 	w.recoverStaleJobs()
 
+	go w.runProgressAggregator()
+
+	if w.JobBackend == "redis" && w.Cache != nil {
+		go w.listenForJobNotifications()
+	}
+
+	defer close(w.done)
+
 	// Main polling loop
 	for {
+		if w.shuttingDown.Load() {
+			log.Println("Shutdown requested: no longer claiming new jobs")
+			return
+		}
+
 		job, err := w.claimJob()
 		if err != nil {
 			log.Printf("Error claiming job: %v", err)
@@ -75,22 +400,142 @@ func (w *Worker) Start() {
 		}
 
 		if job == nil {
-			// No jobs available, sleep
-			time.Sleep(w.PollInterval)
+			// No jobs claimable right now - wait for a notification to
+			// wake us early (redis backend) or just the sweep interval to
+			// elapse (postgres backend, or a dropped/missed notification).
+			select {
+			case <-w.wakeCh:
+			case <-time.After(w.QueueSweepInterval):
+			}
 			continue
 		}
 
+		w.mu.Lock()
+		w.inFlightJob = job
+		w.mu.Unlock()
+
 		// Process the job
 		w.processJob(job)
+
+		w.mu.Lock()
+		w.inFlightJob = nil
+		w.mu.Unlock()
+	}
+}
+
+// Shutdown stops the worker from claiming new jobs and waits for whatever
+// job is currently in flight to finish on its own, up to ctx's deadline.
+// If ctx expires first, the in-flight job's batch is marked failed with a
+// shutdown reason (rather than left stuck in "processing" forever) before
+// the DB connection is closed.
+func (w *Worker) Shutdown(ctx context.Context) error {
+	w.shuttingDown.Store(true)
+
+	select {
+	case <-w.done:
+		// Start's loop already returned: nothing was in flight, or it
+		// finished before ctx expired.
+	case <-ctx.Done():
+		w.mu.Lock()
+		job := w.inFlightJob
+		w.mu.Unlock()
+		if job != nil {
+			w.markShutdownFailure(job)
+		}
+	}
+
+	// Drain whatever progress deltas are still buffered so the last few
+	// rows a job processed before shutdown aren't lost.
+	close(w.progressCh)
+	select {
+	case <-w.progressDone:
+	case <-ctx.Done():
+		log.Printf("Warning: timed out waiting for progress aggregator to drain")
+	}
+
+	return w.DB.Close()
+}
+
+// markShutdownFailure marks a job (and its batch, if not already finished)
+// as failed because the worker was shut down mid-processing. The status
+// guards make this a no-op if processJob actually won the race and already
+// recorded a terminal outcome.
+func (w *Worker) markShutdownFailure(job *Job) {
+	const reason = "worker shut down before job completed"
+
+	w.flushBatchNow(job.BatchID)
+
+	_, err := w.DB.Exec(`
+		UPDATE reconciliation_jobs
+		SET status = 'failed', last_error = $1, ended_at = NOW(), updated_at = NOW()
+		WHERE id = $2 AND status = 'processing'
+	`, reason, job.ID)
+	if err != nil {
+		log.Printf("Error marking job failed during shutdown: %v", err)
+	}
+
+	_, err = w.DB.Exec(`
+		UPDATE reconciliation_batches
+		SET status = 'failed', completed_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status NOT IN ('completed', 'failed')
+	`, job.BatchID)
+	if err != nil {
+		log.Printf("Error marking batch failed during shutdown: %v", err)
+	}
+
+	w.publishTerminalSnapshot(job.BatchID, "failed")
+	w.publishTerminalEvent(job.BatchID, events.StageError, reason)
+	w.Webhooks.Emit(webhooks.EventBatchFailed, map[string]interface{}{
+		"batchId": job.BatchID,
+		"jobId":   job.ID,
+		"error":   reason,
+	})
+	log.Printf("Marked job/batch failed due to shutdown: id=%s, batch_id=%s", job.ID, job.BatchID)
+}
+
+// listenForJobNotifications runs only on the redis job backend. It
+// PSUBSCRIBEs to job_enqueued:* and pushes a wake to wakeCh for whichever
+// worker claims the notification via TryClaimNotification - the other
+// subscribed workers just let it pass, since QueueSweepInterval's periodic
+// claimJob attempt (or their own reaction to a future notification) still
+// covers them if this worker dies before actually claiming the job.
+func (w *Worker) listenForJobNotifications() {
+	sub := w.Cache.SubscribeJobEnqueued(context.Background())
+	defer sub.Close()
+
+	for msg := range sub.Channel() {
+		batchID := msg.Payload
+
+		claimed, err := w.Cache.TryClaimNotification(context.Background(), batchID, notificationClaimTTL)
+		if err != nil {
+			log.Printf("Warning: failed to claim job notification for batch %s: %v", batchID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		select {
+		case w.wakeCh <- batchID:
+		default:
+			// Claim loop is already awake and about to poll anyway.
+		}
 	}
 }
 
+// recoverStaleJobs reclaims jobs whose worker has gone quiet - either its
+// heartbeat hasn't moved in StaleThreshold (a wedged or crashed process), or
+// it simply overran the hard timeout claimJob assigned it at claim time.
+// Falls back to updated_at for jobs claimed before heartbeat_at existed.
 func (w *Worker) recoverStaleJobs() {
 	query := `
-		UPDATE reconciliation_jobs 
+		UPDATE reconciliation_jobs
 		SET status = 'queued', updated_at = NOW()
-		WHERE status = 'processing' 
-		AND updated_at < NOW() - $1::interval
+		WHERE status = 'processing'
+		AND (
+			COALESCE(heartbeat_at, updated_at) < NOW() - $1::interval
+			OR timeout_at < NOW()
+		)
 	`
 	result, err := w.DB.Exec(query, fmt.Sprintf("%d minutes", int(w.StaleThreshold.Minutes())))
 	if err != nil {
@@ -110,13 +555,22 @@ func (w *Worker) claimJob() (*Job, error) {
 	}
 	defer tx.Rollback()
 
-	// Find and lock a queued job (or stale processing job)
+	// Find and lock a queued job (or a processing job whose heartbeat has
+	// gone quiet or whose timeout has elapsed). A queued job only becomes
+	// eligible once scheduled_for has passed, so delayed/backoff-retry jobs
+	// sit in the table without being claimed early. Among eligible jobs,
+	// higher priority wins, then earlier schedule, then earlier creation -
+	// so a same-priority backlog still drains in arrival order.
 	query := `
-		SELECT id, batch_id, file_path, status, attempts, last_error, created_at, updated_at
+		SELECT id, batch_id, file_path, format, type, payload, payload_version, status, priority, scheduled_for, attempts, last_error, created_at, updated_at,
+		       pulled_at, started_at, heartbeat_at, ended_at, timeout_at
 		FROM reconciliation_jobs
-		WHERE status = 'queued'
-		   OR (status = 'processing' AND updated_at < NOW() - $1::interval)
-		ORDER BY created_at ASC
+		WHERE (status = 'queued' AND scheduled_for <= NOW())
+		   OR (status = 'processing' AND (
+		       COALESCE(heartbeat_at, updated_at) < NOW() - $1::interval
+		       OR timeout_at < NOW()
+		   ))
+		ORDER BY priority DESC, scheduled_for ASC, created_at ASC
 		LIMIT 1
 		FOR UPDATE SKIP LOCKED
 	`
@@ -130,15 +584,21 @@ func (w *Worker) claimJob() (*Job, error) {
 		return nil, fmt.Errorf("failed to query jobs: %w", err)
 	}
 
-	// Update job to processing
+	// Update job to processing, stamping a fresh claim/heartbeat/timeout so
+	// this attempt gets its own full MaxJobDuration window regardless of how
+	// long a previous attempt ran.
 	updateQuery := `
 		UPDATE reconciliation_jobs
 		SET status = 'processing',
 		    attempts = attempts + 1,
+		    pulled_at = NOW(),
+		    started_at = NOW(),
+		    heartbeat_at = NOW(),
+		    timeout_at = NOW() + $2::interval,
 		    updated_at = NOW()
 		WHERE id = $1
 	`
-	_, err = tx.Exec(updateQuery, job.ID)
+	_, err = tx.Exec(updateQuery, job.ID, fmt.Sprintf("%d seconds", int(w.MaxJobDuration.Seconds())))
 	if err != nil {
 		return nil, fmt.Errorf("failed to update job status: %w", err)
 	}
@@ -153,26 +613,19 @@ func (w *Worker) claimJob() (*Job, error) {
 
 func (w *Worker) processJob(job *Job) {
 	startTime := time.Now()
-	log.Printf("Processing job: id=%s, batch_id=%s", job.ID, job.BatchID)
+	log.Printf("Processing job: id=%s, batch_id=%s, type=%s", job.ID, job.BatchID, job.Type)
 
 	// Update batch status to processing if not already
 	_, err := w.DB.Exec(`
 		UPDATE reconciliation_batches
-		SET status = 'processing'
+		SET status = 'processing', updated_at = NOW()
 		WHERE id = $1 AND status = 'uploading'
 	`, job.BatchID)
 	if err != nil {
 		log.Printf("Warning: Failed to update batch status: %v", err)
 	}
 
-	// Process the job (Step 8 will implement actual CSV processing)
-	if w.ProcessJobFunc != nil {
-		err = w.ProcessJobFunc(job)
-	} else {
-		// Placeholder: just mark as done for now
-		log.Println("ProcessJobFunc not set - placeholder processing")
-		err = nil
-	}
+	err = w.dispatch(job)
 
 	duration := time.Since(startTime)
 
@@ -183,7 +636,51 @@ func (w *Worker) processJob(job *Job) {
 	}
 }
 
+// dispatch decodes job's payload and routes it to the Handlers entry
+// registered for its Type. A job with Type == "" predates this column
+// entirely - it carries everything csv.ingest needs directly in
+// FilePath/Format, so it's routed to the csv.ingest handler with a payload
+// synthesized from those columns rather than decoded from Payload, which
+// is empty for a job that old.
+func (w *Worker) dispatch(job *Job) error {
+	jobType := job.Type
+	var payload JobPayload
+	if jobType == "" {
+		jobType = JobTypeCSVIngest
+		payload = CSVIngestPayload{FilePath: job.FilePath, Format: job.Format}
+	} else {
+		decoded, err := DecodeJobPayload(job, w.codec())
+		if err != nil {
+			return NewPermanentJobError(err)
+		}
+		payload = decoded
+	}
+
+	if handler, ok := w.Handlers[jobType]; ok {
+		return handler(job, payload)
+	}
+
+	// Fall back to the legacy single-handler entry point for csv.ingest
+	// jobs enqueued before Handlers existed. Any other type with no
+	// registered handler is a configuration error, not something retrying
+	// will fix.
+	if jobType == JobTypeCSVIngest && w.ProcessJobFunc != nil {
+		return w.ProcessJobFunc(job)
+	}
+
+	return NewPermanentJobError(fmt.Errorf("no handler registered for job type %q", jobType))
+}
+
+func (w *Worker) codec() PayloadCodec {
+	if w.Codec != nil {
+		return w.Codec
+	}
+	return JSONCodec
+}
+
 func (w *Worker) completeJob(job *Job, duration time.Duration) {
+	w.flushBatchNow(job.BatchID)
+
 	tx, err := w.DB.Beginx()
 	if err != nil {
 		log.Printf("Error beginning transaction for job completion: %v", err)
@@ -195,6 +692,7 @@ func (w *Worker) completeJob(job *Job, duration time.Duration) {
 	_, err = tx.Exec(`
 		UPDATE reconciliation_jobs
 		SET status = 'completed',
+		    ended_at = NOW(),
 		    updated_at = NOW()
 		WHERE id = $1
 	`, job.ID)
@@ -207,7 +705,8 @@ func (w *Worker) completeJob(job *Job, duration time.Duration) {
 	_, err = tx.Exec(`
 		UPDATE reconciliation_batches
 		SET status = 'completed',
-		    completed_at = NOW()
+		    completed_at = NOW(),
+		    updated_at = NOW()
 		WHERE id = $1
 	`, job.BatchID)
 	if err != nil {
@@ -220,13 +719,111 @@ func (w *Worker) completeJob(job *Job, duration time.Duration) {
 		return
 	}
 
+	w.publishTerminalSnapshot(job.BatchID, "completed")
+	w.publishTerminalEvent(job.BatchID, events.StageComplete, "")
+	w.Webhooks.Emit(webhooks.EventBatchCompleted, map[string]interface{}{
+		"batchId": job.BatchID,
+		"jobId":   job.ID,
+	})
+
 	log.Printf("Job completed: id=%s, batch_id=%s, duration=%v", job.ID, job.BatchID, duration)
 }
 
+// publishTerminalSnapshot reloads the batch's current counters from Postgres
+// and publishes a final cache snapshot stamped with completedAt, so a poller
+// reading from Redis sees the same terminal state it would get from a
+// Postgres fallback.
+func (w *Worker) publishTerminalSnapshot(batchID, status string) {
+	if w.Cache == nil {
+		return
+	}
+
+	var batch struct {
+		ProcessedCount   int            `db:"processed_count"`
+		TotalTransactions sql.NullInt64 `db:"total_transactions"`
+		AutoMatchedCount int            `db:"auto_matched_count"`
+		NeedsReviewCount int            `db:"needs_review_count"`
+		UnmatchedCount   int            `db:"unmatched_count"`
+		CompletedAt      sql.NullTime   `db:"completed_at"`
+	}
+	err := w.DB.Get(&batch, `
+		SELECT processed_count, total_transactions, auto_matched_count, needs_review_count, unmatched_count, completed_at
+		FROM reconciliation_batches WHERE id = $1
+	`, batchID)
+	if err != nil {
+		log.Printf("Warning: Failed to reload batch for cache snapshot: %v", err)
+		return
+	}
+
+	var total *int
+	if batch.TotalTransactions.Valid {
+		t := int(batch.TotalTransactions.Int64)
+		total = &t
+	}
+	var completedAt *string
+	if batch.CompletedAt.Valid {
+		s := batch.CompletedAt.Time.UTC().Format(time.RFC3339)
+		completedAt = &s
+	}
+
+	w.publishSnapshot(batchID, status, BatchProgress{
+		Processed:   batch.ProcessedCount,
+		AutoMatched: batch.AutoMatchedCount,
+		NeedsReview: batch.NeedsReviewCount,
+		Unmatched:   batch.UnmatchedCount,
+	}, total, completedAt)
+}
+
+// publishTerminalEvent reloads batchID's current counters and emits a
+// terminal (Complete or Error) Event, the WebSocket-side counterpart to
+// publishTerminalSnapshot - unlike that one, it doesn't require Cache,
+// since Events works in-process too.
+func (w *Worker) publishTerminalEvent(batchID string, stage events.Stage, errMsg string) {
+	var batch struct {
+		ProcessedCount   int `db:"processed_count"`
+		AutoMatchedCount int `db:"auto_matched_count"`
+		NeedsReviewCount int `db:"needs_review_count"`
+		UnmatchedCount   int `db:"unmatched_count"`
+	}
+	err := w.DB.Get(&batch, `
+		SELECT processed_count, auto_matched_count, needs_review_count, unmatched_count
+		FROM reconciliation_batches WHERE id = $1
+	`, batchID)
+	if err != nil {
+		log.Printf("Warning: Failed to reload batch for terminal event: %v", err)
+		return
+	}
+
+	w.PublishEvent(events.Event{
+		BatchID:     batchID,
+		Stage:       stage,
+		Processed:   batch.ProcessedCount,
+		AutoMatched: batch.AutoMatchedCount,
+		NeedsReview: batch.NeedsReviewCount,
+		Unmatched:   batch.UnmatchedCount,
+		Error:       errMsg,
+	})
+}
+
+// backoffDelay computes the retry delay for a job that has already failed
+// attempts times: min(MaxBackoff, BaseBackoff * BackoffFactor^attempts), plus
+// up to 20% jitter so a burst of jobs that failed together don't all wake up
+// and retry in the same instant.
+func (w *Worker) backoffDelay(attempts int) time.Duration {
+	delay := time.Duration(float64(w.BaseBackoff) * math.Pow(w.BackoffFactor, float64(attempts)))
+	if delay > w.MaxBackoff {
+		delay = w.MaxBackoff
+	}
+	jitter := time.Duration(rand.Int63n(int64(delay)/5 + 1))
+	return delay + jitter
+}
+
 func (w *Worker) failJob(job *Job, err error, duration time.Duration) {
 	errorMsg := err.Error()
 	log.Printf("Job failed: id=%s, batch_id=%s, error=%s, duration=%v", job.ID, job.BatchID, errorMsg, duration)
 
+	w.flushBatchNow(job.BatchID)
+
 	tx, err2 := w.DB.Beginx()
 	if err2 != nil {
 		log.Printf("Error beginning transaction for job failure: %v", err2)
@@ -234,34 +831,58 @@ func (w *Worker) failJob(job *Job, err error, duration time.Duration) {
 	}
 	defer tx.Rollback()
 
-	// Check if we should retry
-	shouldRetry := job.Attempts+1 < w.MaxAttempts
+	permanent := isPermanent(err)
+	shouldRetry := !permanent && job.Attempts+1 < w.MaxAttempts
 
-	if shouldRetry {
-		// Re-queue the job
+	switch {
+	case shouldRetry:
+		delay := w.backoffDelay(job.Attempts)
 		_, err2 = tx.Exec(`
 			UPDATE reconciliation_jobs
 			SET status = 'queued',
+			    scheduled_for = NOW() + $1::interval,
+			    last_error = $2,
+			    updated_at = NOW()
+			WHERE id = $3
+		`, fmt.Sprintf("%d milliseconds", delay.Milliseconds()), errorMsg, job.ID)
+	case permanent:
+		// No amount of retrying fixes a bad statement schema or an invalid
+		// ID - send it to the dead letter queue for a human to inspect and
+		// requeue (or discard) via POST /admin/jobs/:id/requeue.
+		_, err2 = tx.Exec(`
+			UPDATE reconciliation_jobs
+			SET status = 'dead_letter',
 			    last_error = $1,
+			    ended_at = NOW(),
 			    updated_at = NOW()
 			WHERE id = $2
 		`, errorMsg, job.ID)
-	} else {
-		// Mark as failed permanently
+		if err2 == nil {
+			_, err2 = tx.Exec(`
+				UPDATE reconciliation_batches
+				SET status = 'failed',
+				    completed_at = NOW(),
+				    updated_at = NOW()
+				WHERE id = $1
+			`, job.BatchID)
+		}
+	default:
+		// Transient error, but retries are exhausted.
 		_, err2 = tx.Exec(`
 			UPDATE reconciliation_jobs
 			SET status = 'failed',
 			    last_error = $1,
+			    ended_at = NOW(),
 			    updated_at = NOW()
 			WHERE id = $2
 		`, errorMsg, job.ID)
 
-		// Mark batch as failed
 		if err2 == nil {
 			_, err2 = tx.Exec(`
 				UPDATE reconciliation_batches
 				SET status = 'failed',
-				    completed_at = NOW()
+				    completed_at = NOW(),
+				    updated_at = NOW()
 				WHERE id = $1
 			`, job.BatchID)
 		}
@@ -277,53 +898,421 @@ func (w *Worker) failJob(job *Job, err error, duration time.Duration) {
 		return
 	}
 
-	if shouldRetry {
+	switch {
+	case shouldRetry:
 		log.Printf("Job re-queued for retry: id=%s, attempts=%d", job.ID, job.Attempts+1)
-	} else {
-		log.Printf("Job failed permanently: id=%s, batch_id=%s", job.ID, job.BatchID)
+	case permanent:
+		w.publishTerminalSnapshot(job.BatchID, "failed")
+		w.publishTerminalEvent(job.BatchID, events.StageError, errorMsg)
+		w.Webhooks.Emit(webhooks.EventBatchFailed, map[string]interface{}{
+			"batchId": job.BatchID,
+			"jobId":   job.ID,
+			"error":   errorMsg,
+		})
+		log.Printf("Job sent to dead letter queue (permanent error): id=%s, batch_id=%s", job.ID, job.BatchID)
+	default:
+		w.publishTerminalSnapshot(job.BatchID, "failed")
+		w.publishTerminalEvent(job.BatchID, events.StageError, errorMsg)
+		w.Webhooks.Emit(webhooks.EventBatchFailed, map[string]interface{}{
+			"batchId": job.BatchID,
+			"jobId":   job.ID,
+			"error":   errorMsg,
+		})
+		log.Printf("Job failed permanently (retries exhausted): id=%s, batch_id=%s", job.ID, job.BatchID)
+	}
+}
+
+// BatchProgress carries the running counts/totals accumulated while a batch
+// is processed, used both for the Postgres UPDATE and the Redis snapshot.
+type BatchProgress struct {
+	Processed         int
+	AutoMatched       int
+	NeedsReview       int
+	Unmatched         int
+	AutoMatchedTotal  float64
+	NeedsReviewTotal  float64
+	UnmatchedTotal    float64
+}
+
+// Heartbeat bumps heartbeat_at for a claimed job, proving its worker is
+// still alive. ProcessJobFunc calls this alongside UpdateBatchProgress so a
+// CPU-bound stretch of matching (no DB writes for a while) doesn't look
+// indistinguishable from a hung process to recoverStaleJobs.
+func (w *Worker) Heartbeat(jobID string) error {
+	_, err := w.DB.Exec(`
+		UPDATE reconciliation_jobs
+		SET heartbeat_at = NOW()
+		WHERE id = $1 AND status = 'processing'
+	`, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to record heartbeat: %w", err)
+	}
+	return nil
+}
+
+// runProgressAggregator owns pending, the only goroutine allowed to touch
+// it, so UpdateBatchProgress/flushBatchNow never need to lock around batch
+// progress state - they just hand deltas and flush requests across a
+// channel. It flushes on whichever comes first: FlushInterval elapsing,
+// pending growing past FlushThreshold, or an explicit flushRequest, and
+// exits once progressCh is closed and drained (see Shutdown).
+func (w *Worker) runProgressAggregator() {
+	defer close(w.progressDone)
+
+	pending := make(map[string]BatchProgress)
+	ticker := time.NewTicker(w.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case delta, ok := <-w.progressCh:
+			if !ok {
+				w.flushPending(pending)
+				return
+			}
+			pending[delta.BatchID] = delta.Progress
+			w.mu.Lock()
+			w.metrics.DeltasReceived++
+			w.mu.Unlock()
+			if len(pending) >= w.FlushThreshold {
+				w.flushPending(pending)
+			}
+
+		case req := <-w.flushRequestCh:
+			// Drain whatever's already buffered first - it was enqueued by
+			// the same caller before it sent this flush request, so it
+			// belongs in the same flush rather than being left stranded.
+			for more := true; more; {
+				select {
+				case delta, ok := <-w.progressCh:
+					if !ok {
+						more = false
+						break
+					}
+					pending[delta.BatchID] = delta.Progress
+					w.mu.Lock()
+					w.metrics.DeltasReceived++
+					w.mu.Unlock()
+				default:
+					more = false
+				}
+			}
+			if _, ok := pending[req.batchID]; ok {
+				w.flushPending(pending)
+			}
+			close(req.done)
+
+		case <-ticker.C:
+			if len(pending) > 0 {
+				w.flushPending(pending)
+			}
+		}
+	}
+}
+
+// flushPending writes every batch currently buffered in pending to Postgres
+// as a single multi-row UPDATE, publishes a cache snapshot per batch, and
+// empties pending on success. Left untouched on error so the next tick or
+// flush request retries the same rows instead of silently losing them.
+func (w *Worker) flushPending(pending map[string]BatchProgress) {
+	if len(pending) == 0 {
+		return
+	}
+
+	values := make([]string, 0, len(pending))
+	args := make([]interface{}, 0, len(pending)*5)
+	argNum := 1
+	for batchID, progress := range pending {
+		values = append(values, fmt.Sprintf("($%d::uuid, $%d::int, $%d::int, $%d::int, $%d::int)",
+			argNum, argNum+1, argNum+2, argNum+3, argNum+4))
+		args = append(args, batchID, progress.Processed, progress.AutoMatched, progress.NeedsReview, progress.Unmatched)
+		argNum += 5
+	}
+
+	query := fmt.Sprintf(`
+		UPDATE reconciliation_batches AS b
+		SET processed_count = v.processed,
+		    auto_matched_count = v.auto_matched,
+		    needs_review_count = v.needs_review,
+		    unmatched_count = v.unmatched,
+		    updated_at = NOW()
+		FROM (VALUES %s) AS v(id, processed, auto_matched, needs_review, unmatched)
+		WHERE b.id = v.id
+	`, strings.Join(values, ", "))
+
+	if _, err := w.DB.Exec(query, args...); err != nil {
+		log.Printf("Warning: Failed to flush batch progress (%d batches): %v", len(pending), err)
+		return
+	}
+
+	w.mu.Lock()
+	w.metrics.FlushCount++
+	w.metrics.RowsFlushed += uint64(len(pending))
+	w.mu.Unlock()
+
+	for batchID, progress := range pending {
+		w.publishSnapshot(batchID, "processing", progress, nil, nil)
+		delete(pending, batchID)
+	}
+}
+
+// flushBatchNow round-trips through the aggregator goroutine to force
+// batchID's buffered progress (if any) to Postgres before returning. Used
+// right before SetBatchTotal's own write and before every terminal job
+// status change, so a completed/failed job never reports stale counters.
+func (w *Worker) flushBatchNow(batchID string) {
+	done := make(chan struct{})
+	select {
+	case w.flushRequestCh <- flushRequest{batchID: batchID, done: done}:
+		<-done
+	case <-w.progressDone:
+		// Aggregator already shut down (e.g. called during a second
+		// shutdown pass) - nothing left to flush.
+	}
+}
+
+// ProgressMetrics returns a point-in-time snapshot of the aggregator's
+// behavior, for surfacing on an ops/debug endpoint.
+func (w *Worker) ProgressMetrics() ProgressMetricsSnapshot {
+	w.mu.Lock()
+	snap := ProgressMetricsSnapshot{
+		DeltasReceived: w.metrics.DeltasReceived,
+		FlushCount:     w.metrics.FlushCount,
+		RowsFlushed:    w.metrics.RowsFlushed,
+		Dropped:        w.metrics.Dropped,
+	}
+	w.mu.Unlock()
+
+	snap.QueueDepth = len(w.progressCh)
+	if snap.RowsFlushed > 0 {
+		snap.CoalesceRatio = float64(snap.DeltasReceived) / float64(snap.RowsFlushed)
 	}
+	return snap
 }
 
-// UpdateBatchProgress updates batch counters (called during CSV processing)
+// UpdateBatchProgress queues batchID's latest counters for the aggregator
+// goroutine to flush, instead of writing to Postgres directly - many calls
+// in quick succession (one per ProgressEvery rows) collapse into a single
+// UPDATE per FlushInterval. The send is non-blocking: if progressCh is full
+// the delta is dropped rather than blocking CSV processing, since the next
+// call supersedes it anyway (BatchProgress carries running totals, not
+// increments).
+func (w *Worker) UpdateBatchProgress(batchID string, progress BatchProgress) error {
+	// Validate batchID is a valid UUID to prevent SQL injection
+	if _, err := uuid.Parse(batchID); err != nil {
+		return fmt.Errorf("invalid batch ID: %w", err)
+	}
+
+	select {
+	case w.progressCh <- batchProgressDelta{BatchID: batchID, Progress: progress}:
+	default:
+		w.mu.Lock()
+		w.metrics.Dropped++
+		w.mu.Unlock()
+		log.Printf("Warning: Dropped batch progress update for %s - aggregator queue full", batchID)
+	}
+	return nil
+}
+
+// SetBatchTotal sets total_transactions when processing completes. Flushes
+// any buffered progress for batchID first, so a reader that sees
+// total_transactions set also sees up-to-date counters rather than a stale
+// processed_count from before the last flush.
 // Uses direct query formatting to avoid prepared statement issues with Neon pooler
-func (w *Worker) UpdateBatchProgress(batchID string, processed, autoMatched, needsReview, unmatched int) error {
+func (w *Worker) SetBatchTotal(batchID string, total int) error {
 	// Validate batchID is a valid UUID to prevent SQL injection
 	if _, err := uuid.Parse(batchID); err != nil {
 		return fmt.Errorf("invalid batch ID: %w", err)
 	}
-	
+
+	w.flushBatchNow(batchID)
+
 	// Format query directly to avoid prepared statements (safe since we validate UUID and use integers)
 	// Use underlying *sql.DB to avoid sqlx's prepared statement handling
 	query := fmt.Sprintf(`
 		UPDATE reconciliation_batches
-		SET processed_count = %d,
-		    auto_matched_count = %d,
-		    needs_review_count = %d,
-		    unmatched_count = %d
+		SET total_transactions = %d,
+		    updated_at = NOW()
 		WHERE id = '%s'
-	`, processed, autoMatched, needsReview, unmatched, batchID)
-	
+	`, total, batchID)
+
 	_, err := w.DB.DB.Exec(query)
 	return err
 }
 
-// SetBatchTotal sets total_transactions when processing completes
+// BatchCheckpoint records the CSV data-row offset through which a batch's
+// rows have been durably committed, so a crashed/restarted worker can skip
+// straight past already-inserted rows instead of reprocessing the file from
+// the start. One row per batch_id, upserted on every successful flush.
+type BatchCheckpoint struct {
+	BatchID   string    `db:"batch_id"`
+	RowOffset int       `db:"row_offset"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+// LoadBatchCheckpoint returns the most recently saved checkpoint for a
+// batch, or nil if the batch has never flushed one (a fresh job, or one
+// still on its first batch).
+func (w *Worker) LoadBatchCheckpoint(batchID string) (*BatchCheckpoint, error) {
+	var checkpoint BatchCheckpoint
+	err := w.DB.Get(&checkpoint, `
+		SELECT batch_id, row_offset, updated_at
+		FROM batch_checkpoints
+		WHERE batch_id = $1
+	`, batchID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load batch checkpoint: %w", err)
+	}
+	return &checkpoint, nil
+}
+
+// SaveBatchCheckpoint upserts the row offset a batch has committed through,
+// and bumps reconciliation_batches' last_checkpoint_at/updated_at to match.
+// Called after every successful flushBatch commit.
 // Uses direct query formatting to avoid prepared statement issues with Neon pooler
-func (w *Worker) SetBatchTotal(batchID string, total int) error {
-	// Validate batchID is a valid UUID to prevent SQL injection
+func (w *Worker) SaveBatchCheckpoint(batchID string, rowOffset int) error {
 	if _, err := uuid.Parse(batchID); err != nil {
 		return fmt.Errorf("invalid batch ID: %w", err)
 	}
-	
-	// Format query directly to avoid prepared statements (safe since we validate UUID and use integers)
-	// Use underlying *sql.DB to avoid sqlx's prepared statement handling
+
 	query := fmt.Sprintf(`
+		INSERT INTO batch_checkpoints (batch_id, row_offset, updated_at)
+		VALUES ('%s', %d, NOW())
+		ON CONFLICT (batch_id) DO UPDATE
+		SET row_offset = EXCLUDED.row_offset, updated_at = EXCLUDED.updated_at
+	`, batchID, rowOffset)
+	if _, err := w.DB.DB.Exec(query); err != nil {
+		return fmt.Errorf("failed to save batch checkpoint: %w", err)
+	}
+
+	touchQuery := fmt.Sprintf(`
 		UPDATE reconciliation_batches
-		SET total_transactions = %d
+		SET last_checkpoint_at = NOW(), updated_at = NOW()
 		WHERE id = '%s'
-	`, total, batchID)
-	
-	_, err := w.DB.DB.Exec(query)
-	return err
+	`, batchID)
+	if _, err := w.DB.DB.Exec(touchQuery); err != nil {
+		return fmt.Errorf("failed to update batch checkpoint timestamp: %w", err)
+	}
+
+	return nil
+}
+
+// ListBatchesParams filters ListBatches. The zero value matches every batch.
+type ListBatchesParams struct {
+	// UpdatedAfter restricts results to batches touched since this time, so
+	// a dashboard can poll for what changed instead of refetching everything.
+	UpdatedAfter time.Time
+	// Limit caps the number of rows returned; defaults to 100.
+	Limit int
+}
+
+// BatchSummary is the subset of reconciliation_batches an incremental poll
+// needs to detect and render a changed batch.
+type BatchSummary struct {
+	ID                string
+	Status            string
+	ProcessedCount    int
+	TotalTransactions *int
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	CompletedAt       *time.Time
+}
+
+// ListBatches returns batches updated after params.UpdatedAfter, newest
+// first, mirroring the incremental-poll pattern UpdateBatchProgress/
+// publishSnapshot already use for a single batch so a UI dashboard can ask
+// "what changed since my last poll" instead of refetching all state.
+func (w *Worker) ListBatches(params ListBatchesParams) ([]BatchSummary, error) {
+	limit := params.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+
+	var rows []struct {
+		ID                string        `db:"id"`
+		Status            string        `db:"status"`
+		ProcessedCount    int           `db:"processed_count"`
+		TotalTransactions sql.NullInt64 `db:"total_transactions"`
+		CreatedAt         time.Time     `db:"created_at"`
+		UpdatedAt         time.Time     `db:"updated_at"`
+		CompletedAt       sql.NullTime  `db:"completed_at"`
+	}
+	err := w.DB.Select(&rows, `
+		SELECT id::text AS id, status::text AS status, processed_count,
+		       total_transactions, created_at, COALESCE(updated_at, created_at) AS updated_at, completed_at
+		FROM reconciliation_batches
+		WHERE COALESCE(updated_at, created_at) > $1
+		ORDER BY COALESCE(updated_at, created_at) DESC
+		LIMIT $2
+	`, params.UpdatedAfter, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list batches: %w", err)
+	}
+
+	summaries := make([]BatchSummary, 0, len(rows))
+	for _, r := range rows {
+		summary := BatchSummary{
+			ID:             r.ID,
+			Status:         r.Status,
+			ProcessedCount: r.ProcessedCount,
+			CreatedAt:      r.CreatedAt,
+			UpdatedAt:      r.UpdatedAt,
+		}
+		if r.TotalTransactions.Valid {
+			total := int(r.TotalTransactions.Int64)
+			summary.TotalTransactions = &total
+		}
+		if r.CompletedAt.Valid {
+			completedAt := r.CompletedAt.Time
+			summary.CompletedAt = &completedAt
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+// publishSnapshot writes the current progress to the Redis cache (if
+// enabled) and publishes it on the batch's update channel. Best-effort: a
+// cache failure here must never fail the caller, since Postgres remains the
+// source of truth.
+func (w *Worker) publishSnapshot(batchID, status string, progress BatchProgress, total *int, completedAt *string) {
+	if w.Cache == nil {
+		return
+	}
+
+	snap := cache.BatchSnapshot{
+		Status:            status,
+		ProcessedCount:    progress.Processed,
+		TotalTransactions: total,
+		AutoMatchedCount:  progress.AutoMatched,
+		NeedsReviewCount:  progress.NeedsReview,
+		UnmatchedCount:    progress.Unmatched,
+		AutoMatchedTotal:  progress.AutoMatchedTotal,
+		NeedsReviewTotal:  progress.NeedsReviewTotal,
+		UnmatchedTotal:    progress.UnmatchedTotal,
+		CompletedAt:       completedAt,
+		UpdatedAt:         time.Now().UTC().Format(time.RFC3339),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := w.Cache.SetSnapshot(ctx, batchID, snap); err != nil {
+		log.Printf("Warning: Failed to publish batch snapshot to cache: %v", err)
+	}
+}
+
+// PublishEvent fans a batch progress event out through Events, logging
+// (rather than returning) a failure - a dropped progress event isn't worth
+// failing a job over, since a WebSocket client can always fall back to
+// GetBatch for current state.
+func (w *Worker) PublishEvent(e events.Event) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := w.Events.Publish(ctx, e); err != nil {
+		log.Printf("Warning: Failed to publish batch event: %v", err)
+	}
 }
 
@@ -0,0 +1,119 @@
+package worker
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+)
+
+// JobPayload is the per-job-type data a reconciliation_jobs row carries in
+// its Payload column. The worker's claim/retry/backoff machinery never
+// looks inside one - only JobType, used to pick a payload type out of the
+// registry below and a Handler out of Worker.Handlers. The codec is the
+// Worker's own, not chosen per type.
+type JobPayload interface {
+	JobType() string
+}
+
+// PayloadCodec turns a JobPayload to and from bytes for Job.Payload.
+type PayloadCodec interface {
+	Encode(v interface{}) ([]byte, error)
+	Decode(data []byte, v interface{}) error
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Encode(v interface{}) ([]byte, error)     { return json.Marshal(v) }
+func (jsonCodec) Decode(data []byte, v interface{}) error  { return json.Unmarshal(data, v) }
+
+type gobCodec struct{}
+
+func (gobCodec) Encode(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Decode(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// JSONCodec is the default PayloadCodec - human-readable, so a payload
+// pasted next to last_error in a psql session during an incident is
+// readable as-is. GobCodec is available for a payload-heavy job type (e.g.
+// one that embeds a generated export file) where binary size matters more
+// than debuggability.
+var (
+	JSONCodec PayloadCodec = jsonCodec{}
+	GobCodec  PayloadCodec = gobCodec{}
+)
+
+// payloadType is one registry entry: how to build a zero-value JobPayload
+// for a job type, its current payload_version, and (if the struct's shape
+// has changed since v1) how to upgrade an older queued job's raw bytes
+// before decoding, so a job enqueued by yesterday's deploy doesn't fail to
+// decode against today's struct.
+type payloadType struct {
+	factory func() JobPayload
+	version int
+	migrate func(raw []byte, fromVersion int) ([]byte, error)
+}
+
+var payloadRegistry = map[string]payloadType{}
+
+// Register associates jobType with a JobPayload factory and its current
+// payload_version. migrate may be nil for a type whose payload has never
+// changed shape; otherwise it upgrades raw bytes queued at an older
+// version before DecodeJobPayload decodes them into the current struct.
+func Register(jobType string, version int, factory func() JobPayload, migrate func(raw []byte, fromVersion int) ([]byte, error)) {
+	payloadRegistry[jobType] = payloadType{factory: factory, version: version, migrate: migrate}
+}
+
+// DecodeJobPayload builds job.Type's registered JobPayload from
+// job.Payload, running the registered migration first if the job was
+// queued at an older payload_version than the type currently expects.
+func DecodeJobPayload(job *Job, codec PayloadCodec) (JobPayload, error) {
+	entry, ok := payloadRegistry[job.Type]
+	if !ok {
+		return nil, fmt.Errorf("no payload type registered for job type %q", job.Type)
+	}
+
+	raw := job.Payload
+	if job.PayloadVersion < entry.version {
+		if entry.migrate == nil {
+			return nil, fmt.Errorf("job type %q payload is version %d, handler expects %d, and no migration is registered",
+				job.Type, job.PayloadVersion, entry.version)
+		}
+		migrated, err := entry.migrate(raw, job.PayloadVersion)
+		if err != nil {
+			return nil, fmt.Errorf("failed to migrate %q payload from version %d: %w", job.Type, job.PayloadVersion, err)
+		}
+		raw = migrated
+	}
+
+	payload := entry.factory()
+	if err := codec.Decode(raw, payload); err != nil {
+		return nil, fmt.Errorf("failed to decode %q payload: %w", job.Type, err)
+	}
+	return payload, nil
+}
+
+// EncodeJobPayload is the inverse of DecodeJobPayload - it's a package
+// function rather than a JobPayload method so payload types stay plain
+// data structs with no encoding awareness of their own.
+func EncodeJobPayload(payload JobPayload, codec PayloadCodec) ([]byte, error) {
+	data, err := codec.Encode(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %q payload: %w", payload.JobType(), err)
+	}
+	return data, nil
+}
+
+// PayloadVersion returns the version the registry currently expects a
+// jobType's payload to be encoded at, or 0 if jobType isn't registered.
+func PayloadVersion(jobType string) int {
+	return payloadRegistry[jobType].version
+}
@@ -0,0 +1,58 @@
+package worker
+
+// Job type identifiers, shared between EnqueueJob callers and the
+// Worker.Handlers dispatch table. Unregistered strings are rejected by
+// DecodeJobPayload, so a typo here fails loudly at claim time rather than
+// silently never being handled (EnqueueTypedJob does not check the
+// registry).
+const (
+	JobTypeCSVIngest       = "csv.ingest"
+	JobTypeRematchBatch    = "rematch.batch"
+	JobTypeExportResults   = "export.results"
+	JobTypeRescanUnmatched = "rescan.unmatched"
+)
+
+// CSVIngestPayload is what a statement upload enqueues: which file to read
+// and what format to expect it in. FilePath/Format are also still columns
+// on Job itself (see dispatch's legacy fallback) - the payload mirrors
+// them so csv.ingest looks the same as every other job type to a handler.
+type CSVIngestPayload struct {
+	FilePath string `json:"filePath"`
+	Format   string `json:"format"`
+}
+
+func (CSVIngestPayload) JobType() string { return JobTypeCSVIngest }
+
+// RematchBatchPayload re-runs matching for transactions already ingested
+// under BatchID, without re-reading the source statement - for example
+// after a recalibration changes the scoring weights new matches use.
+type RematchBatchPayload struct {
+	BatchID string `json:"batchId"`
+}
+
+func (RematchBatchPayload) JobType() string { return JobTypeRematchBatch }
+
+// ExportResultsPayload generates an export of a batch's reconciliation
+// results in the requested format (e.g. "csv", "xlsx").
+type ExportResultsPayload struct {
+	BatchID string `json:"batchId"`
+	Format  string `json:"format"`
+}
+
+func (ExportResultsPayload) JobType() string { return JobTypeExportResults }
+
+// RescanUnmatchedPayload re-attempts matching for transactions still sitting
+// unmatched across a batch, picking up any invoices that have arrived since
+// the original ingest run.
+type RescanUnmatchedPayload struct {
+	BatchID string `json:"batchId"`
+}
+
+func (RescanUnmatchedPayload) JobType() string { return JobTypeRescanUnmatched }
+
+func init() {
+	Register(JobTypeCSVIngest, 1, func() JobPayload { return &CSVIngestPayload{} }, nil)
+	Register(JobTypeRematchBatch, 1, func() JobPayload { return &RematchBatchPayload{} }, nil)
+	Register(JobTypeExportResults, 1, func() JobPayload { return &ExportResultsPayload{} }, nil)
+	Register(JobTypeRescanUnmatched, 1, func() JobPayload { return &RescanUnmatchedPayload{} }, nil)
+}